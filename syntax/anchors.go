@@ -0,0 +1,102 @@
+package syntax
+
+// AnchorInfo describes where the matches of a regexp are anchored.
+type AnchorInfo struct {
+	// Start reports whether every match starts at the text beginning:
+	// `^abc`, `\Afoo|\Abar` or `(^a)+`.
+	Start bool
+
+	// End reports whether every match ends at the text end:
+	// `abc$`, `(?:foo|bar)\z` or `a\Z`.
+	//
+	// Note that `$` and `\Z` can also match before the final newline
+	// in most engines except Go.
+	End bool
+}
+
+// AnalyzeAnchors reports whether re is anchored at the text start, end or both.
+//
+// `\A`, `\z` and `\Z` are always anchors, while `^` and `$` are only anchors
+// if the m flag is not set. All alternation branches should be anchored
+// for the alternation to be anchored.
+//
+// The analysis is conservative: if the anchoring can't be proven,
+// like for `a?^b`, the pattern is reported as not anchored.
+func AnalyzeAnchors(re *Regexp) AnchorInfo {
+	multiline := flagEnabled(re.Flags, 'm', false)
+	return anchorsOf(&re.Expr, &multiline)
+}
+
+func anchorsOf(e *Expr, multiline *bool) AnchorInfo {
+	switch e.Op {
+	case OpCaret:
+		return AnchorInfo{Start: !*multiline}
+	case OpDollar:
+		return AnchorInfo{End: !*multiline}
+
+	case OpEscapeChar:
+		switch e.Args[0].Value {
+		case "A":
+			return AnchorInfo{Start: true}
+		case "z", "Z":
+			return AnchorInfo{End: true}
+		}
+
+	case OpFlagOnlyGroup:
+		*multiline = flagEnabled(e.Args[0].Value, 'm', *multiline)
+
+	case OpGroupWithFlags:
+		groupMultiline := flagEnabled(e.Args[1].Value, 'm', *multiline)
+		return anchorsOf(&e.Args[0], &groupMultiline)
+
+	case OpCapture, OpNamedCapture, OpGroup, OpAtomicGroup, OpNonGreedy, OpPossessive:
+		groupMultiline := *multiline
+		return anchorsOf(&e.Args[0], &groupMultiline)
+
+	case OpPlus:
+		// The first and the last iterations are anchored.
+		return anchorsOf(&e.Args[0], multiline)
+
+	case OpRepeat:
+		if min, _ := e.RepeatBounds(); min != 0 {
+			return anchorsOf(&e.Args[0], multiline)
+		}
+
+	case OpConcat:
+		return concatAnchors(e, multiline)
+
+	case OpAlt:
+		result := AnchorInfo{Start: true, End: true}
+		for i := range e.Args {
+			info := anchorsOf(&e.Args[i], multiline)
+			result.Start = result.Start && info.Start
+			result.End = result.End && info.End
+		}
+		return result
+	}
+
+	return AnchorInfo{}
+}
+
+// concatAnchors finds the anchors that precede all chars
+// and the anchors that follow all chars of the concatenation.
+// Zero-width expressions, like `\b` or `(?=x)`, are skipped.
+func concatAnchors(e *Expr, multiline *bool) AnchorInfo {
+	var result AnchorInfo
+	startKnown := false
+	for i := range e.Args {
+		info := anchorsOf(&e.Args[i], multiline)
+		_, max := exprWidth(e.Args[i])
+		zeroWidth := max == 0
+		if !startKnown {
+			result.Start = info.Start
+			startKnown = info.Start || !zeroWidth
+		}
+		if info.End {
+			result.End = true
+		} else if !zeroWidth {
+			result.End = false
+		}
+	}
+	return result
+}
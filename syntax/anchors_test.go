@@ -0,0 +1,58 @@
+package syntax
+
+import (
+	"testing"
+)
+
+func TestAnalyzeAnchors(t *testing.T) {
+	tests := []struct {
+		pattern string
+		flags   string
+		want    AnchorInfo
+	}{
+		{``, "", AnchorInfo{}},
+		{`abc`, "", AnchorInfo{}},
+		{`^abc`, "", AnchorInfo{Start: true}},
+		{`abc$`, "", AnchorInfo{End: true}},
+		{`^abc$`, "", AnchorInfo{Start: true, End: true}},
+		{`^`, "", AnchorInfo{Start: true}},
+		{`\Afoo\z`, "", AnchorInfo{Start: true, End: true}},
+		{`foo\Z`, "", AnchorInfo{End: true}},
+		{`\b^foo\b$(?#x)`, "", AnchorInfo{Start: true, End: true}},
+		{`(?=a)^a(?<=a)$`, "", AnchorInfo{Start: true, End: true}},
+
+		{`^foo|^bar`, "", AnchorInfo{Start: true}},
+		{`^foo|bar`, "", AnchorInfo{}},
+		{`foo$|bar\z`, "", AnchorInfo{End: true}},
+		{`^(?:foo|bar)$`, "", AnchorInfo{Start: true, End: true}},
+		{`(^foo|^bar)x`, "", AnchorInfo{Start: true}},
+		{`(?P<x>^a)(?:b$)`, "", AnchorInfo{Start: true, End: true}},
+
+		{`(^a)+`, "", AnchorInfo{Start: true}},
+		{`(?:a$){2,}`, "", AnchorInfo{End: true}},
+		{`(?:^a)*b`, "", AnchorInfo{}},
+		{`(?:^a){0,2}`, "", AnchorInfo{}},
+		{`a?^b`, "", AnchorInfo{}},
+		{`^a|`, "", AnchorInfo{}},
+		{`x^`, "", AnchorInfo{}},
+
+		{`(?m)^foo$`, "", AnchorInfo{}},
+		{`^foo$`, "m", AnchorInfo{}},
+		{`^foo(?m)$`, "", AnchorInfo{Start: true}},
+		{`(?m:^foo)$`, "", AnchorInfo{End: true}},
+		{`(?-m)^foo$`, "m", AnchorInfo{Start: true, End: true}},
+		{`(?:(?m)^a)$`, "", AnchorInfo{End: true}},
+		{`\Afoo\z`, "m", AnchorInfo{Start: true, End: true}},
+	}
+
+	p := NewParser(nil)
+	for _, test := range tests {
+		re, err := p.ParseFlags(test.pattern, test.flags)
+		if err != nil {
+			t.Fatalf("parse(%q): %v", test.pattern, err)
+		}
+		if have := AnalyzeAnchors(re); have != test.want {
+			t.Errorf("anchors(%q, %q):\nhave: %+v\nwant: %+v", test.pattern, test.flags, have, test.want)
+		}
+	}
+}
@@ -1,21 +1,210 @@
 package syntax
 
 import (
+	"errors"
+	"sort"
+	"strconv"
 	"strings"
 )
 
 type Regexp struct {
-	Pattern string
-	Expr    Expr
+	Pattern string `json:"pattern"`
+	Expr    Expr   `json:"expr"`
+
+	// Flags are the initial pattern flags passed to ParseFlags, like "ix".
+	// The pattern behaves as if it started with a `(?flags)` group.
+	//
+	// Print and the translators don't add the flags to their output.
+	Flags string `json:"flags,omitempty"`
+
+	// Comments maps the expression positions to the OpComment expressions
+	// that precede them. It's only filled when ParserOptions.AttachComments is set.
+	//
+	// Comments are looked up by Expr.Pos, so they stay attached
+	// to the expressions that are moved around by the AST rewrites.
+	// They're not encoded into JSON.
+	Comments map[Position][]Expr `json:"-"`
+
+	// QuantifierComments maps the quantifier expression positions to the
+	// free-spacing comments (OpComment) that are placed between the
+	// quantified expression and the quantifier itself, like in `a #c\n*`.
+	// It's filled by the parser, so Print can put them back.
+	// Like Comments, they're not encoded into JSON.
+	QuantifierComments map[Position][]Expr `json:"-"`
+
+	// captureIndexes maps the capture group positions to their indexes.
+	// It's only filled by the parser when the groups are not numbered
+	// in the order of their appearance, like the .NET named groups.
+	captureIndexes map[Position]int
+}
+
+// Clone returns a deep copy of re.
+//
+// Parser reuses its memory between Parse calls, so the result
+// is only valid until the next Parse call. Use Clone to keep it longer.
+func (re *Regexp) Clone() *Regexp {
+	return &Regexp{
+		Pattern:  re.Pattern,
+		Expr:     re.Expr.Clone(),
+		Flags:    re.Flags,
+		Comments: cloneComments(re.Comments),
+
+		QuantifierComments: cloneComments(re.QuantifierComments),
+
+		// The parser never modifies the previously created indexes map.
+		captureIndexes: re.captureIndexes,
+	}
+}
+
+func cloneComments(comments map[Position][]Expr) map[Position][]Expr {
+	if comments == nil {
+		return nil
+	}
+	clone := make(map[Position][]Expr, len(comments))
+	for pos, list := range comments {
+		clone[pos] = append([]Expr(nil), list...)
+	}
+	return clone
+}
+
+// CaptureGroup describes a single capturing group of the regexp.
+type CaptureGroup struct {
+	// Index is a 1-based group number.
+	Index int
+
+	// Name is a group name; it's empty for unnamed groups.
+	Name string
+
+	// Pos is the group location inside the pattern.
+	Pos Position
+
+	// Expr is the group expression (OpCapture or OpNamedCapture).
+	Expr *Expr
+}
+
+// CaptureGroups returns the re capturing groups ordered by their index.
+//
+// Groups are numbered by their opening parenthesis from left to right,
+// named groups share the numbering with the unnamed ones.
+// For the .NET dialect, the parser records its numbering rules instead:
+// named groups are numbered after the unnamed ones.
+// Non-capturing groups, like `(?:re)` and lookarounds, are not reported.
+//
+// The returned Expr pointers refer to the re tree.
+func (re *Regexp) CaptureGroups() []CaptureGroup {
+	return captureGroups(&re.Expr, re.captureIndexes)
+}
+
+func captureGroups(root *Expr, indexes map[Position]int) []CaptureGroup {
+	var groups []CaptureGroup
+	WalkExpr(root, func(e *Expr) bool {
+		if e.Op != OpCapture && e.Op != OpNamedCapture {
+			return true
+		}
+		group := CaptureGroup{Index: indexes[e.Pos], Pos: e.Pos, Expr: e}
+		if group.Index == 0 {
+			group.Index = len(groups) + 1
+		}
+		if e.Op == OpNamedCapture {
+			group.Name = e.Args[1].Value
+		}
+		groups = append(groups, group)
+		return true
+	})
+	sort.SliceStable(groups, func(i, j int) bool {
+		return groups[i].Index < groups[j].Index
+	})
+	return groups
 }
 
 type RegexpPCRE struct {
-	Pattern string
-	Expr    Expr
+	Pattern string `json:"pattern"`
+	Expr    Expr   `json:"expr"`
+
+	Source    string  `json:"source"`
+	Modifiers string  `json:"modifiers"`
+	Delim     [2]byte `json:"delim"`
+
+	// Flags are the Modifiers that have an inline flag equivalent, like "ix".
+	// See Regexp.Flags for more info.
+	Flags string `json:"flags,omitempty"`
+
+	// Comments are the attached comments; see Regexp.Comments for more info.
+	Comments map[Position][]Expr `json:"-"`
+
+	// QuantifierComments are the quantifier comments; see Regexp.QuantifierComments.
+	QuantifierComments map[Position][]Expr `json:"-"`
+
+	captureIndexes map[Position]int
+
+	// The fields below are derived from Modifiers.
+	// ParsePCRE reports an error for the unknown modifiers;
+	// see ParsePCRE for the accepted ones.
+
+	CaseInsensitive bool `json:"caseInsensitive,omitempty"` // i
+	Multiline       bool `json:"multiline,omitempty"`       // m
+	DotAll          bool `json:"dotAll,omitempty"`          // s
+	Extended        bool `json:"extended,omitempty"`        // x
+	Anchored        bool `json:"anchored,omitempty"`        // A
+	DollarEndOnly   bool `json:"dollarEndOnly,omitempty"`   // D
+	Ungreedy        bool `json:"ungreedy,omitempty"`        // U
+	Extra           bool `json:"extra,omitempty"`           // X
+	DupNames        bool `json:"dupNames,omitempty"`        // J
+	UTF             bool `json:"utf,omitempty"`             // u
+	NoAutoCapture   bool `json:"noAutoCapture,omitempty"`   // n
+}
+
+// setModifierFlags fills the modifier fields from re.Modifiers.
+func (re *RegexpPCRE) setModifierFlags() error {
+	for i := 0; i < len(re.Modifiers); i++ {
+		switch ch := re.Modifiers[i]; ch {
+		case 'i':
+			re.CaseInsensitive = true
+		case 'm':
+			re.Multiline = true
+		case 's':
+			re.DotAll = true
+		case 'x':
+			re.Extended = true
+		case 'A':
+			re.Anchored = true
+		case 'D':
+			re.DollarEndOnly = true
+		case 'U':
+			re.Ungreedy = true
+		case 'X':
+			re.Extra = true
+		case 'J':
+			re.DupNames = true
+		case 'u':
+			re.UTF = true
+		case 'n':
+			re.NoAutoCapture = true
+		case 'S':
+			// PHP study hint; it doesn't affect the pattern.
+		case ' ', '\n', '\r':
+			// PHP ignores whitespace after the closing delimiter.
+		default:
+			return errors.New("unknown modifier " + strconv.QuoteRune(rune(ch)))
+		}
+	}
+	return nil
+}
 
-	Source    string
-	Modifiers string
-	Delim     [2]byte
+// Clone returns a deep copy of re.
+// See Regexp.Clone for more info.
+func (re *RegexpPCRE) Clone() *RegexpPCRE {
+	clone := *re
+	clone.Expr = re.Expr.Clone()
+	clone.Comments = cloneComments(re.Comments)
+	clone.QuantifierComments = cloneComments(re.QuantifierComments)
+	return &clone
+}
+
+// CaptureGroups returns the re capturing groups ordered by their index.
+// See Regexp.CaptureGroups for more info.
+func (re *RegexpPCRE) CaptureGroups() []CaptureGroup {
+	return captureGroups(&re.Expr, re.captureIndexes)
 }
 
 func (re *RegexpPCRE) HasModifier(mod byte) bool {
@@ -24,33 +213,77 @@ func (re *RegexpPCRE) HasModifier(mod byte) bool {
 
 type Expr struct {
 	// The operations that this expression performs. See `operation.go`.
-	Op Operation
+	// It's encoded as a string, like "Concat", in JSON.
+	Op Operation `json:"op"`
+
+	Form Form `json:"form,omitempty"`
 
-	Form Form
+	// Negated is set for the OpEscapeUni and OpEscapeClass expressions
+	// that match the complement of the named class: `\PL`, `\P{L}`, `\p{^L}`, `\D`.
+	// `\P{^L}` is a double negation, so it's not negated.
+	Negated bool `json:"negated,omitempty"`
 
-	_ [2]byte // Reserved
+	_ [1]byte // Reserved
 
 	// Pos describes a source location inside regexp pattern.
-	Pos Position
+	Pos Position `json:"pos"`
 
 	// Args is a list of sub-expressions of this expression.
 	//
 	// See Operation constants documentation to learn how to
 	// interpret the particular expression args.
-	Args []Expr
+	Args []Expr `json:"args,omitempty"`
 
 	// Value holds expression textual value.
 	//
 	// Usually, that value is identical to src[Begin():End()],
 	// but this is not true for programmatically generated objects.
-	Value string
+	Value string `json:"value"`
 }
 
 // Begin returns expression leftmost offset.
-func (e Expr) Begin() uint16 { return e.Pos.Begin }
+func (e Expr) Begin() Offset { return e.Pos.Begin }
 
 // End returns expression rightmost offset.
-func (e Expr) End() uint16 { return e.Pos.End }
+func (e Expr) End() Offset { return e.Pos.End }
+
+// Clone returns a deep copy of e.
+//
+// The copy doesn't share Args memory with e,
+// so it's not affected by the subsequent Parse calls.
+func (e Expr) Clone() Expr {
+	if len(e.Args) == 0 {
+		e.Args = nil
+		return e
+	}
+	args := make([]Expr, len(e.Args))
+	for i, a := range e.Args {
+		args[i] = a.Clone()
+	}
+	e.Args = args
+	return e
+}
+
+// EqualExpr reports whether a and b are structurally identical.
+//
+// Expression positions are ignored, so `a|b` from `x(a|b)`
+// is equal to the `a|b` pattern.
+// Values are only compared for the leaf expressions as the
+// compound expression Value is defined by its args.
+func EqualExpr(a, b Expr) bool {
+	if a.Op != b.Op || a.Form != b.Form || a.Negated != b.Negated || len(a.Args) != len(b.Args) {
+		return false
+	}
+	if len(a.Args) == 0 {
+		return a.Value == b.Value
+	}
+	for i := range a.Args {
+		if !EqualExpr(a.Args[i], b.Args[i]) {
+			return false
+		}
+	}
+	return true
+}
 
 // LastArg returns expression last argument.
 //
@@ -59,6 +292,140 @@ func (e Expr) LastArg() Expr {
 	return e.Args[len(e.Args)-1]
 }
 
+// IsQuantifier reports whether e is a quantified expression,
+// like `x*`, `x{2,5}` or their non-greedy and possessive forms: `x*?`, `x++`.
+func (e Expr) IsQuantifier() bool {
+	switch e.Op {
+	case OpStar, OpPlus, OpQuestion, OpRepeat, OpNonGreedy, OpPossessive:
+		return true
+	default:
+		return false
+	}
+}
+
+// QuantifiedOperand returns the expression repeated by quantifier e:
+// `x` for `x*`, `x{2}` and `x+?`.
+//
+// Should only be called on expressions for which IsQuantifier is true.
+func (e Expr) QuantifiedOperand() Expr {
+	if e.Op == OpNonGreedy || e.Op == OpPossessive {
+		e = e.Args[0]
+	}
+	return e.Args[0]
+}
+
+// RepeatBounds returns the numeric bounds of the OpRepeat e:
+// `x{2,5}` is (2, 5) and `x{2}` is (2, 2).
+// Unbounded max, like in `x{2,}`, is reported as -1.
+//
+// The parser rejects malformed and overflowing bounds, so they're
+// only possible in the programmatically built expressions;
+// they're reported as (-1, -1).
+//
+// Should only be called on OpRepeat expressions.
+func (e Expr) RepeatBounds() (min, max int) {
+	return repeatBounds(e.Args[1].Value)
+}
+
+// repeatBounds is like parseRepeatBounds, but it reports
+// malformed bounds as (-1, -1) instead of an error.
+func repeatBounds(s string) (min, max int) {
+	min, max, err := parseRepeatBounds(s)
+	if err != nil {
+		return -1, -1
+	}
+	return min, max
+}
+
+// parseRepeatBounds parses `{min,max}` repetition into numeric bounds.
+// Unbounded max is reported as -1.
+// Vim-style `\{-min,max}` and BRE-style `\{min,max\}` forms are supported as well.
+//
+// The returned error wraps strconv.ErrRange if a bound overflows int
+// and strconv.ErrSyntax if the bounds are not decimal numbers.
+func parseRepeatBounds(s string) (min, max int, err error) {
+	s = strings.TrimPrefix(s, `\`)
+	s = strings.TrimPrefix(s, "{")
+	s = strings.TrimSuffix(s, "}")
+	s = strings.TrimSuffix(s, `\`)
+	s = strings.TrimPrefix(s, "-")
+	comma := strings.IndexByte(s, ',')
+	if comma < 0 {
+		if s == "" {
+			return 0, -1, nil // Vim `\{}` is like `*`
+		}
+		min, err = repeatCount(s)
+		return min, min, err
+	}
+	min, max = 0, -1
+	if s[:comma] != "" {
+		if min, err = repeatCount(s[:comma]); err != nil {
+			return 0, 0, err
+		}
+	}
+	if s[comma+1:] != "" {
+		if max, err = repeatCount(s[comma+1:]); err != nil {
+			return 0, 0, err
+		}
+	}
+	return min, max, nil
+}
+
+func repeatCount(s string) (int, error) {
+	for i := 0; i < len(s); i++ {
+		if !isDigit(s[i]) {
+			// Atoi accepts signs, but they're not valid here.
+			return 0, &strconv.NumError{Func: "Atoi", Num: s, Err: strconv.ErrSyntax}
+		}
+	}
+	return strconv.Atoi(s)
+}
+
+// IsGroup reports whether e is a parenthesized expression with a body,
+// like `(x)`, `(?:x)`, `(?i:x)` or `(?=x)`.
+// Flag-only groups like `(?i)` have no body, so they're not reported.
+func (e Expr) IsGroup() bool {
+	switch e.Op {
+	case OpCapture, OpNamedCapture, OpGroup, OpGroupWithFlags, OpAtomicGroup, OpAbsentGroup:
+		return true
+	default:
+		return e.IsLookaround()
+	}
+}
+
+// GroupBody returns the expression enclosed into the group e.
+//
+// Should only be called on expressions for which IsGroup is true.
+func (e Expr) GroupBody() Expr {
+	return e.Args[0]
+}
+
+// IsLookaround reports whether e is a lookahead or lookbehind assertion.
+func (e Expr) IsLookaround() bool {
+	switch e.Op {
+	case OpPositiveLookahead, OpNegativeLookahead, OpPositiveLookbehind, OpNegativeLookbehind:
+		return true
+	default:
+		return false
+	}
+}
+
+// IsAnchor reports whether e is a zero-width position assertion,
+// like `^`, `$`, `\A`, `\z` or `\b`.
+// Lookarounds are reported by IsLookaround instead.
+func (e Expr) IsAnchor() bool {
+	switch e.Op {
+	case OpCaret, OpDollar:
+		return true
+	case OpEscapeChar:
+		switch e.Args[0].Value {
+		case "A", "z", "Z", "b", "B", "G", "<", ">":
+			return true
+		}
+	}
+	return false
+}
+
 type Operation byte
 
 type Form byte
@@ -0,0 +1,283 @@
+package syntax
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestClone(t *testing.T) {
+	p := NewParser(nil)
+
+	re, err := p.Parse(`a(b|[c-d])+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := formatSyntax(re)
+	clone := re.Clone()
+	if have := formatSyntax(clone); have != want {
+		t.Fatalf("clone mismatch:\nhave: %s\nwant: %s", have, want)
+	}
+
+	// Both Regexp object and exprs memory are reused by the parser.
+	if _, err := p.Parse(`(?:x|y{2})*z`); err != nil {
+		t.Fatal(err)
+	}
+	if have := formatSyntax(clone); have != want {
+		t.Errorf("clone is modified by Parse:\nhave: %s\nwant: %s", have, want)
+	}
+
+	clone2 := clone.Clone()
+	clone2.Expr.Args[0].Value = "x"
+	if clone.Expr.Args[0].Value != "a" {
+		t.Errorf("clones share Args memory")
+	}
+}
+
+func TestClonePCRE(t *testing.T) {
+	p := NewParser(nil)
+
+	re, err := p.ParsePCRE(`/a+b/i`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	clone := re.Clone()
+	if _, err := p.ParsePCRE(`~x|y{2}~`); err != nil {
+		t.Fatal(err)
+	}
+	have := formatExprSyntax(&Regexp{Pattern: clone.Pattern, Expr: clone.Expr}, clone.Expr)
+	if have != `{(+ a) b}` {
+		t.Errorf("clone is modified by ParsePCRE: %s", have)
+	}
+	if clone.Modifiers != "i" || clone.Delim != [2]byte{'/', '/'} {
+		t.Errorf("clone PCRE fields mismatch: %q %q", clone.Modifiers, clone.Delim)
+	}
+}
+
+func TestEqualExpr(t *testing.T) {
+	tests := []struct {
+		x     string
+		y     string
+		equal bool
+	}{
+		{`a`, `a`, true},
+		{`a|b`, `a|b`, true},
+		{`(?:x)+[a-z]`, `(?:x)+[a-z]`, true},
+		{`a`, `b`, false},
+		{`ab`, `abc`, false},
+		{`a|b`, `b|a`, false},
+		{`a+`, `a*`, false},
+		{`a+?`, `a++`, false},
+		{`[a-z]`, `[a-y]`, false},
+		{`(?P<x>a)`, `(?<x>a)`, false},
+		{`\x41`, `\x{41}`, false},
+		{`(a)`, `(?:a)`, false},
+		{`\p{L}`, `\P{L}`, false},
+		{`\P{L}`, `\p{^L}`, false},
+		{`\d`, `\D`, false},
+	}
+
+	p := NewParser(nil)
+	for _, test := range tests {
+		x, err := p.Parse(test.x)
+		if err != nil {
+			t.Fatalf("parse(%q): %v", test.x, err)
+		}
+		xExpr := x.Expr.Clone()
+		y, err := p.Parse(test.y)
+		if err != nil {
+			t.Fatalf("parse(%q): %v", test.y, err)
+		}
+		if have := EqualExpr(xExpr, y.Expr); have != test.equal {
+			t.Errorf("equal(%q, %q): have %v, want %v", test.x, test.y, have, test.equal)
+		}
+	}
+
+	// Positions are ignored.
+	re, err := p.Parse(`x(a|b)`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	alt := re.Expr.Args[1].Args[0].Clone()
+	re, err = p.Parse(`a|b`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !EqualExpr(alt, re.Expr) {
+		t.Errorf("expected sub-expression to be equal to the pattern")
+	}
+}
+
+func TestCaptureGroups(t *testing.T) {
+	tests := []struct {
+		pattern string
+		want    []string
+	}{
+		{`abc`, nil},
+		{`(?:a)(?=b)(?i:c)`, nil},
+		{`(a)(b)`, []string{`1 (a)`, `2 (b)`}},
+		{`((a)(?:(b)))`, []string{`1 ((a)(?:(b)))`, `2 (a)`, `3 (b)`}},
+		{`(a)(?P<x>b)(?:c)(d)`, []string{`1 (a)`, `2 x (?P<x>b)`, `3 (d)`}},
+		{`(?<x>(?'y'a)|(b))`, []string{`1 x (?<x>(?'y'a)|(b))`, `2 y (?'y'a)`, `3 (b)`}},
+	}
+
+	p := NewParser(nil)
+	for _, test := range tests {
+		re, err := p.Parse(test.pattern)
+		if err != nil {
+			t.Fatalf("parse(%q): %v", test.pattern, err)
+		}
+		var have []string
+		for _, g := range re.CaptureGroups() {
+			if g.Expr.Pos != g.Pos {
+				t.Errorf("%q: group %d pos mismatch", test.pattern, g.Index)
+			}
+			s := strconv.Itoa(g.Index)
+			if g.Name != "" {
+				s += " " + g.Name
+			}
+			have = append(have, s+" "+test.pattern[g.Pos.Begin:g.Pos.End])
+		}
+		if !reflect.DeepEqual(have, test.want) {
+			t.Errorf("groups(%q):\nhave: %q\nwant: %q", test.pattern, have, test.want)
+		}
+	}
+}
+
+func TestCaptureGroupsDotNet(t *testing.T) {
+	tests := []struct {
+		pattern string
+		want    []string
+	}{
+		{`(a)(?<x>b)(c)`, []string{`1 (a)`, `2 (c)`, `3 x (?<x>b)`}},
+		{`(?<x>a)|(?<x>b)(?'y'c)`, []string{`1 x (?<x>a)`, `1 x (?<x>b)`, `2 y (?'y'c)`}},
+	}
+
+	p := NewParser(&ParserOptions{Dialect: DialectDotNet})
+	for _, test := range tests {
+		re, err := p.Parse(test.pattern)
+		if err != nil {
+			t.Fatalf("parse(%q): %v", test.pattern, err)
+		}
+		var have []string
+		for _, g := range re.CaptureGroups() {
+			if g.Expr.Pos != g.Pos {
+				t.Errorf("%q: group %d pos mismatch", test.pattern, g.Index)
+			}
+			s := strconv.Itoa(g.Index)
+			if g.Name != "" {
+				s += " " + g.Name
+			}
+			have = append(have, s+" "+test.pattern[g.Pos.Begin:g.Pos.End])
+		}
+		if !reflect.DeepEqual(have, test.want) {
+			t.Errorf("groups(%q):\nhave: %q\nwant: %q", test.pattern, have, test.want)
+		}
+	}
+}
+
+func TestCaptureGroupsUnnumbered(t *testing.T) {
+	re := &Regexp{Expr: Expr{Op: OpConcat, Args: []Expr{
+		{Op: OpCapture, Args: []Expr{{Op: OpChar, Value: "a"}}},
+		{Op: OpNamedCapture, Args: []Expr{{Op: OpChar, Value: "b"}, {Op: OpString, Value: "x"}}},
+	}}}
+	groups := re.CaptureGroups()
+	if len(groups) != 2 || groups[0].Index != 1 || groups[1].Index != 2 || groups[1].Name != "x" {
+		t.Errorf("unexpected groups: %+v", groups)
+	}
+}
+
+func TestExprPredicates(t *testing.T) {
+	tests := []struct {
+		pattern string
+		want    string
+		inner   string
+	}{
+		{`x`, ``, ``},
+		{`x*`, `quantifier`, `x`},
+		{`(?:ab){2,}`, `quantifier`, `(?:ab)`},
+		{`x+?`, `quantifier`, `x`},
+		{`[a-z]++`, `quantifier`, `[a-z]`},
+		{`(ab)`, `group`, `ab`},
+		{`(?P<name>a|b)`, `group`, `a|b`},
+		{`(?i:a)`, `group`, `a`},
+		{`(?>a)`, `group`, `a`},
+		{`(?i)`, ``, ``},
+		{`(?=a)`, `group lookaround`, `a`},
+		{`(?<!a)`, `group lookaround`, `a`},
+		{`^`, `anchor`, ``},
+		{`$`, `anchor`, ``},
+		{`\b`, `anchor`, ``},
+		{`\z`, `anchor`, ``},
+		{`\d`, ``, ``},
+	}
+
+	p := NewParser(nil)
+	for _, test := range tests {
+		re, err := p.Parse(test.pattern)
+		if err != nil {
+			t.Fatalf("parse(%q): %v", test.pattern, err)
+		}
+		e := re.Expr
+		var have []string
+		var inner Expr
+		if e.IsQuantifier() {
+			have = append(have, "quantifier")
+			inner = e.QuantifiedOperand()
+		}
+		if e.IsGroup() {
+			have = append(have, "group")
+			inner = e.GroupBody()
+		}
+		if e.IsLookaround() {
+			have = append(have, "lookaround")
+		}
+		if e.IsAnchor() {
+			have = append(have, "anchor")
+		}
+		if s := strings.Join(have, " "); s != test.want {
+			t.Errorf("predicates(%q): have %q, want %q", test.pattern, s, test.want)
+		}
+		if s := Print(&Regexp{Expr: inner}); s != test.inner {
+			t.Errorf("inner(%q): have %q, want %q", test.pattern, s, test.inner)
+		}
+	}
+}
+
+func TestRepeatBounds(t *testing.T) {
+	tests := []struct {
+		pattern string
+		min     int
+		max     int
+	}{
+		{`x{5}`, 5, 5},
+		{`x{2,5}`, 2, 5},
+		{`x{2,}`, 2, -1},
+		{`x{0,1}?`, 0, 1},
+	}
+
+	p := NewParser(nil)
+	for _, test := range tests {
+		re, err := p.Parse(test.pattern)
+		if err != nil {
+			t.Fatalf("parse(%q): %v", test.pattern, err)
+		}
+		e := re.Expr
+		if e.Op == OpNonGreedy {
+			e = e.Args[0]
+		}
+		min, max := e.RepeatBounds()
+		if min != test.min || max != test.max {
+			t.Errorf("bounds(%q): have (%d, %d), want (%d, %d)", test.pattern, min, max, test.min, test.max)
+		}
+	}
+
+	// The parser rejects such bounds, but they can be built by hand.
+	for _, bounds := range []string{`{x}`, `{+1}`, `{99999999999999999999}`} {
+		e := Expr{Op: OpRepeat, Args: []Expr{{Op: OpChar, Value: "x"}, {Op: OpString, Value: bounds}}}
+		if min, max := e.RepeatBounds(); min != -1 || max != -1 {
+			t.Errorf("bounds(%q): have (%d, %d), want (-1, -1)", bounds, min, max)
+		}
+	}
+}
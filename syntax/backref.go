@@ -0,0 +1,75 @@
+package syntax
+
+import (
+	"strconv"
+)
+
+// Backreference describes a `\N` backreference to a capturing group.
+type Backreference struct {
+	// Index is a referenced group number.
+	Index int
+
+	// Group is the referenced group.
+	// It's nil if there is no group with such index.
+	Group *CaptureGroup
+
+	// Pos is the backreference location inside the pattern.
+	Pos Position
+
+	// Expr is the backreference expression (OpEscapeOctal).
+	Expr *Expr
+}
+
+// Backreferences returns the re backreferences in the order of their appearance.
+// Every backreference is linked to the capture group it refers to.
+//
+// `\1`-`\9` escapes are always treated as backreferences;
+// `\NN` escapes are backreferences only if there are at least NN groups,
+// otherwise they're octal char codes.
+// Escapes inside char classes are never backreferences.
+//
+// The returned Expr pointers refer to the re tree.
+func (re *Regexp) Backreferences() []Backreference {
+	groups := re.CaptureGroups()
+	maxIndex := 0
+	if len(groups) != 0 {
+		maxIndex = groups[len(groups)-1].Index
+	}
+	var refs []Backreference
+	WalkExpr(&re.Expr, func(e *Expr) bool {
+		if e.Op == OpCharClass || e.Op == OpNegCharClass {
+			return false
+		}
+		index, ok := backrefIndex(e, maxIndex)
+		if !ok {
+			return true
+		}
+		ref := Backreference{Index: index, Pos: e.Pos, Expr: e}
+		for i := range groups {
+			if groups[i].Index == index {
+				ref.Group = &groups[i]
+				break
+			}
+		}
+		refs = append(refs, ref)
+		return true
+	})
+	return refs
+}
+
+// backrefIndex returns the group index referenced by the `\N` escape e.
+// maxIndex is the largest group index of the pattern.
+func backrefIndex(e *Expr, maxIndex int) (int, bool) {
+	if e.Op != OpEscapeOctal || e.Form != FormDefault {
+		return 0, false
+	}
+	v := e.Args[0].Value
+	if v == "" || v[0] == '0' {
+		return 0, false
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || (len(v) > 1 && n > maxIndex) {
+		return 0, false
+	}
+	return n, true
+}
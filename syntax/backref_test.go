@@ -0,0 +1,45 @@
+package syntax
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestBackreferences(t *testing.T) {
+	tests := []struct {
+		pattern string
+		want    []string
+	}{
+		{`abc\0`, nil},
+		{`(a)\1`, []string{`\1 => 1 (a)`}},
+		{`(a)(?P<x>b)\2\1`, []string{`\2 => 2 (?P<x>b)`, `\1 => 1 (a)`}},
+		{`\1(a)`, []string{`\1 => 1 (a)`}},
+		{`(a)\2`, []string{`\2 => <nil>`}},
+		{`(a)[\1]`, nil},
+		{`(a)\12`, nil},
+		{`(a)(b)(c)(d)(e)(f)(g)(h)(i)(j)(k)(l)\12`, []string{`\12 => 12 (l)`}},
+	}
+
+	p := NewParser(nil)
+	for _, test := range tests {
+		re, err := p.Parse(test.pattern)
+		if err != nil {
+			t.Fatalf("parse(%q): %v", test.pattern, err)
+		}
+		var have []string
+		for _, ref := range re.Backreferences() {
+			if ref.Expr.Pos != ref.Pos {
+				t.Errorf("%q: backreference %d pos mismatch", test.pattern, ref.Index)
+			}
+			target := "<nil>"
+			if ref.Group != nil {
+				target = fmt.Sprintf("%d %s", ref.Group.Index, test.pattern[ref.Group.Pos.Begin:ref.Group.Pos.End])
+			}
+			have = append(have, test.pattern[ref.Pos.Begin:ref.Pos.End]+" => "+target)
+		}
+		if !reflect.DeepEqual(have, test.want) {
+			t.Errorf("backreferences(%q):\nhave: %q\nwant: %q", test.pattern, have, test.want)
+		}
+	}
+}
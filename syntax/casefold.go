@@ -0,0 +1,282 @@
+package syntax
+
+import (
+	stdsyntax "regexp/syntax"
+	"strings"
+)
+
+// FoldMode selects the case folding rules used by ExpandCaseFolding.
+type FoldMode int
+
+const (
+	// FoldASCII only folds ASCII letters: `a` <=> `A`.
+	FoldASCII FoldMode = iota
+
+	// FoldUnicode uses Unicode simple case folding,
+	// so `k` is expanded to `[kKK]` (with U+212A Kelvin sign).
+	FoldUnicode
+)
+
+// ExpandCaseFolding returns a copy of re where case-insensitive
+// matching is expressed explicitly: the `i` flag is removed from
+// the flag groups and the chars it applies to are replaced by
+// the char classes that match all their case variants.
+//
+// For example, `(?i)ab[x-z]` is expanded to `[aA][bB][x-zX-Z]`.
+//
+// Char classes that contain shorthands (like `\w` and `\pL`) or POSIX classes
+// are expanded to the explicit ranges, so `(?i)\W` becomes a class that
+// lists all non-word chars. Like in regexp/syntax, the negated elements
+// are folded before the negation. Unknown class names can't be expanded,
+// so such expressions are wrapped into a `(?i:...)` group.
+//
+// Expressions positions refer to re.Pattern.
+// re is not modified.
+func ExpandCaseFolding(re *Regexp, mode FoldMode) *Regexp {
+	e := re.Expr.Clone()
+	f := caseFolder{mode: mode}
+	foldCase := flagEnabled(re.Flags, 'i', false)
+	f.fold(&e, &foldCase)
+	return &Regexp{Pattern: re.Pattern, Expr: e, Flags: removeFlag(re.Flags, 'i')}
+}
+
+type caseFolder struct {
+	mode FoldMode
+}
+
+func (f *caseFolder) fold(e *Expr, foldCase *bool) {
+	switch e.Op {
+	case OpFlagOnlyGroup:
+		*foldCase = flagEnabled(e.Args[0].Value, 'i', *foldCase)
+		flags := removeFlag(e.Args[0].Value, 'i')
+		if flags == "" {
+			*e = Expr{Op: OpConcat, Pos: e.Pos}
+			return
+		}
+		e.Args[0].Value = flags
+
+	case OpGroupWithFlags:
+		groupFoldCase := flagEnabled(e.Args[1].Value, 'i', *foldCase)
+		f.fold(&e.Args[0], &groupFoldCase)
+		flags := removeFlag(e.Args[1].Value, 'i')
+		if flags == "" {
+			*e = Expr{Op: OpGroup, Pos: e.Pos, Args: e.Args[:1]}
+			return
+		}
+		e.Args[1].Value = flags
+
+	case OpCapture, OpNamedCapture, OpGroup, OpAtomicGroup,
+		OpPositiveLookahead, OpNegativeLookahead, OpPositiveLookbehind, OpNegativeLookbehind:
+		groupFoldCase := *foldCase
+		f.fold(&e.Args[0], &groupFoldCase)
+
+	case OpConcat:
+		for i := range e.Args {
+			f.fold(&e.Args[i], foldCase)
+		}
+		args := make([]Expr, 0, len(e.Args))
+		for _, a := range e.Args {
+			if a.Op == OpConcat {
+				// Expanded literals and removed flag groups.
+				args = appendLiterals(args, a.Args...)
+				continue
+			}
+			args = appendLiterals(args, a)
+		}
+		e.Args = args
+
+	case OpLiteral:
+		if *foldCase {
+			f.foldChars(e, e.Args)
+		}
+
+	case OpQuote:
+		if *foldCase {
+			var chars []Expr
+			for _, r := range e.Args[0].Value {
+				c := stdLiteralChar(r, false)
+				c.Pos = e.Pos
+				chars = append(chars, c)
+			}
+			f.foldChars(e, chars)
+		}
+
+	case OpChar, OpEscapeMeta, OpEscapeChar, OpEscapeHex, OpEscapeOctal:
+		if *foldCase {
+			f.foldChar(e)
+		}
+
+	case OpCharClass, OpNegCharClass:
+		if *foldCase {
+			if isCharsClass(e) {
+				f.foldClass(e)
+			} else {
+				f.expandClass(e)
+			}
+		}
+
+	case OpEscapeClass, OpEscapeUni:
+		if *foldCase {
+			f.expandClass(e)
+		}
+
+	default:
+		for i := range e.Args {
+			f.fold(&e.Args[i], foldCase)
+		}
+	}
+}
+
+// foldChars replaces e with a concatenation of the folded chars.
+func (f *caseFolder) foldChars(e *Expr, chars []Expr) {
+	args := make([]Expr, 0, len(chars))
+	for _, c := range chars {
+		f.foldChar(&c)
+		args = appendLiterals(args, c)
+	}
+	if len(args) == 1 {
+		*e = args[0]
+		return
+	}
+	*e = Expr{Op: OpConcat, Pos: e.Pos, Args: args}
+}
+
+// foldChar replaces a single char expression with a char class
+// if that char has other case variants.
+func (f *caseFolder) foldChar(e *Expr) {
+	r, ok := exprRune(e)
+	if !ok {
+		return
+	}
+	extra := f.extraRanges([]rune{r, r})
+	if len(extra) == 0 {
+		return
+	}
+	*e = Expr{
+		Op:   OpCharClass,
+		Pos:  e.Pos,
+		Args: appendRangeExprs([]Expr{*e}, extra),
+	}
+}
+
+func (f *caseFolder) foldClass(e *Expr) {
+	var ranges []rune
+	for i := range e.Args {
+		a := &e.Args[i]
+		switch a.Op {
+		case OpCharRange:
+			lo, ok1 := exprRune(&a.Args[0])
+			hi, ok2 := exprRune(&a.Args[1])
+			if ok1 && ok2 && lo <= hi {
+				ranges = append(ranges, lo, hi)
+			}
+		case OpQuote:
+			for _, r := range a.Args[0].Value {
+				ranges = append(ranges, r, r)
+			}
+		default:
+			if r, ok := exprRune(a); ok {
+				ranges = append(ranges, r, r)
+			}
+		}
+	}
+	e.Args = appendRangeExprs(e.Args, f.extraRanges(ranges))
+}
+
+// expandClass replaces the class-like e with an explicit char class
+// that matches the same chars case-insensitively.
+// OpNegCharClass stays negated, the other classes become OpCharClass.
+func (f *caseFolder) expandClass(e *Expr) {
+	c := stdConverter{asciiFold: f.mode == FoldASCII}
+	op := OpCharClass
+	positive := *e
+	if e.Op == OpNegCharClass {
+		op = OpNegCharClass
+		positive.Op = OpCharClass
+	}
+	ranges := c.classRanges(&positive, stdsyntax.FoldCase|stdsyntax.ClassNL)
+	if len(c.errors) != 0 {
+		*e = Expr{
+			Op:   OpGroupWithFlags,
+			Pos:  e.Pos,
+			Args: []Expr{*e, {Op: OpString, Value: "i"}},
+		}
+		return
+	}
+	*e = Expr{Op: op, Pos: e.Pos, Args: appendRangeExprs(nil, ranges)}
+}
+
+// isCharsClass reports whether the e char class only consists of
+// the chars, char ranges and quotes.
+func isCharsClass(e *Expr) bool {
+	for i := range e.Args {
+		a := &e.Args[i]
+		switch a.Op {
+		case OpCharRange:
+			_, ok1 := exprRune(&a.Args[0])
+			_, ok2 := exprRune(&a.Args[1])
+			if !ok1 || !ok2 {
+				return false
+			}
+		case OpQuote:
+			// Always literal.
+		default:
+			if _, ok := exprRune(a); !ok {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// extraRanges returns the case variants of the ranges chars
+// that are not already included into the ranges.
+func (f *caseFolder) extraRanges(ranges []rune) []rune {
+	if len(ranges) == 0 {
+		return nil
+	}
+	orig := cleanRanges(append([]rune(nil), ranges...))
+	var folded []rune
+	if f.mode == FoldUnicode {
+		folded = appendFoldedRanges(append([]rune(nil), orig...))
+	} else {
+		folded = appendASCIIFoldedRanges(append([]rune(nil), orig...))
+	}
+	return subtractRanges(folded, orig)
+}
+
+// appendASCIIFoldedRanges is like appendFoldedRanges, but only ASCII letters are folded.
+func appendASCIIFoldedRanges(ranges []rune) []rune {
+	n := len(ranges)
+	for i := 0; i < n; i += 2 {
+		lo, hi := ranges[i], ranges[i+1]
+		if lo <= 'z' && hi >= 'a' {
+			ranges = append(ranges, maxRune(lo, 'a')-'a'+'A', minRune(hi, 'z')-'a'+'A')
+		}
+		if lo <= 'Z' && hi >= 'A' {
+			ranges = append(ranges, maxRune(lo, 'A')-'A'+'a', minRune(hi, 'Z')-'A'+'a')
+		}
+	}
+	return ranges
+}
+
+// removeFlag removes the flag from the `(?flags)` group flags.
+// If there are no flags left, an empty string is returned.
+func removeFlag(flags string, flag byte) string {
+	flags = strings.ReplaceAll(flags, string(flag), "")
+	return strings.TrimSuffix(flags, "-")
+}
+
+func minRune(x, y rune) rune {
+	if x < y {
+		return x
+	}
+	return y
+}
+
+func maxRune(x, y rune) rune {
+	if x > y {
+		return x
+	}
+	return y
+}
@@ -0,0 +1,99 @@
+package syntax
+
+import (
+	"regexp"
+	"testing"
+)
+
+func TestExpandCaseFolding(t *testing.T) {
+	tests := []struct {
+		pattern string
+		mode    FoldMode
+		want    string
+	}{
+		{`abc`, FoldASCII, `abc`},
+		{`(?i)a`, FoldASCII, `[aA]`},
+		{`(?i)a1b`, FoldASCII, `[aA]1[bB]`},
+		{`(?i)12`, FoldASCII, `12`},
+		{`x(?i:ab)y`, FoldASCII, `x(?:[aA][bB])y`},
+		{`(?is)a.`, FoldASCII, `(?s)[aA].`},
+		{`(?s-i)a`, FoldASCII, `(?s)a`},
+		{`a(?i)b(?-i)c`, FoldASCII, `a[bB]c`},
+		{`(?i)[a-c0-9_]`, FoldASCII, `[a-c0-9_A-C]`},
+		{`(?i)[^X-Z]`, FoldASCII, `[^X-Zx-z]`},
+		{`(?i)\x41\.`, FoldASCII, `[\x41a]\.`},
+		{`(?i)\Qa.\E`, FoldASCII, `[aA]\.`},
+		{`(?i)(a)|b`, FoldASCII, `([aA])|[bB]`},
+		{`(?i)ф`, FoldASCII, `ф`},
+		{`(?i)ф`, FoldUnicode, `[фФ]`},
+		{`(?i)k`, FoldUnicode, "[kK\u212A]"},
+		{`(?i)[[:upper:]]`, FoldASCII, `[A-Za-z]`},
+		{`(?i)[^\d]`, FoldASCII, `[^0-9]`},
+		{`(?i)\w`, FoldASCII, `[0-9A-Z_a-z]`},
+		{`(?i)\w`, FoldUnicode, "[0-9A-Z_a-z\u017F\u212A]"},
+		{`(?i)\W`, FoldASCII, `[\x{0}-/:-@\[-\^` + "`" + `\{-\x{10FFFF}]`},
+		{`(?i)\p{Foo}`, FoldASCII, `(?i:\p{Foo})`},
+	}
+
+	p := NewParser(nil)
+	for _, test := range tests {
+		re, err := p.Parse(test.pattern)
+		if err != nil {
+			t.Fatalf("parse(%q): %v", test.pattern, err)
+		}
+		have := Print(ExpandCaseFolding(re, test.mode))
+		if have != test.want {
+			t.Errorf("fold(%q):\nhave: %s\nwant: %s", test.pattern, have, test.want)
+		}
+	}
+}
+
+func TestExpandCaseFoldingFlags(t *testing.T) {
+	re, err := NewParser(nil).ParseFlags(`a(?-i)b`, "is")
+	if err != nil {
+		t.Fatal(err)
+	}
+	folded := ExpandCaseFolding(re, FoldASCII)
+	if have := Print(folded); have != `[aA]b` {
+		t.Errorf("fold: have %s, want [aA]b", have)
+	}
+	if folded.Flags != "s" {
+		t.Errorf("fold: have %q flags, want \"s\"", folded.Flags)
+	}
+}
+
+func TestExpandCaseFoldingMatch(t *testing.T) {
+	patterns := []string{
+		`(?i)hello, World!`,
+		`(?i)[a-z]+[^q]`,
+		`x(?i:ab)y(?i)z`,
+		`(?i)straße|(?-i)KELVIN`,
+		`(?i)[[:upper:]][[:^alpha:]]`,
+		`(?i)\P{Lu}\p{Ll}`,
+		`(?i)\w\W[\Wk][^\w]`,
+		`a((?i)[abc]\Qa.b\E(?i)|\w{1,3})??\p{Greek}(?s-i)`,
+	}
+	inputs := []string{
+		"Hello, world!", "HELLO, WORLD!", "abcQ", "ABCq", "xABYz", "xabyZ",
+		"STRAßE", "straSSe", "KELVIN", "kelvin", "\u212Aelvin",
+		"a1", "Kk", "s\u017F", "a.ſ k", "aſβ", "aAa.bγ", "aKs\u212Aβ",
+	}
+
+	for _, pattern := range patterns {
+		re, err := NewParser(nil).Parse(pattern)
+		if err != nil {
+			t.Fatalf("parse(%q): %v", pattern, err)
+		}
+		expanded := Print(ExpandCaseFolding(re, FoldUnicode))
+		have := regexp.MustCompile(expanded)
+		want := regexp.MustCompile(pattern)
+		for _, input := range inputs {
+			h := have.FindAllString(input, -1)
+			w := want.FindAllString(input, -1)
+			if len(h) != len(w) || (len(h) != 0 && h[0] != w[0]) {
+				t.Errorf("fold(%q) => %q match %q:\nhave: %q\nwant: %q",
+					pattern, expanded, input, h, w)
+			}
+		}
+	}
+}
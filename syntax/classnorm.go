@@ -0,0 +1,224 @@
+package syntax
+
+import (
+	"strings"
+)
+
+// ClassRedundancy describes a char class element that
+// duplicates or overlaps with the other elements of the same class.
+type ClassRedundancy struct {
+	// Pos is a span of the redundant class element.
+	Pos Position
+
+	// Text is the pattern part that is described by Pos.
+	Text string
+
+	Message string
+}
+
+// NormalizeCharClasses returns a copy of re where every char class
+// elements are sorted, deduplicated and merged: `[a-cb-z0-90]` => `[0-9a-z]`.
+// The class elements that duplicate or overlap with the preceding
+// elements of the same class are reported.
+//
+// Class shorthands (like `\d`) and POSIX classes are kept in their
+// original order before the merged ranges; the ranges that are
+// fully covered by them are removed: `[0-5\d]` => `[\d]`.
+// Only the ASCII part of the shorthands is taken into account,
+// so the result doesn't depend on the regexp engine Unicode rules.
+//
+// Expressions positions refer to re.Pattern.
+// re is not modified.
+func NormalizeCharClasses(re *Regexp) (*Regexp, []ClassRedundancy) {
+	n := classNormalizer{pattern: re.Pattern}
+	e := re.Expr.Clone()
+	WalkExpr(&e, func(e *Expr) bool {
+		if e.Op == OpCharClass || e.Op == OpNegCharClass {
+			n.normalize(e)
+			return false
+		}
+		return true
+	})
+	return &Regexp{Pattern: re.Pattern, Expr: e, Flags: re.Flags}, n.redundant
+}
+
+type classNormalizer struct {
+	pattern   string
+	redundant []ClassRedundancy
+}
+
+// classElem is a char class element along with the chars it matches.
+type classElem struct {
+	expr   *Expr
+	ranges []rune
+}
+
+func (n *classNormalizer) normalize(e *Expr) {
+	var literals []rune
+	var others []Expr
+	var othersRanges []rune
+	var prev []classElem
+	var covered []rune
+	runeExprs := make(map[rune]Expr)
+
+	for i := range e.Args {
+		a := &e.Args[i]
+		ranges, isLiteral := n.elemRanges(a, runeExprs)
+		if !isLiteral {
+			if n.isDuplicate(a, others) {
+				continue
+			}
+			others = append(others, *a)
+		}
+
+		if ranges != nil {
+			n.checkRedundancy(a, ranges, prev, covered)
+			prev = append(prev, classElem{expr: a, ranges: ranges})
+			covered = cleanRanges(append(covered, ranges...))
+		}
+		if isLiteral {
+			literals = append(literals, ranges...)
+		} else {
+			othersRanges = append(othersRanges, ranges...)
+		}
+	}
+
+	literals = subtractRanges(literals, othersRanges)
+
+	args := others
+	for i := 0; i < len(literals); i += 2 {
+		lo, hi := literals[i], literals[i+1]
+		loExpr := classRuneExpr(lo, runeExprs)
+		switch {
+		case lo == hi:
+			args = append(args, loExpr)
+		case lo+1 == hi:
+			args = append(args, loExpr, classRuneExpr(hi, runeExprs))
+		default:
+			hiExpr := classRuneExpr(hi, runeExprs)
+			args = append(args, Expr{
+				Op:   OpCharRange,
+				Pos:  Position{Begin: loExpr.Begin(), End: hiExpr.End()},
+				Args: []Expr{loExpr, hiExpr},
+			})
+		}
+	}
+	e.Args = args
+}
+
+// elemRanges returns the chars matched by the class element.
+// isLiteral reports whether the element can be merged with other
+// ranges; for the non-literal elements, ranges can be nil if they're unknown.
+//
+// The original char expressions are recorded into runeExprs.
+func (n *classNormalizer) elemRanges(e *Expr, runeExprs map[rune]Expr) (ranges []rune, isLiteral bool) {
+	switch e.Op {
+	case OpCharRange:
+		lo, ok1 := exprRune(&e.Args[0])
+		hi, ok2 := exprRune(&e.Args[1])
+		if !ok1 || !ok2 || lo > hi {
+			return nil, false
+		}
+		recordRuneExpr(runeExprs, lo, e.Args[0])
+		recordRuneExpr(runeExprs, hi, e.Args[1])
+		return []rune{lo, hi}, true
+
+	case OpQuote:
+		for _, r := range e.Args[0].Value {
+			ranges = append(ranges, r, r)
+		}
+		return cleanRanges(ranges), true
+
+	case OpEscapeClass:
+		if !e.Negated {
+			return perlClassRanges[strings.ToLower(e.Args[0].Value)], false
+		}
+
+	case OpPosixClass:
+		name := posixClassName(e)
+		if class, ok := posixClassRanges[name]; ok {
+			return class, false
+		}
+		return nil, false
+	}
+
+	r, ok := exprRune(e)
+	if !ok {
+		return nil, false
+	}
+	recordRuneExpr(runeExprs, r, *e)
+	return []rune{r, r}, true
+}
+
+func (n *classNormalizer) isDuplicate(e *Expr, others []Expr) bool {
+	for _, other := range others {
+		if EqualExpr(*e, other) {
+			n.report(e, "duplicates "+n.text(other.Pos))
+			return true
+		}
+	}
+	return false
+}
+
+func (n *classNormalizer) checkRedundancy(e *Expr, ranges []rune, prev []classElem, covered []rune) {
+	if len(subtractRanges(ranges, covered)) == 0 {
+		for _, elem := range prev {
+			if len(subtractRanges(ranges, elem.ranges)) == 0 {
+				n.report(e, "is already matched by "+n.text(elem.expr.Pos))
+				return
+			}
+		}
+		n.report(e, "is already matched by other class elements")
+		return
+	}
+	ranges = cleanRanges(append([]rune(nil), ranges...))
+	for _, elem := range prev {
+		if !equalRanges(subtractRanges(ranges, elem.ranges), ranges) {
+			n.report(e, "overlaps with "+n.text(elem.expr.Pos))
+			return
+		}
+	}
+}
+
+func (n *classNormalizer) report(e *Expr, message string) {
+	text := n.text(e.Pos)
+	n.redundant = append(n.redundant, ClassRedundancy{
+		Pos:     e.Pos,
+		Text:    text,
+		Message: text + " " + message,
+	})
+}
+
+func (n *classNormalizer) text(pos Position) string {
+	return patternText(n.pattern, pos)
+}
+
+// recordRuneExpr remembers the first expression that was used to express r.
+func recordRuneExpr(runeExprs map[rune]Expr, r rune, e Expr) {
+	if _, ok := runeExprs[r]; !ok {
+		runeExprs[r] = e
+	}
+}
+
+// classRuneExpr returns a char class element that matches r,
+// preferring the form that was used in the original pattern.
+func classRuneExpr(r rune, runeExprs map[rune]Expr) Expr {
+	e, ok := runeExprs[r]
+	if !ok || (e.Op == OpChar && strings.ContainsAny(e.Value, `-^[]\`)) {
+		// Chars that may change their meaning after reordering are escaped.
+		return stdLiteralChar(r, true)
+	}
+	return e
+}
+
+func equalRanges(x, y []rune) bool {
+	if len(x) != len(y) {
+		return false
+	}
+	for i := range x {
+		if x[i] != y[i] {
+			return false
+		}
+	}
+	return true
+}
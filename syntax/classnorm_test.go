@@ -0,0 +1,60 @@
+package syntax
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestNormalizeCharClasses(t *testing.T) {
+	tests := []struct {
+		pattern   string
+		want      string
+		redundant []string
+	}{
+		{`[abc]`, `[a-c]`, nil},
+		{`[a-cb-z0-90]`, `[0-9a-z]`, []string{
+			`b-z overlaps with a-c`,
+			`0 is already matched by 0-9`,
+		}},
+		{`[^zyx]`, `[^x-z]`, nil},
+		{`[aa]`, `[a]`, []string{`a is already matched by a`}},
+		{`[a-cd-f]x[\t\x{0A}]`, `[a-f]x[\t\x{0A}]`, nil},
+		{`[0-5\d]`, `[\d]`, []string{`\d overlaps with 0-5`}},
+		{`[\d0-5_]`, `[\d_]`, []string{`0-5 is already matched by \d`}},
+		{`[\d\w\d]`, `[\d\w]`, []string{
+			`\w overlaps with \d`,
+			`\d duplicates \d`,
+		}},
+		{`[[:alpha:]a-z\pL\pL]`, `[[:alpha:]\pL]`, []string{
+			`a-z is already matched by [:alpha:]`,
+			`\pL duplicates \pL`,
+		}},
+		{`[ab-cd]`, `[a-d]`, nil},
+		{`[a\Qab\E]`, `[ab]`, []string{`\Qab\E overlaps with a`}},
+		{`[a-cx-z]|[b]`, `[a-cx-z]|[b]`, nil},
+		{`[a^]`, `[\^a]`, nil},
+		{`[-*a]`, `[*\-a]`, nil},
+	}
+
+	p := NewParser(nil)
+	for _, test := range tests {
+		re, err := p.Parse(test.pattern)
+		if err != nil {
+			t.Fatalf("parse(%q): %v", test.pattern, err)
+		}
+		normalized, redundant := NormalizeCharClasses(re)
+		if have := Print(normalized); have != test.want {
+			t.Errorf("normalize(%q):\nhave: %s\nwant: %s", test.pattern, have, test.want)
+		}
+		var messages []string
+		for _, r := range redundant {
+			if r.Text != test.pattern[r.Pos.Begin:r.Pos.End] {
+				t.Errorf("normalize(%q): %q text doesn't match its pos", test.pattern, r.Text)
+			}
+			messages = append(messages, r.Message)
+		}
+		if !reflect.DeepEqual(messages, test.redundant) {
+			t.Errorf("normalize(%q) redundant:\nhave: %q\nwant: %q", test.pattern, messages, test.redundant)
+		}
+	}
+}
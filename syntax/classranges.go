@@ -0,0 +1,62 @@
+package syntax
+
+import (
+	stdsyntax "regexp/syntax"
+)
+
+// ClassRanges returns the sorted rune ranges matched by the class-like e
+// as a flat list of [lo, hi] pairs.
+//
+// e can be a char class (possibly negated), a POSIX class like `[:^digit:]`,
+// a class shorthand like `\d`, `\W` or `\pL`, or a single char expression.
+// Shorthands and POSIX classes are expanded using the regexp/syntax (RE2)
+// definitions, so `\d`, `\w`, `\s` and POSIX classes are ASCII-only.
+// Negated classes include the '\n' char unless it's listed explicitly.
+//
+// The unsupported class elements are reported as ErrUnsupported errors inside ErrorList.
+func ClassRanges(e *Expr) ([]rune, error) {
+	c := stdConverter{}
+	ranges := c.classRanges(e, stdsyntax.ClassNL)
+	if len(c.errors) != 0 {
+		return nil, c.errors
+	}
+	return ranges, nil
+}
+
+// ComplementClass returns the explicit ranges matched by the negated
+// char class e (OpNegCharClass) inside the [0, maxRune] chars space.
+//
+// Use unicode.MaxRune for the full Unicode space and unicode.MaxASCII
+// for the ASCII-only one: `[^\d]` over ASCII is [0 '/' ':' 0x7F].
+// The result doesn't contain negated sets, so it can be used by the
+// code that only works with the positive char classes.
+//
+// Errors are reported in the same way as by ClassRanges.
+func ComplementClass(e *Expr, maxRune rune) ([]rune, error) {
+	c := stdConverter{}
+	if e.Op != OpNegCharClass {
+		c.fail(e, "expected a negated char class, found "+e.Op.String())
+		return nil, c.errors
+	}
+	positive := *e
+	positive.Op = OpCharClass
+	ranges := negateRanges(c.classRanges(&positive, stdsyntax.ClassNL))
+	if len(c.errors) != 0 {
+		return nil, c.errors
+	}
+	return clipRanges(ranges, maxRune), nil
+}
+
+// clipRanges removes the chars that are greater than maxRune from the clean ranges.
+func clipRanges(ranges []rune, maxRune rune) []rune {
+	for i := 0; i < len(ranges); i += 2 {
+		if ranges[i] > maxRune {
+			return ranges[:i]
+		}
+		if ranges[i+1] > maxRune {
+			ranges[i+1] = maxRune
+			return ranges[:i+2]
+		}
+	}
+	return ranges
+}
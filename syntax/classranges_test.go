@@ -0,0 +1,132 @@
+package syntax
+
+import (
+	"fmt"
+	"testing"
+	"unicode"
+)
+
+func TestClassRanges(t *testing.T) {
+	tests := []struct {
+		pattern string
+		want    string
+	}{
+		{`a`, `[a a]`},
+		{`\x41`, `[A A]`},
+		{`\d`, `[0 9]`},
+		{`\w`, `[0 9 A Z _ _ a z]`},
+		{`\s`, "[\t \n \f \r    ]"},
+		{`\D`, fmt.Sprintf(`[%d / : %d]`, 0, unicode.MaxRune)},
+		{`[[:alpha:]]`, `[A Z a z]`},
+		{`[[:^digit:]]`, fmt.Sprintf(`[%d / : %d]`, 0, unicode.MaxRune)},
+		{`[[:xdigit:]_]`, `[0 9 A F _ _ a f]`},
+		{`[^\x00-\x{10FFFE}]`, fmt.Sprintf(`[%d %d]`, unicode.MaxRune, unicode.MaxRune)},
+		{`[\d\s[:upper:]x-z]`, "[\t \n \f \r     0 9 A Z x z]"},
+		{`\p{Greek}`, ""},
+	}
+
+	p := NewParser(nil)
+	for _, test := range tests {
+		re, err := p.Parse(test.pattern)
+		if err != nil {
+			t.Fatalf("parse(%q): %v", test.pattern, err)
+		}
+		ranges, err := ClassRanges(&re.Expr)
+		if err != nil {
+			t.Errorf("ranges(%q): %v", test.pattern, err)
+			continue
+		}
+		if test.want == "" {
+			if len(ranges) == 0 {
+				t.Errorf("ranges(%q): empty result", test.pattern)
+			}
+			continue
+		}
+		have := formatRanges(ranges)
+		if have != test.want {
+			t.Errorf("ranges(%q):\nhave: %s\nwant: %s", test.pattern, have, test.want)
+		}
+	}
+}
+
+func TestClassRangesErrors(t *testing.T) {
+	tests := []struct {
+		pattern string
+		want    string
+	}{
+		{`.`, `Dot can't be used as a char`},
+		{`[\pX]`, `unknown Unicode class X`},
+	}
+
+	p := NewParser(nil)
+	for _, test := range tests {
+		re, err := p.Parse(test.pattern)
+		if err != nil {
+			t.Fatalf("parse(%q): %v", test.pattern, err)
+		}
+		_, err = ClassRanges(&re.Expr)
+		have := "<nil>"
+		if err != nil {
+			have = err.Error()
+		}
+		if have != test.want {
+			t.Errorf("ranges(%q):\nhave: %s\nwant: %s", test.pattern, have, test.want)
+		}
+	}
+}
+
+// formatRanges prints ranges as runes, except for the large ones.
+func formatRanges(ranges []rune) string {
+	s := "["
+	for i, r := range ranges {
+		if i != 0 {
+			s += " "
+		}
+		if r > unicode.MaxASCII || r == 0 {
+			s += fmt.Sprint(int(r))
+		} else {
+			s += string(r)
+		}
+	}
+	return s + "]"
+}
+
+func TestComplementClass(t *testing.T) {
+	tests := []struct {
+		pattern string
+		maxRune rune
+		want    string
+	}{
+		{`[^\d]`, unicode.MaxASCII, "[0 / : \x7F]"},
+		{`[^\d]`, unicode.MaxRune, fmt.Sprintf(`[%d / : %d]`, 0, unicode.MaxRune)},
+		{`[^\x00-\x7F]`, unicode.MaxASCII, `[]`},
+		{`[^\x00-\x7F]`, unicode.MaxRune, fmt.Sprintf(`[128 %d]`, unicode.MaxRune)},
+		{`[^a-z\W]`, unicode.MaxASCII, `[0 9 A Z _ _]`},
+		{`[^[:^alpha:]]`, unicode.MaxRune, `[A Z a z]`},
+		{`[^\x{80}-\x{10FFFF}\x00-@]`, unicode.MaxRune, "[A \x7F]"},
+	}
+
+	p := NewParser(nil)
+	for _, test := range tests {
+		re, err := p.Parse(test.pattern)
+		if err != nil {
+			t.Fatalf("parse(%q): %v", test.pattern, err)
+		}
+		ranges, err := ComplementClass(&re.Expr, test.maxRune)
+		if err != nil {
+			t.Errorf("complement(%q): %v", test.pattern, err)
+			continue
+		}
+		if have := formatRanges(ranges); have != test.want {
+			t.Errorf("complement(%q):\nhave: %s\nwant: %s", test.pattern, have, test.want)
+		}
+	}
+
+	re, err := p.Parse(`[a]`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ComplementClass(&re.Expr, unicode.MaxRune); err == nil {
+		t.Errorf("expected an error for a non-negated class")
+	}
+}
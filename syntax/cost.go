@@ -0,0 +1,128 @@
+package syntax
+
+import (
+	"math"
+)
+
+// maxProgramSize is a ProgramSize saturation limit.
+const maxProgramSize = 1 << 30
+
+// Cost is an estimated regexp compilation and matching cost.
+type Cost struct {
+	// ProgramSize is an estimated number of instructions in the
+	// compiled program (like regexp/syntax Prog).
+	// Counted repetitions are expanded, so `(a{100}){100}` is large.
+	// The value is saturated at 1<<30.
+	ProgramSize int
+
+	// Blowup is the worst-case matching time class for the
+	// backtracking engines, see CheckBacktracking.
+	Blowup Blowup
+}
+
+// EstimateCost estimates the re compilation and matching cost.
+//
+// It can be used to reject the overly expensive user-supplied
+// patterns before compiling them.
+func EstimateCost(re *Regexp) Cost {
+	cost := Cost{ProgramSize: addSize(programSize(&re.Expr), 4)}
+	for _, risk := range CheckBacktracking(re) {
+		if risk.Blowup > cost.Blowup {
+			cost.Blowup = risk.Blowup
+		}
+	}
+	return cost
+}
+
+// WorstCaseSteps returns an estimated number of steps a backtracking
+// engine may perform while matching an input of n chars.
+//
+// For the linear patterns it's ProgramSize*n, polynomial ones are
+// estimated as ProgramSize*n^2 and exponential ones as ProgramSize*2^n.
+// Large results are reported as +Inf.
+// Linear-time engines, like RE2, never exceed the ProgramSize*n steps.
+func (c Cost) WorstCaseSteps(n int) float64 {
+	size := float64(c.ProgramSize)
+	x := float64(n)
+	switch c.Blowup {
+	case BlowupPolynomial:
+		return size * x * x
+	case BlowupExponential:
+		return size * math.Pow(2, x)
+	default:
+		return size * x
+	}
+}
+
+// programSize returns an estimated number of instructions needed to compile e.
+func programSize(e *Expr) int {
+	switch e.Op {
+	case OpConcat:
+		size := 0
+		for i := range e.Args {
+			size = addSize(size, programSize(&e.Args[i]))
+		}
+		return size
+
+	case OpAlt:
+		size := len(e.Args) - 1 // Alt instructions
+		for i := range e.Args {
+			size = addSize(size, programSize(&e.Args[i]))
+		}
+		return size
+
+	case OpLiteral:
+		return len(e.Args)
+	case OpQuote:
+		return len([]rune(e.Args[0].Value))
+
+	case OpComment, OpFlagOnlyGroup:
+		return 0
+
+	case OpStar, OpPlus, OpQuestion:
+		return addSize(programSize(&e.Args[0]), 1)
+
+	case OpRepeat:
+		body := programSize(&e.Args[0])
+		min, max := e.RepeatBounds()
+		if max == -1 {
+			// x{n,} => xxx...x+
+			return addSize(mulSize(body, maxInt(min, 1)), 1)
+		}
+		// x{n,m} => xxx(x(x)?)?
+		return addSize(mulSize(body, max), max-min)
+
+	case OpNonGreedy, OpPossessive, OpGroup, OpGroupWithFlags, OpAtomicGroup:
+		return programSize(&e.Args[0])
+
+	case OpCapture, OpNamedCapture,
+		OpPositiveLookahead, OpNegativeLookahead, OpPositiveLookbehind, OpNegativeLookbehind:
+		// Group start and end instructions.
+		return addSize(programSize(&e.Args[0]), 2)
+
+	default:
+		// Chars, classes, anchors and other single instruction ops.
+		return 1
+	}
+}
+
+func addSize(x, y int) int {
+	if x+y > maxProgramSize {
+		return maxProgramSize
+	}
+	return x + y
+}
+
+func mulSize(x, y int) int {
+	if x != 0 && y > maxProgramSize/x {
+		return maxProgramSize
+	}
+	return addSize(x*y, 0)
+}
+
+func maxInt(x, y int) int {
+	if x > y {
+		return x
+	}
+	return y
+}
@@ -0,0 +1,80 @@
+package syntax
+
+import (
+	"math"
+	stdsyntax "regexp/syntax"
+	"testing"
+)
+
+func TestEstimateCostProgramSize(t *testing.T) {
+	patterns := []string{
+		`a`,
+		`abc`,
+		`ab|cd|ef`,
+		`(a)(b)`,
+		`x*y+z?`,
+		`[a-z]+\d{3}`,
+		`(?:ab){10}`,
+		`a{2,5}`,
+		`a{3,}`,
+		`^(\w+)@(\w+)\.com$`,
+		`((a{10}){10}){2}`,
+	}
+
+	for _, pattern := range patterns {
+		re, err := NewParser(nil).Parse(pattern)
+		if err != nil {
+			t.Fatalf("parse(%q): %v", pattern, err)
+		}
+		stdre, err := stdsyntax.Parse(pattern, stdsyntax.Perl)
+		if err != nil {
+			t.Fatalf("std parse(%q): %v", pattern, err)
+		}
+		prog, err := stdsyntax.Compile(stdre.Simplify())
+		if err != nil {
+			t.Fatalf("std compile(%q): %v", pattern, err)
+		}
+		have := EstimateCost(re).ProgramSize
+		want := len(prog.Inst)
+		if have < want/2 || have > want*2 {
+			t.Errorf("cost(%q): program size %d is too far from %d", pattern, have, want)
+		}
+	}
+}
+
+func TestEstimateCost(t *testing.T) {
+	tests := []struct {
+		pattern string
+		blowup  Blowup
+		steps   float64
+	}{
+		{`abc`, BlowupLinear, 7 * 10},
+		{`.*.*`, BlowupPolynomial, 8 * 10 * 10},
+		{`(a+)+`, BlowupExponential, 9 * 1024},
+	}
+
+	for _, test := range tests {
+		re, err := NewParser(nil).Parse(test.pattern)
+		if err != nil {
+			t.Fatalf("parse(%q): %v", test.pattern, err)
+		}
+		cost := EstimateCost(re)
+		if cost.Blowup != test.blowup {
+			t.Errorf("cost(%q): blowup mismatch: have %s, want %s", test.pattern, cost.Blowup, test.blowup)
+		}
+		if steps := cost.WorstCaseSteps(10); steps != test.steps {
+			t.Errorf("cost(%q): steps mismatch: have %v, want %v", test.pattern, steps, test.steps)
+		}
+	}
+
+	re, err := NewParser(nil).Parse(`((a{1000}){1000}){2000}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if size := EstimateCost(re).ProgramSize; size != maxProgramSize {
+		t.Errorf("expected saturated program size, got %d", size)
+	}
+	if steps := EstimateCost(re).WorstCaseSteps(math.MaxInt32); steps < 1e18 {
+		t.Errorf("expected a huge steps estimate, got %v", steps)
+	}
+}
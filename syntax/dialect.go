@@ -0,0 +1,299 @@
+package syntax
+
+//go:generate stringer -type=Dialect -trimprefix=Dialect -linecomment=true
+
+// Dialect selects the regexp flavor that is being parsed.
+//
+// Dialect affects which constructs are accepted and how the ambiguous
+// ones are interpreted. For example, `\g<name>` is a subroutine call
+// in PCRE, but it's just an escaped 'g' followed by `<name>` in Java.
+// Constructs that are recognized but not supported by the dialect
+// are reported as parse errors.
+type Dialect byte
+
+const (
+	// DialectDefault is a permissive mode that accepts
+	// both RE2 and PCRE syntax.
+	DialectDefault Dialect = iota // default
+
+	// DialectRE2 is RE2 and Go regexp package syntax.
+	DialectRE2 // RE2
+
+	// DialectPCRE is PCRE (version 1) and PHP preg syntax.
+	DialectPCRE // PCRE
+
+	// DialectPCRE2 is PCRE2 syntax.
+	DialectPCRE2 // PCRE2
+
+	// DialectECMAScript is JavaScript RegExp syntax.
+	DialectECMAScript // ECMAScript
+
+	// DialectPython is Python re module syntax.
+	DialectPython // Python
+
+	// DialectJava is java.util.regex syntax.
+	DialectJava // Java
+
+	// DialectDotNet is .NET System.Text.RegularExpressions syntax.
+	DialectDotNet // .NET
+
+	// DialectOnig is Ruby/Oniguruma syntax.
+	// It enables `(?~absent)` operator that is parsed as OpAbsentGroup.
+	DialectOnig // Oniguruma
+
+	// DialectPOSIXBasic is POSIX basic regular expressions syntax.
+	// Parens and braces are literal unless escaped: `\(x\)` is OpCapture
+	// and `x\{1,2\}` is OpRepeat. Backslash is literal inside brackets.
+	// GNU extensions `\|`, `\+` and `\?` are supported as well.
+	DialectPOSIXBasic // POSIX BRE
+
+	// DialectPOSIXExtended is POSIX extended regular expressions syntax.
+	// Constructs that are not a part of ERE, like `(?=re)`, `x*?` or `\d`,
+	// are reported as parse errors. Backslash is literal inside brackets.
+	DialectPOSIXExtended // POSIX ERE
+
+	// DialectVim is Vim regexp syntax.
+	// Vim-specific constructs are mapped to the conventional ops where possible.
+	// For example, `\(x\)` is OpCapture and `\(x\)\@=` is OpPositiveLookahead.
+	DialectVim // Vim
+)
+
+// syntaxFeature is a bit set of the constructs that can be toggled by Dialect.
+type syntaxFeature uint32
+
+const (
+	featLookahead syntaxFeature = 1 << iota
+	featLookbehind
+	featAtomicGroup
+	featPossessive
+	featNonGreedy
+	featComment
+	featQuote
+	featFlagGroup
+	featFlagsReset
+	featNamedCapture
+	featNamedCaptureAngle
+	featNamedCaptureQuote
+	featEscapeUni
+	featEscapeOctalFull
+	featSubroutineCall
+	featAbsentGroup
+	featEscapeUnicode
+	featEscapeControl
+
+	featNone syntaxFeature = 0
+	featAll                = featEscapeControl<<1 - 1
+
+	featLookaround = featLookahead | featLookbehind
+)
+
+// featureNames are used in "not supported" error messages.
+var featureNames = map[syntaxFeature]string{
+	featLookahead:         "lookahead assertions",
+	featLookbehind:        "lookbehind assertions",
+	featAtomicGroup:       "atomic groups",
+	featPossessive:        "possessive quantifiers",
+	featNonGreedy:         "non-greedy quantifiers",
+	featComment:           "(?#...) comments",
+	featQuote:             `\Q...\E quotes`,
+	featFlagGroup:         "inline flag groups",
+	featFlagsReset:        "(?^) flag resets",
+	featNamedCapture:      "(?P<name>) named groups",
+	featNamedCaptureAngle: "(?<name>) named groups",
+	featNamedCaptureQuote: "(?'name') named groups",
+	featEscapeUni:         `\p{...} classes`,
+	featEscapeOctalFull:   `\o{...} escapes`,
+	featSubroutineCall:    "subroutine calls",
+	featAbsentGroup:       "absent operators",
+	featEscapeUnicode:     `\uFFFF escapes`,
+	featEscapeControl:     `\cX escapes`,
+}
+
+type dialectInfo struct {
+	// features is a set of constructs accepted by the dialect.
+	// Some of them, like featAbsentGroup, also change the way the pattern is tokenized.
+	features syntaxFeature
+
+	lookbehind lookbehindRule
+
+	// dupNames permits several capturing groups with the same name.
+	dupNames bool
+
+	// The rules below are only checked by Validate.
+
+	// strictClassRange makes `[\d-a]` an error instead of a literal '-'.
+	strictClassRange bool
+
+	// strictEscapes makes `\x2` and `\1` backreferences an error.
+	strictEscapes bool
+
+	// escapeLetters lists the letters that can be escaped with `\`.
+	// If empty, any letter escape is permitted.
+	escapeLetters string
+
+	// strictUnicodeNames permits only the short category names
+	// and script names inside `\p{...}`: `\p{Lu}` and `\p{Greek}`.
+	strictUnicodeNames bool
+
+	// maxRepeat is the max `{m,n}` repeat bound; 0 means no limit.
+	maxRepeat int
+}
+
+var dialects = [...]dialectInfo{
+	DialectDefault: {
+		features:         featAll &^ (featAbsentGroup | featEscapeUnicode | featEscapeControl),
+		lookbehind:       lookbehindAny,
+		strictClassRange: true,
+	},
+
+	DialectRE2: {
+		features: featNonGreedy | featQuote | featFlagGroup | featEscapeUni |
+			featNamedCapture | featNamedCaptureAngle,
+		strictEscapes:      true,
+		escapeLetters:      "aftnrvdDsSwWbBAz",
+		strictUnicodeNames: true,
+		maxRepeat:          1000,
+	},
+
+	DialectPCRE: {
+		features:   featAll &^ (featAbsentGroup | featFlagsReset | featEscapeUnicode | featEscapeControl),
+		lookbehind: lookbehindFixedAlternatives,
+		maxRepeat:  65535,
+	},
+
+	DialectPCRE2: {
+		features:         featAll &^ (featAbsentGroup | featEscapeUnicode | featEscapeControl),
+		lookbehind:       lookbehindBounded,
+		strictClassRange: true,
+		maxRepeat:        65535,
+	},
+
+	DialectECMAScript: {
+		features: featLookaround | featNonGreedy | featNamedCaptureAngle | featEscapeUni |
+			featEscapeUnicode | featEscapeControl,
+		lookbehind: lookbehindAny,
+	},
+
+	DialectPython: {
+		features: featLookaround | featAtomicGroup | featPossessive | featNonGreedy |
+			featComment | featFlagGroup | featNamedCapture,
+		lookbehind:       lookbehindFixed,
+		strictClassRange: true,
+		escapeLetters:    "abBdDfnrsStuUvwWAZ",
+	},
+
+	DialectJava: {
+		features: featLookaround | featAtomicGroup | featPossessive | featNonGreedy |
+			featQuote | featFlagGroup | featNamedCaptureAngle | featEscapeUni,
+		lookbehind:       lookbehindBounded,
+		strictClassRange: true,
+	},
+
+	DialectDotNet: {
+		features: featLookaround | featAtomicGroup | featNonGreedy | featComment |
+			featFlagGroup | featNamedCaptureAngle | featNamedCaptureQuote | featEscapeUni,
+		lookbehind:       lookbehindAny,
+		dupNames:         true,
+		strictClassRange: true,
+	},
+
+	DialectOnig: {
+		features: featLookaround | featAtomicGroup | featPossessive | featNonGreedy |
+			featComment | featFlagGroup | featNamedCaptureAngle | featNamedCaptureQuote |
+			featEscapeUni | featEscapeOctalFull | featSubroutineCall | featAbsentGroup,
+		lookbehind:       lookbehindFixedAlternatives,
+		dupNames:         true,
+		strictClassRange: true,
+		maxRepeat:        100000,
+	},
+
+	// POSIX and Vim scanners never produce the unsupported constructs,
+	// but it's better to be explicit here.
+	DialectPOSIXBasic:    {features: featNone},
+	DialectPOSIXExtended: {features: featNone},
+	DialectVim: {
+		features:   featLookaround | featAtomicGroup,
+		lookbehind: lookbehindAny,
+	},
+}
+
+// info returns the d rules.
+// Unknown dialects, like Dialect(100), get the DialectDefault rules.
+func (d Dialect) info() *dialectInfo {
+	return &dialects[d.orDefault()]
+}
+
+// orDefault returns d if it's one of the Dialect constants
+// and DialectDefault otherwise.
+func (d Dialect) orDefault() Dialect {
+	if int(d) >= len(dialects) {
+		return DialectDefault
+	}
+	return d
+}
+
+// checkDialect reports constructs that are not supported by the selected dialect.
+func (p *Parser) checkDialect(e *Expr) {
+	if f := exprFeature(e); f != featNone && p.dialect.features&f == 0 {
+		p.fail(e.Pos, ErrUnsupported, featureNames[f]+" are not supported in "+p.opts.Dialect.String())
+	}
+	for i := range e.Args {
+		p.checkDialect(&e.Args[i])
+	}
+}
+
+// exprFeature returns a dialect-dependent feature that is required by e.
+// Args are not inspected.
+func exprFeature(e *Expr) syntaxFeature {
+	switch e.Op {
+	case OpPositiveLookahead, OpNegativeLookahead:
+		return featLookahead
+	case OpPositiveLookbehind, OpNegativeLookbehind:
+		return featLookbehind
+	case OpAtomicGroup:
+		return featAtomicGroup
+	case OpPossessive:
+		return featPossessive
+	case OpNonGreedy:
+		return featNonGreedy
+	case OpComment:
+		if e.Form == FormDefault {
+			return featComment
+		}
+	case OpQuote:
+		return featQuote
+	case OpFlagOnlyGroup, OpGroupWithFlags:
+		if e.Form == FormFlagsReset {
+			return featFlagsReset
+		}
+		return featFlagGroup
+	case OpNamedCapture:
+		switch e.Form {
+		case FormNamedCaptureAngle:
+			return featNamedCaptureAngle
+		case FormNamedCaptureQuote:
+			return featNamedCaptureQuote
+		default:
+			return featNamedCapture
+		}
+	case OpEscapeUni:
+		return featEscapeUni
+	case OpEscapeOctal:
+		if e.Form == FormEscapeOctalFull {
+			return featEscapeOctalFull
+		}
+	case OpEscapeHex:
+		if e.Form == FormEscapeUnicode || e.Form == FormEscapeUnicodeFull {
+			return featEscapeUnicode
+		}
+	case OpEscapeChar:
+		if v := e.Args[0].Value; len(v) == 2 && v[0] == 'c' {
+			return featEscapeControl
+		}
+	case OpSubroutineCall:
+		return featSubroutineCall
+	case OpAbsentGroup:
+		return featAbsentGroup
+	}
+	return featNone
+}
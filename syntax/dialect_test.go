@@ -0,0 +1,117 @@
+package syntax
+
+import (
+	"testing"
+)
+
+func TestDialectErrors(t *testing.T) {
+	tests := []struct {
+		dialect Dialect
+		pattern string
+		want    string
+	}{
+		{DialectRE2, `a(?=b)`, `lookahead assertions are not supported in RE2`},
+		{DialectRE2, `(?<!a)b`, `lookbehind assertions are not supported in RE2`},
+		{DialectRE2, `(?>a)`, `atomic groups are not supported in RE2`},
+		{DialectRE2, `a*+`, `possessive quantifiers are not supported in RE2`},
+		{DialectRE2, `a(?#x)`, `(?#...) comments are not supported in RE2`},
+		{DialectRE2, `(?'x'a)`, `(?'name') named groups are not supported in RE2`},
+		{DialectPCRE, `(?^i)a`, `(?^) flag resets are not supported in PCRE`},
+		{DialectECMAScript, `(?i)a`, `inline flag groups are not supported in ECMAScript`},
+		{DialectECMAScript, `\Qa\E`, `\Q...\E quotes are not supported in ECMAScript`},
+		{DialectECMAScript, `(?P<x>a)`, `(?P<name>) named groups are not supported in ECMAScript`},
+		{DialectPython, `(?<x>a)`, `(?<name>) named groups are not supported in Python`},
+		{DialectPython, `\pL`, `\p{...} classes are not supported in Python`},
+		{DialectPython, `(?<=a|bc)`, `lookbehind assertion is not fixed length`},
+		{DialectJava, `(?#x)`, `(?#...) comments are not supported in Java`},
+		{DialectJava, `(?<=a+)`, `lookbehind assertion has unbounded length`},
+		{DialectDotNet, `a++`, `possessive quantifiers are not supported in .NET`},
+		{DialectOnig, `(?P<x>a)`, `(?P<name>) named groups are not supported in Oniguruma`},
+		{DialectOnig, `(?<=a|b?)`, `lookbehind assertion is not fixed length`},
+	}
+
+	for _, test := range tests {
+		p := NewParser(&ParserOptions{Dialect: test.dialect})
+		_, err := p.Parse(test.pattern)
+		have := "<nil>"
+		if err != nil {
+			have = err.Error()
+		}
+		if have != test.want {
+			t.Errorf("%s: parse(%q):\nhave: %s\nwant: %s",
+				test.dialect, test.pattern, have, test.want)
+		}
+	}
+}
+
+func TestDialectAccepts(t *testing.T) {
+	tests := []struct {
+		dialect Dialect
+		pattern string
+	}{
+		{DialectRE2, `(?i)(?P<a>x)(?<b>y)\Q.\E\pL+?`},
+		{DialectPCRE, `(?<=a|bc)(?>x)y++(?#c)\g<1>\o{7}`},
+		{DialectPCRE2, `(?^i)(?<=a{1,3})`},
+		{DialectECMAScript, `(?<=a+)(?<x>b)(?!c)d*?`},
+		{DialectPython, `(?P<x>a)(?<=ab)(?>c)d++(?#e)`},
+		{DialectJava, `(?<x>a)(?<=b{1,3})\Qc\E`},
+		{DialectDotNet, `(?'x'a)(?<=b+)(?>c)(?#d)`},
+		{DialectOnig, `(?<x>a)(?<=a|bc)(?~x)\g'x'`},
+	}
+
+	for _, test := range tests {
+		p := NewParser(&ParserOptions{Dialect: test.dialect})
+		if _, err := p.Parse(test.pattern); err != nil {
+			t.Errorf("%s: parse(%q): unexpected error: %v", test.dialect, test.pattern, err)
+		}
+	}
+}
+
+func TestDialectTokenization(t *testing.T) {
+	// Constructs that are not a part of the dialect are not recognized
+	// at all, so they're parsed in a dialect-agnostic way.
+	runParserTests(t, &ParserOptions{Dialect: DialectJava}, []parserTest{
+		{`\g<x>`, `{\g <x>}`},
+		{`\o{2}`, `(repeat \o {2})`},
+		{`(?~x)`, `(flags ?~x)`},
+		{`\u{41}`, `(repeat \u {41})`},
+		{`\u00E9x`, `{\u 00E9x}`},
+	})
+	runParserTests(t, &ParserOptions{Dialect: DialectECMAScript}, []parserTest{
+		{`a\Eb`, `{a \E b}`},
+		{`\u{1F600}\u0041`, `{\u{1F600} \u0041}`},
+		{`\u{}\u00`, `{\u {} \u 00}`},
+		{`\cA[\ca-\cz]`, `{\cA [\ca-\cz]}`},
+	})
+	runParserTests(t, &ParserOptions{}, []parserTest{
+		{`\u00E9`, `{\u 00E9}`},
+		{`\cA`, `{\c A}`},
+	})
+}
+
+func TestDialectErrorPos(t *testing.T) {
+	p := NewParser(&ParserOptions{Dialect: DialectRE2})
+	_, err := p.Parse(`ab(?=x)c`)
+	perr, ok := err.(ParseError)
+	if !ok {
+		t.Fatalf("expected ParseError, got %v", err)
+	}
+	if perr.Pos.Begin != 2 || perr.Pos.End != 7 {
+		t.Errorf("error pos mismatch: have %d:%d, want 2:7", perr.Pos.Begin, perr.Pos.End)
+	}
+}
+
+func TestDialectUnknown(t *testing.T) {
+	// Unknown dialects are parsed and validated as DialectDefault.
+	const pattern = `(?<=a+)\d{1,1001}`
+	for _, d := range []Dialect{DialectVim + 1, Dialect(255)} {
+		p := NewParser(&ParserOptions{Dialect: d})
+		re, err := p.Parse(pattern)
+		if err != nil {
+			t.Fatalf("%s: parse(%q): unexpected error: %v", d, pattern, err)
+		}
+		if err := Validate(re, &ValidateOptions{Dialect: d}); err != nil {
+			t.Errorf("%s: validate(%q): unexpected error: %v", d, pattern, err)
+		}
+	}
+}
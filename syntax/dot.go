@@ -0,0 +1,48 @@
+package syntax
+
+import (
+	"strconv"
+	"strings"
+)
+
+// DumpDOT renders re AST as a Graphviz DOT graph.
+//
+// Every expression is a node that is labeled by its operation name;
+// leaf nodes are also labeled by their values.
+// The graph can be rendered with `dot -Tsvg` or a similar tool.
+func DumpDOT(re *Regexp) string {
+	d := dotDumper{}
+	d.b.WriteString("digraph regexp {\n")
+	d.b.WriteString("  label=\"" + dotEscape(re.Pattern) + "\";\n")
+	d.b.WriteString("  node [shape=box, fontname=monospace];\n")
+	d.dumpExpr(&re.Expr)
+	d.b.WriteString("}\n")
+	return d.b.String()
+}
+
+type dotDumper struct {
+	b      strings.Builder
+	nextID int
+}
+
+func (d *dotDumper) dumpExpr(e *Expr) string {
+	id := "n" + strconv.Itoa(d.nextID)
+	d.nextID++
+
+	label := e.Op.String()
+	if len(e.Args) == 0 && e.Value != "" {
+		label += `\n` + dotEscape(e.Value)
+	}
+	d.b.WriteString("  " + id + " [label=\"" + label + "\"];\n")
+
+	for i := range e.Args {
+		argID := d.dumpExpr(&e.Args[i])
+		d.b.WriteString("  " + id + " -> " + argID + ";\n")
+	}
+	return id
+}
+
+// dotEscape makes s suitable for the DOT quoted string.
+func dotEscape(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s)
+}
@@ -0,0 +1,34 @@
+package syntax
+
+import (
+	"testing"
+)
+
+func TestDumpDOT(t *testing.T) {
+	re, err := NewParser(nil).Parse(`a|"\d+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	have := DumpDOT(re)
+	want := `digraph regexp {
+  label="a|\"\\d+";
+  node [shape=box, fontname=monospace];
+  n0 [label="Alt"];
+  n1 [label="Char\na"];
+  n0 -> n1;
+  n2 [label="Concat"];
+  n3 [label="Char\n\""];
+  n2 -> n3;
+  n4 [label="Plus"];
+  n5 [label="EscapeClass"];
+  n6 [label="String\nd"];
+  n5 -> n6;
+  n4 -> n5;
+  n2 -> n4;
+  n0 -> n2;
+}
+`
+	if have != want {
+		t.Errorf("result mismatch:\nhave:\n%s\nwant:\n%s", have, want)
+	}
+}
@@ -0,0 +1,87 @@
+package syntax
+
+// MatchesEmpty reports whether re can match an empty string,
+// so its matches can be zero-length: `a*`, `(a|)` or `(?:a?b?)+`.
+//
+// Zero-width assertions, like `^`, `\b` and lookarounds, are assumed
+// to succeed, so `^$` and `\b` can match an empty string too.
+// A backreference can match an empty string if the group
+// it refers to can match it: `(a*)\1`.
+func MatchesEmpty(re *Regexp) bool {
+	groups := re.CaptureGroups()
+	maxIndex := 0
+	if len(groups) != 0 {
+		maxIndex = groups[len(groups)-1].Index
+	}
+	m := emptyMatcher{groups: groups, maxIndex: maxIndex}
+	return m.matchesEmpty(&re.Expr)
+}
+
+type emptyMatcher struct {
+	groups   []CaptureGroup
+	maxIndex int
+
+	// visiting holds the groups that are being checked, so
+	// the backreferences inside them, like in `(a\1)`, don't loop.
+	visiting []*Expr
+}
+
+func (m *emptyMatcher) matchesEmpty(e *Expr) bool {
+	switch e.Op {
+	case OpConcat:
+		for i := range e.Args {
+			if !m.matchesEmpty(&e.Args[i]) {
+				return false
+			}
+		}
+		return true
+
+	case OpAlt:
+		for i := range e.Args {
+			if m.matchesEmpty(&e.Args[i]) {
+				return true
+			}
+		}
+		return false
+
+	case OpStar, OpQuestion:
+		return true
+
+	case OpRepeat:
+		min, _ := e.RepeatBounds()
+		return min == 0 || m.matchesEmpty(&e.Args[0])
+
+	case OpPlus, OpNonGreedy, OpPossessive, OpCapture, OpNamedCapture,
+		OpGroup, OpGroupWithFlags, OpAtomicGroup:
+		return m.matchesEmpty(&e.Args[0])
+
+	case OpEscapeOctal:
+		index, ok := backrefIndex(e, m.maxIndex)
+		if !ok {
+			return false
+		}
+		// Several groups can share the same index, like in .NET `(?<x>a)|(?<x>b*)`.
+		for _, g := range m.groups {
+			if g.Index == index && m.groupMatchesEmpty(g.Expr) {
+				return true
+			}
+		}
+		return false
+
+	default:
+		min, _ := exprWidth(*e)
+		return min == 0
+	}
+}
+
+func (m *emptyMatcher) groupMatchesEmpty(group *Expr) bool {
+	for _, e := range m.visiting {
+		if e == group {
+			return false
+		}
+	}
+	m.visiting = append(m.visiting, group)
+	result := m.matchesEmpty(&group.Args[0])
+	m.visiting = m.visiting[:len(m.visiting)-1]
+	return result
+}
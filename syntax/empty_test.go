@@ -0,0 +1,81 @@
+package syntax
+
+import (
+	"regexp"
+	"testing"
+)
+
+func TestMatchesEmpty(t *testing.T) {
+	tests := []struct {
+		pattern string
+		want    bool
+	}{
+		{``, true},
+		{`a`, false},
+		{`abc`, false},
+		{`a*`, true},
+		{`a+`, false},
+		{`a?b?`, true},
+		{`a?b`, false},
+		{`(a|)`, true},
+		{`a|b|(?:)`, true},
+		{`(?:a?b?)+`, true},
+		{`(?:a|b)+`, false},
+		{`a{0,3}`, true},
+		{`a{2}`, false},
+		{`(?:a*){2,5}`, true},
+		{`[a-z]*?`, true},
+		{`.`, false},
+		{`\d*\s?`, true},
+		{`(?i)(?:ab)?`, true},
+		{`\Q\E`, true},
+		{`(?P<x>a*)`, true},
+
+		{`^$`, true},
+		{`^a$`, false},
+		{`\b`, true},
+		{`\Aa?\z`, true},
+		{`(?=a)`, true},
+		{`(?<!a)b?`, true},
+		{`a++`, false},
+		{`(?>a?)`, true},
+		{`(?#comment)`, true},
+
+		{`(a*)\1`, true},
+		{`(a)\1`, false},
+		{`(a\1)`, false},
+		{`(a|\1)`, false},
+		{`(a*)(b)\2`, false},
+	}
+
+	p := NewParser(nil)
+	for _, test := range tests {
+		re, err := p.Parse(test.pattern)
+		if err != nil {
+			t.Fatalf("parse(%q): %v", test.pattern, err)
+		}
+		if have := MatchesEmpty(re); have != test.want {
+			t.Errorf("matchesEmpty(%q): have %v, want %v", test.pattern, have, test.want)
+		}
+
+		// Without the assertions, Go should agree with the result.
+		stdre, err := regexp.Compile(`^(?:` + test.pattern + `)$`)
+		if err != nil {
+			continue
+		}
+		if CheckRE2(re) == nil && !containsAssertion(re) && stdre.MatchString("") != test.want {
+			t.Errorf("matchesEmpty(%q): Go regexp disagrees", test.pattern)
+		}
+	}
+}
+
+func containsAssertion(re *Regexp) bool {
+	found := false
+	WalkExpr(&re.Expr, func(e *Expr) bool {
+		if e.IsAnchor() || e.IsLookaround() {
+			found = true
+		}
+		return !found
+	})
+	return found
+}
@@ -1,27 +1,193 @@
 package syntax
 
+import (
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// ParseError describes a pattern parsing failure.
 type ParseError struct {
-	Pos     Position
+	// Pos is a span of the pattern part that caused the error.
+	Pos Position
+
+	// Code is a stable error kind identifier.
+	// Use it instead of matching the Message text.
+	Code ErrorCode
+
+	// Text is the pattern part that is described by Pos.
+	// It can be empty for the unexpected pattern end errors.
+	Text string
+
 	Message string
 }
 
+// ErrorCode identifies the parse error kind.
+//
+// New codes are only appended to the end of the list,
+// so the numeric values are stable.
+type ErrorCode byte
+
+//go:generate stringer -type=ErrorCode -trimprefix=Err
+const (
+	ErrUnknown ErrorCode = iota
+
+	// ErrPatternTooLong: pattern length exceeds the Offset limit
+	// or ParserOptions.MaxPatternSize.
+	ErrPatternTooLong
+
+	// ErrTrailingBackslash: `a\`.
+	ErrTrailingBackslash
+
+	// ErrIncompleteEscape: `\p`, `\x` or Vim `\z` at the pattern end.
+	ErrIncompleteEscape
+
+	// ErrUnterminatedEscape: `\x{1`, `\p{L` or `\g<name`.
+	ErrUnterminatedEscape
+
+	// ErrUnterminatedRepeat: Vim `a\{1` or BRE `a\{1}`.
+	ErrUnterminatedRepeat
+
+	// ErrUnterminatedClass: `[a-z`.
+	ErrUnterminatedClass
+
+	// ErrUnterminatedGroup: `(a`.
+	ErrUnterminatedGroup
+
+	// ErrIncompleteGroup: `(?` that is not followed by a valid group syntax.
+	ErrIncompleteGroup
+
+	// ErrUnexpectedToken: `*` in `*a` or `a|*`.
+	ErrUnexpectedToken
+
+	// ErrInvalidLookaround: Vim `\@` that is not followed by a lookaround kind.
+	ErrInvalidLookaround
+
+	// ErrUnsupported: the construct is not supported by the selected dialect.
+	ErrUnsupported
+
+	// ErrLookbehindUnbounded: `(?<=a+)` when lookbehind length should be bounded.
+	ErrLookbehindUnbounded
+
+	// ErrLookbehindNotFixed: `(?<=a?)` when lookbehind length should be fixed.
+	ErrLookbehindNotFixed
+
+	// ErrInvalidRange: `[z-a]` or `[a-\d]` (reported by Validate).
+	ErrInvalidRange
+
+	// ErrInvalidEscape: `\y` or `\x2` in RE2 (reported by Validate).
+	ErrInvalidEscape
+
+	// ErrEmptyLookbehind: `(?<=)` (reported by Validate).
+	ErrEmptyLookbehind
+
+	// ErrUnknownUnicodeClass: `\p{Foo}` (reported by Validate).
+	ErrUnknownUnicodeClass
+
+	// ErrInvalidRepeat: `a{5,2}` (reported by Validate).
+	ErrInvalidRepeat
+
+	// ErrRepeatTooLarge: `a{1001}` in RE2 (reported by Validate)
+	// or the bounds that overflow int, like `a{99999999999999999999}`.
+	ErrRepeatTooLarge
+
+	// ErrRedundantRepeat: `a{0}` or `a{1}` (reported by Validate as a warning).
+	ErrRedundantRepeat
+
+	// ErrNestingTooDeep: expressions nesting exceeds ParserOptions.MaxDepth.
+	ErrNestingTooDeep
+
+	// ErrUndefinedBackreference: `(a)\2` (reported by Validate).
+	ErrUndefinedBackreference
+
+	// ErrForwardBackreference: `\1(a)` or `(a\1)` (reported by Validate as a warning).
+	ErrForwardBackreference
+
+	// ErrDuplicateGroupName: `(?P<x>a)(?P<x>b)` without ParserOptions.DupNames.
+	ErrDuplicateGroupName
+
+	// ErrUnknownPosixClass: `[[:foo:]]` (reported by Validate).
+	ErrUnknownPosixClass
+
+	// ErrMalformedRepeat: `.{a}` or `a{,5}` with ParserOptions.StrictRepeat,
+	// BRE `a\{x\}` or Vim `a\{x}`.
+	ErrMalformedRepeat
+)
+
 func (e ParseError) Error() string { return e.Message }
 
-func throw(pos Position, message string) {
-	panic(ParseError{Pos: pos, Message: message})
+// ErrorList is a list of errors that is returned by
+// the parser when ParserOptions.Recover is set.
+type ErrorList []ParseError
+
+func (list ErrorList) Error() string {
+	switch len(list) {
+	case 0:
+		return "no errors"
+	case 1:
+		return list[0].Error()
+	default:
+		return list[0].Error() + " (and " + strconv.Itoa(len(list)-1) + " more errors)"
+	}
 }
 
-func throwExpectedFound(pos Position, expected, found string) {
-	throw(pos, "expected '"+expected+"', found '"+found+"'")
+// FormatError renders the pattern line that contains the err location
+// and underlines the err span with `^~~~` followed by the message:
+//
+//	a(?=b)c
+//	 ^~~~~ lookahead assertions are not supported in RE2
+//
+// Empty spans, like for the unexpected pattern end, are marked with a single `^`.
+// A span that crosses the line end is underlined up to the line end.
+// Columns are counted in runes; tabs are preserved, so the underline
+// is aligned with the pattern line in the terminal.
+func FormatError(pattern string, err ParseError) string {
+	begin, end := int(err.Pos.Begin), int(err.Pos.End)
+	if end > len(pattern) {
+		end = len(pattern)
+	}
+	if begin > end {
+		begin = end
+	}
+
+	lineBegin := strings.LastIndexByte(pattern[:begin], '\n') + 1
+	lineEnd := strings.IndexByte(pattern[begin:], '\n')
+	if lineEnd == -1 {
+		lineEnd = len(pattern)
+	} else {
+		lineEnd += begin
+	}
+	if end > lineEnd {
+		end = lineEnd
+	}
+
+	var b strings.Builder
+	b.WriteString(pattern[lineBegin:lineEnd])
+	b.WriteByte('\n')
+	for _, ch := range pattern[lineBegin:begin] {
+		if ch == '\t' {
+			b.WriteByte('\t')
+		} else {
+			b.WriteByte(' ')
+		}
+	}
+	b.WriteByte('^')
+	if n := utf8.RuneCountInString(pattern[begin:end]); n > 1 {
+		b.WriteString(strings.Repeat("~", n-1))
+	}
+	if err.Message != "" {
+		b.WriteString(" " + err.Message)
+	}
+	return b.String()
 }
 
-func throwUnexpectedToken(pos Position, token string) {
-	throw(pos, "unexpected token: "+token)
+func throw(pos Position, code ErrorCode, message string) {
+	panic(ParseError{Pos: pos, Code: code, Message: message})
 }
 
 func newPos(begin, end int) Position {
 	return Position{
-		Begin: uint16(begin),
-		End:   uint16(end),
+		Begin: Offset(begin),
+		End:   Offset(end),
 	}
 }
@@ -0,0 +1,115 @@
+package syntax
+
+import (
+	"testing"
+)
+
+func TestFormatError(t *testing.T) {
+	tests := []struct {
+		opts    ParserOptions
+		pattern string
+		want    string
+	}{
+		{
+			ParserOptions{},
+			`a(?P<x>b|c`,
+			"a(?P<x>b|c\n          ^ expected ')', found 'None'",
+		},
+		{
+			ParserOptions{},
+			`ab|*`,
+			"ab|*\n   ^ unexpected token: *",
+		},
+		{
+			ParserOptions{},
+			`(?P<x>a)(?P<x>b)`,
+			"(?P<x>a)(?P<x>b)\n            ^ duplicate capture group name x",
+		},
+		{
+			ParserOptions{},
+			`αβ[z`,
+			"αβ[z\n  ^ unterminated '['",
+		},
+		{
+			ParserOptions{Dialect: DialectRE2},
+			"x\t(?=é)",
+			"x\t(?=é)\n \t^~~~~ lookahead assertions are not supported in RE2",
+		},
+		{
+			ParserOptions{FreeSpacing: true},
+			"a # comment\n\\x{12 b\nc",
+			"\\x{12 b\n^~ can't find closing '}'",
+		},
+	}
+
+	for _, test := range tests {
+		opts := test.opts
+		_, err := NewParser(&opts).Parse(test.pattern)
+		perr, ok := err.(ParseError)
+		if !ok {
+			t.Fatalf("parse(%q): expected ParseError, got %v", test.pattern, err)
+		}
+		if have := FormatError(test.pattern, perr); have != test.want {
+			t.Errorf("format(%q):\nhave:\n%s\nwant:\n%s", test.pattern, have, test.want)
+		}
+	}
+
+	// Positions outside of the pattern are clamped.
+	err := ParseError{Pos: Position{Begin: 5, End: 10}, Message: "oops"}
+	if have := FormatError(`abc`, err); have != "abc\n   ^ oops" {
+		t.Errorf("format out of range:\n%s", have)
+	}
+}
+
+func TestParseErrorCodes(t *testing.T) {
+	tests := []struct {
+		opts    ParserOptions
+		pattern string
+		code    ErrorCode
+		text    string
+	}{
+		{ParserOptions{}, `a\`, ErrTrailingBackslash, `\`},
+		{ParserOptions{}, `\p`, ErrIncompleteEscape, `\p`},
+		{ParserOptions{}, `\x`, ErrIncompleteEscape, `\x`},
+		{ParserOptions{}, `x\x{12`, ErrUnterminatedEscape, `\x`},
+		{ParserOptions{}, `\g<name`, ErrUnterminatedEscape, `\g<`},
+		{ParserOptions{}, `[a-z`, ErrUnterminatedClass, `[`},
+		{ParserOptions{}, `(a`, ErrUnterminatedGroup, ``},
+		{ParserOptions{}, `x(?`, ErrIncompleteGroup, `(`},
+		{ParserOptions{}, `a|*`, ErrUnexpectedToken, `*`},
+		{ParserOptions{StrictRepeat: true}, `.{a}`, ErrMalformedRepeat, `{a}`},
+		{ParserOptions{}, `a{99999999999999999999}`, ErrRepeatTooLarge, `{99999999999999999999}`},
+		{ParserOptions{Dialect: DialectPOSIXBasic}, `a\{x\}`, ErrMalformedRepeat, `\{x\}`},
+		{ParserOptions{Dialect: DialectVim}, `a\{1,x}`, ErrMalformedRepeat, `\{1,x}`},
+		{ParserOptions{Dialect: DialectVim}, `a\{1`, ErrUnterminatedRepeat, `\{`},
+		{ParserOptions{Dialect: DialectVim}, `a\@x`, ErrInvalidLookaround, `\@`},
+		{ParserOptions{Dialect: DialectPOSIXBasic}, `a\{1}`, ErrUnterminatedRepeat, `\{`},
+		{ParserOptions{Dialect: DialectPOSIXExtended}, `\d`, ErrUnsupported, `\d`},
+		{ParserOptions{Dialect: DialectRE2}, `a(?=b)`, ErrUnsupported, `(?=b)`},
+		{ParserOptions{Dialect: DialectJava}, `(?<=a+)`, ErrLookbehindUnbounded, `(?<=a+)`},
+		{ParserOptions{Dialect: DialectPython}, `(?<=a?)`, ErrLookbehindNotFixed, `(?<=a?)`},
+	}
+
+	for _, test := range tests {
+		opts := test.opts
+		_, err := NewParser(&opts).Parse(test.pattern)
+		perr, ok := err.(ParseError)
+		if !ok {
+			t.Errorf("parse(%q): expected ParseError, got %v", test.pattern, err)
+			continue
+		}
+		if perr.Code != test.code {
+			t.Errorf("parse(%q): code mismatch: have %s, want %s", test.pattern, perr.Code, test.code)
+		}
+		if perr.Text != test.text {
+			t.Errorf("parse(%q): text mismatch: have %q, want %q", test.pattern, perr.Text, test.text)
+		}
+
+		// Recover mode should report the same errors.
+		opts.Recover = true
+		_, err = NewParser(&opts).Parse(test.pattern)
+		if list, ok := err.(ErrorList); !ok || list[0] != perr {
+			t.Errorf("parse(%q): recover mode error mismatch: %v", test.pattern, err)
+		}
+	}
+}
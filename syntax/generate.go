@@ -0,0 +1,268 @@
+package syntax
+
+import (
+	"math/rand"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// GeneratorOptions configures the Generator.
+// A nil options pointer is equivalent to the zero value.
+type GeneratorOptions struct {
+	// MaxRepeat limits the number of repetitions for the unbounded
+	// quantifiers, like `*` and `{2,}`: `a*` generates up to MaxRepeat chars
+	// and `a{2,}` generates up to 2+MaxRepeat chars.
+	// Bounded quantifiers, like `{2,100}`, are limited in the same way.
+	// If zero, 5 is used.
+	MaxRepeat int
+
+	// Rand is a randomness source.
+	// If nil, a source with a fixed seed is used, so the generated strings
+	// sequence is the same for every new Generator.
+	Rand *rand.Rand
+}
+
+// Generator produces random strings that match the parsed patterns.
+type Generator struct {
+	opts GeneratorOptions
+
+	foldCase bool
+	captures map[int]string
+	groups   map[*Expr]int
+}
+
+// NewGenerator returns a Generator that uses opts.
+// The Generator is not safe for concurrent use.
+func NewGenerator(opts *GeneratorOptions) *Generator {
+	g := &Generator{}
+	if opts != nil {
+		g.opts = *opts
+	}
+	if g.opts.MaxRepeat == 0 {
+		g.opts.MaxRepeat = 5
+	}
+	if g.opts.Rand == nil {
+		g.opts.Rand = rand.New(rand.NewSource(1))
+	}
+	return g
+}
+
+// Generate returns a random string that is matched by re.
+//
+// When possible, the chars are selected from the printable ASCII range,
+// so `.` and `[^a]` produce readable strings.
+// Zero-width assertions, like `^` and `\b`, are not checked, so `a\bb`
+// produces a string that can't be matched.
+//
+// Alternation branches that can't be generated are skipped:
+// `(a)|b\1` always produces "a", as `\1` refers to a group that is not matched.
+// If there is no such branch, the error is returned as an ErrorList
+// with a single ErrUnsupported ParseError. This includes lookarounds
+// and backreferences to the groups that are not matched yet, like in `\1(a)`.
+func (g *Generator) Generate(re *Regexp) (s string, err error) {
+	g.foldCase = flagEnabled(re.Flags, 'i', false)
+	g.captures = make(map[int]string)
+	g.groups = make(map[*Expr]int)
+	for _, group := range re.CaptureGroups() {
+		g.groups[group.Expr] = group.Index
+	}
+
+	defer func() {
+		r := recover()
+		if r == nil {
+			return
+		}
+		if perr, ok := r.(ParseError); ok {
+			perr.Text = patternText(re.Pattern, perr.Pos)
+			err = ErrorList{perr}
+			return
+		}
+		panic(r)
+	}()
+
+	var b strings.Builder
+	g.generate(&b, &re.Expr)
+	return b.String(), nil
+}
+
+func (g *Generator) generate(b *strings.Builder, e *Expr) {
+	switch e.Op {
+	case OpConcat, OpLiteral:
+		for i := range e.Args {
+			g.generate(b, &e.Args[i])
+		}
+	case OpAlt:
+		g.generateAlt(b, e)
+
+	case OpStar, OpPlus, OpQuestion, OpRepeat:
+		min, max := 0, -1
+		switch e.Op {
+		case OpPlus:
+			min = 1
+		case OpQuestion:
+			max = 1
+		case OpRepeat:
+			min, max = e.RepeatBounds()
+		}
+		if max == -1 || max-min > g.opts.MaxRepeat {
+			max = min + g.opts.MaxRepeat
+		}
+		n := min + g.opts.Rand.Intn(max-min+1)
+		for i := 0; i < n; i++ {
+			g.generate(b, &e.Args[0])
+		}
+	case OpNonGreedy, OpPossessive:
+		g.generate(b, &e.Args[0])
+
+	case OpCapture, OpNamedCapture:
+		foldCase := g.foldCase
+		begin := b.Len()
+		g.generate(b, &e.Args[0])
+		g.captures[g.groups[e]] = b.String()[begin:]
+		g.foldCase = foldCase
+	case OpGroup, OpAtomicGroup:
+		foldCase := g.foldCase
+		g.generate(b, &e.Args[0])
+		g.foldCase = foldCase
+	case OpGroupWithFlags:
+		foldCase := g.foldCase
+		g.foldCase = flagEnabled(e.Args[1].Value, 'i', foldCase)
+		g.generate(b, &e.Args[0])
+		g.foldCase = foldCase
+	case OpFlagOnlyGroup:
+		g.foldCase = flagEnabled(e.Args[0].Value, 'i', g.foldCase)
+
+	case OpCaret, OpDollar, OpComment:
+		// Nothing to generate.
+
+	case OpQuote:
+		for _, r := range e.Args[0].Value {
+			b.WriteRune(g.foldRune(r))
+		}
+
+	case OpDot:
+		b.WriteRune(g.randomRune([]rune{0, '\n' - 1, '\n' + 1, unicode.MaxRune}))
+
+	case OpEscapeOctal:
+		v := e.Args[0].Value
+		if e.Form == FormDefault && len(v) == 1 && v != "0" {
+			n, _ := strconv.Atoi(v)
+			s, ok := g.captures[n]
+			if !ok {
+				throw(e.Pos, ErrUnsupported, "backreference to a group that is not matched")
+			}
+			b.WriteString(s)
+			return
+		}
+		g.generateChar(b, e)
+
+	case OpEscapeChar:
+		if min, max := escapeCharWidth(e.Args[0].Value); min == 0 && max == 0 {
+			return // Zero-width assertion
+		}
+		g.generateChar(b, e)
+
+	case OpChar, OpEscapeMeta, OpEscapeHex, OpEscapeUni, OpEscapeClass,
+		OpCharClass, OpNegCharClass, OpPosixClass:
+		g.generateChar(b, e)
+
+	default:
+		throw(e.Pos, ErrUnsupported, "can't generate "+e.Op.String())
+	}
+}
+
+// generateAlt generates one of the e branches, selected randomly.
+// If the selected branch can't be generated, other branches are tried.
+func (g *Generator) generateAlt(b *strings.Builder, e *Expr) {
+	var firstErr *ParseError
+	for _, i := range g.opts.Rand.Perm(len(e.Args)) {
+		err := g.tryGenerate(b, &e.Args[i])
+		if err == nil {
+			return
+		}
+		if firstErr == nil {
+			firstErr = err
+		}
+	}
+	panic(*firstErr)
+}
+
+// tryGenerate is like generate, but it returns the error instead of throwing it.
+// If e can't be generated, the generator state is restored.
+func (g *Generator) tryGenerate(b *strings.Builder, e *Expr) (err *ParseError) {
+	begin := b.Len()
+	foldCase := g.foldCase
+	captures := make(map[int]string, len(g.captures))
+	for k, v := range g.captures {
+		captures[k] = v
+	}
+
+	defer func() {
+		r := recover()
+		if r == nil {
+			return
+		}
+		perr, ok := r.(ParseError)
+		if !ok {
+			panic(r)
+		}
+		prefix := b.String()[:begin]
+		b.Reset()
+		b.WriteString(prefix)
+		g.foldCase = foldCase
+		g.captures = captures
+		err = &perr
+	}()
+
+	g.generate(b, e)
+	return nil
+}
+
+func (g *Generator) generateChar(b *strings.Builder, e *Expr) {
+	if r, ok := exprRune(e); ok {
+		b.WriteRune(g.foldRune(r))
+		return
+	}
+	ranges, err := ClassRanges(e)
+	if err != nil {
+		throw(e.Pos, ErrUnsupported, err.(ErrorList)[0].Message)
+	}
+	if len(ranges) == 0 {
+		throw(e.Pos, ErrUnsupported, "char class matches nothing")
+	}
+	b.WriteRune(g.foldRune(g.randomRune(ranges)))
+}
+
+// randomRune returns a random char from the clean ranges.
+// Printable ASCII chars are preferred.
+func (g *Generator) randomRune(ranges []rune) rune {
+	if printable := subtractRanges(ranges, []rune{0, ' ' - 1, '~' + 1, unicode.MaxRune}); len(printable) != 0 {
+		ranges = printable
+	}
+	total := 0
+	for i := 0; i < len(ranges); i += 2 {
+		total += int(ranges[i+1]-ranges[i]) + 1
+	}
+	n := g.opts.Rand.Intn(total)
+	for i := 0; i < len(ranges); i += 2 {
+		size := int(ranges[i+1]-ranges[i]) + 1
+		if n < size {
+			return ranges[i] + rune(n)
+		}
+		n -= size
+	}
+	return ranges[0]
+}
+
+// foldRune returns a random case variant of r if case folding is enabled.
+func (g *Generator) foldRune(r rune) rune {
+	if !g.foldCase {
+		return r
+	}
+	variants := []rune{r}
+	for f := unicode.SimpleFold(r); f != r; f = unicode.SimpleFold(f) {
+		variants = append(variants, f)
+	}
+	return variants[g.opts.Rand.Intn(len(variants))]
+}
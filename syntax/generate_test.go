@@ -0,0 +1,100 @@
+package syntax
+
+import (
+	"math/rand"
+	"regexp"
+	"testing"
+)
+
+func TestGenerate(t *testing.T) {
+	patterns := []string{
+		``,
+		`abc`,
+		`a|bc|def`,
+		`x*y+z?`,
+		`a{3}b{2,4}c{1,}`,
+		`[a-z]+\d{3}\s\w`,
+		`[^a-z]+`,
+		`.+`,
+		`(?i)hello`,
+		`x(?i:ab)y`,
+		`(ab|cd)\1`,
+		`\pL\p{Greek}[[:digit:]]`,
+		`\x41\t\.\Q*+\E`,
+		`^(\w+)@(\w+)\.com$`,
+		`(?:a{0,100}){2}`,
+		`(a)|b\1`,
+		`(?:(x)|y\1|z(?=q))+`,
+	}
+
+	g := NewGenerator(&GeneratorOptions{MaxRepeat: 3, Rand: rand.New(rand.NewSource(42))})
+	p := NewParser(nil)
+	for _, pattern := range patterns {
+		re, err := p.Parse(pattern)
+		if err != nil {
+			t.Fatalf("parse(%q): %v", pattern, err)
+		}
+		var goPattern string
+		switch pattern {
+		case `(ab|cd)\1`:
+			goPattern = `^(abab|cdcd)$`
+		case `(a)|b\1`:
+			goPattern = `^a$`
+		case `(?:(x)|y\1|z(?=q))+`:
+			goPattern = `^x(?:x|yx)*$`
+		default:
+			goPattern = `^(?:` + pattern + `)$`
+		}
+		matcher := regexp.MustCompile(goPattern)
+		for i := 0; i < 20; i++ {
+			s, err := g.Generate(re)
+			if err != nil {
+				t.Fatalf("generate(%q): %v", pattern, err)
+			}
+			if !matcher.MatchString(s) {
+				t.Errorf("generate(%q): %q doesn't match", pattern, s)
+			}
+		}
+	}
+}
+
+func TestGenerateDeterministic(t *testing.T) {
+	re, err := NewParser(nil).Parse(`[a-z]{5,10}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s1, _ := NewGenerator(nil).Generate(re)
+	s2, _ := NewGenerator(nil).Generate(re)
+	if s1 != s2 {
+		t.Errorf("default generators produced different strings: %q and %q", s1, s2)
+	}
+}
+
+func TestGenerateErrors(t *testing.T) {
+	tests := []struct {
+		pattern string
+		want    string
+	}{
+		{`a(?=b)`, `can't generate PositiveLookahead`},
+		{`\1(a)`, `backreference to a group that is not matched`},
+		{`(?=a)|(?!b)`, `can't generate PositiveLookahead`},
+		{`[^\x00-\x{10FFFF}]`, `char class matches nothing`},
+	}
+
+	g := NewGenerator(nil)
+	p := NewParser(nil)
+	for _, test := range tests {
+		re, err := p.Parse(test.pattern)
+		if err != nil {
+			t.Fatalf("parse(%q): %v", test.pattern, err)
+		}
+		_, err = g.Generate(re)
+		have := "<nil>"
+		if err != nil {
+			have = err.Error()
+		}
+		if have != test.want {
+			t.Errorf("generate(%q):\nhave: %s\nwant: %s", test.pattern, have, test.want)
+		}
+	}
+}
@@ -0,0 +1,284 @@
+package syntax
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// maxGlushkovPositions limits the automaton size, since
+// the counted repetitions, like `(ab){1000}`, are unrolled.
+const maxGlushkovPositions = 10000
+
+// Glushkov is a position automaton of a regexp.
+//
+// Every char-matching expression of the pattern, like `a`, `.`, `\d`
+// or `[a-z]`, is a position. The automaton start state can step
+// into the First positions; from the position i it can step into
+// the Follow[i] positions. A match ends in one of the Last positions
+// or right at the start if the regexp is Nullable.
+//
+// For `(a|b)*ab`, the positions are `a`, `b`, `a` and `b` (0-3),
+// First is [0 1 2], Last is [3], Follow[0] and Follow[1] are [0 1 2],
+// Follow[2] is [3] and Follow[3] is empty.
+type Glushkov struct {
+	// Positions are the char-matching expressions in the pattern order.
+	//
+	// The chars of OpLiteral are separate positions. The \Q...\E chars
+	// are represented by the new OpChar expressions with the quote Pos.
+	// The counted repetitions are unrolled, so `a{2}` positions
+	// point to the same `a` expression twice.
+	Positions []*Expr
+
+	// Nullable reports whether the automaton accepts an empty string.
+	Nullable bool
+
+	// First are the positions that can match the first char.
+	First []int
+
+	// Last are the positions that can match the last char.
+	Last []int
+
+	// Follow[i] are the positions that can follow the position i.
+	Follow [][]int
+}
+
+// BuildGlushkov computes the position automaton of re:
+// the nullable, first, last and follow sets over its positions.
+// All sets are sorted.
+//
+// Zero-width assertions, like `^`, `\b` and lookarounds, are treated
+// as the empty expressions, and so are the comments and flag groups.
+// Atomic groups and possessive quantifiers are treated like the
+// normal ones. In these cases the automaton accepts more strings than re.
+//
+// The flags are not tracked, so the positions should be interpreted
+// in the context of the groups they appear in. Use ExpandCaseFolding
+// to make the case-insensitive positions explicit beforehand.
+//
+// Backreferences, subroutine calls and absent groups are not regular
+// and can't be expressed by the automaton, so they are reported
+// as ErrUnsupported errors in the returned ErrorList.
+// The same goes for the escapes that can match several chars, like `\X`.
+func BuildGlushkov(re *Regexp) (*Glushkov, error) {
+	b := glushkovBuilder{g: &Glushkov{}}
+	b.init(re)
+	groups := re.CaptureGroups()
+	if len(groups) != 0 {
+		b.maxIndex = groups[len(groups)-1].Index
+	}
+	root := b.build(&re.Expr)
+	if len(b.errors) != 0 {
+		return nil, b.errors
+	}
+
+	g := b.g
+	g.Nullable = root.nullable
+	g.First = sortedSet(root.first)
+	g.Last = sortedSet(root.last)
+	for i := range g.Follow {
+		g.Follow[i] = sortedSet(g.Follow[i])
+	}
+	return g, nil
+}
+
+type glushkovBuilder struct {
+	translator
+
+	g        *Glushkov
+	maxIndex int
+}
+
+// glushkovSets are the sets of a subexpression.
+type glushkovSets struct {
+	nullable bool
+	first    []int
+	last     []int
+}
+
+func (b *glushkovBuilder) build(e *Expr) glushkovSets {
+	switch e.Op {
+	case OpConcat:
+		result := glushkovSets{nullable: true}
+		for i := range e.Args {
+			result = b.concat(result, b.build(&e.Args[i]))
+		}
+		return result
+
+	case OpAlt:
+		var result glushkovSets
+		for i := range e.Args {
+			sets := b.build(&e.Args[i])
+			result.nullable = result.nullable || sets.nullable
+			result.first = append(result.first, sets.first...)
+			result.last = append(result.last, sets.last...)
+		}
+		return result
+
+	case OpStar:
+		sets := b.loop(b.build(&e.Args[0]))
+		sets.nullable = true
+		return sets
+	case OpPlus:
+		return b.loop(b.build(&e.Args[0]))
+	case OpQuestion:
+		sets := b.build(&e.Args[0])
+		sets.nullable = true
+		return sets
+
+	case OpRepeat:
+		return b.repeat(e)
+
+	case OpNonGreedy, OpPossessive, OpCapture, OpNamedCapture,
+		OpGroup, OpGroupWithFlags, OpAtomicGroup:
+		return b.build(&e.Args[0])
+
+	case OpLiteral:
+		result := glushkovSets{nullable: true}
+		for i := range e.Args {
+			result = b.concat(result, b.position(&e.Args[i]))
+		}
+		return result
+
+	case OpQuote:
+		result := glushkovSets{nullable: true}
+		for _, r := range e.Args[0].Value {
+			c := stdLiteralChar(r, false)
+			c.Pos = e.Pos
+			result = b.concat(result, b.position(&c))
+		}
+		return result
+
+	case OpCaret, OpDollar, OpComment,
+		OpPositiveLookahead, OpNegativeLookahead, OpPositiveLookbehind, OpNegativeLookbehind:
+		return glushkovSets{nullable: true}
+
+	case OpFlagOnlyGroup:
+		switch flags := e.Args[0].Value; {
+		case strings.HasPrefix(flags, "P="):
+			b.fail(e, "backreferences are not supported")
+		case isRecursionCall(flags):
+			b.fail(e, "recursive patterns are not supported")
+		}
+		return glushkovSets{nullable: true}
+
+	case OpEscapeChar:
+		switch v := e.Args[0].Value; v {
+		case "k", "g":
+			b.fail(e, "backreferences are not supported")
+		default:
+			switch min, max := escapeCharWidth(v); {
+			case max == 0:
+				return glushkovSets{nullable: true}
+			case min != 1 || max != 1:
+				b.fail(e, "escape "+b.text(e.Pos)+" can match several chars")
+			}
+		}
+
+	case OpEscapeOctal:
+		if _, ok := backrefIndex(e, b.maxIndex); ok {
+			b.fail(e, "backreferences are not supported")
+		}
+
+	case OpSubroutineCall:
+		b.fail(e, "subroutine calls are not supported")
+	case OpAbsentGroup:
+		b.fail(e, "absent operators are not supported")
+	}
+
+	return b.position(e)
+}
+
+// position adds a new position for e.
+func (b *glushkovBuilder) position(e *Expr) glushkovSets {
+	if len(b.g.Positions) == maxGlushkovPositions {
+		if len(b.errors) == 0 {
+			b.fail(e, "the automaton exceeds the limit of "+strconv.Itoa(maxGlushkovPositions)+" positions")
+		}
+		return glushkovSets{}
+	}
+	i := len(b.g.Positions)
+	b.g.Positions = append(b.g.Positions, e)
+	b.g.Follow = append(b.g.Follow, nil)
+	return glushkovSets{first: []int{i}, last: []int{i}}
+}
+
+func (b *glushkovBuilder) concat(x, y glushkovSets) glushkovSets {
+	for _, i := range x.last {
+		b.g.Follow[i] = append(b.g.Follow[i], y.first...)
+	}
+	result := glushkovSets{
+		nullable: x.nullable && y.nullable,
+		first:    x.first,
+		last:     y.last,
+	}
+	if x.nullable {
+		result.first = append(result.first[:len(result.first):len(result.first)], y.first...)
+	}
+	if y.nullable {
+		result.last = append(result.last[:len(result.last):len(result.last)], x.last...)
+	}
+	return result
+}
+
+// loop connects the last positions to the first ones.
+func (b *glushkovBuilder) loop(sets glushkovSets) glushkovSets {
+	for _, i := range sets.last {
+		b.g.Follow[i] = append(b.g.Follow[i], sets.first...)
+	}
+	return sets
+}
+
+// repeat unrolls `x{n,m}` into n copies of x followed by the nested
+// optional copies: `x{1,3}` is built as `x(?:x(?:x)?)?`.
+// Every copy gets its own positions.
+func (b *glushkovBuilder) repeat(e *Expr) glushkovSets {
+	min, max := e.RepeatBounds()
+	x := &e.Args[0]
+	if _, width := exprWidth(*x); width == 0 {
+		// The copies of the zero-width x have no positions.
+		b.build(x)
+		return glushkovSets{nullable: true}
+	}
+	result := glushkovSets{nullable: true}
+	for i := 0; i < min && len(b.errors) == 0; i++ {
+		if i == min-1 && max == -1 {
+			// `x{n,}` is built as `x{n-1}x+`.
+			return b.concat(result, b.loop(b.build(x)))
+		}
+		result = b.concat(result, b.build(x))
+	}
+	switch {
+	case max == -1:
+		sets := b.loop(b.build(x))
+		sets.nullable = true
+		return b.concat(result, sets)
+	case max > min && len(b.errors) == 0:
+		return b.concat(result, b.optionalCopies(x, max-min))
+	}
+	return result
+}
+
+func (b *glushkovBuilder) optionalCopies(x *Expr, n int) glushkovSets {
+	sets := b.build(x)
+	if n > 1 && len(b.errors) == 0 {
+		sets = b.concat(sets, b.optionalCopies(x, n-1))
+	}
+	sets.nullable = true
+	return sets
+}
+
+// sortedSet sorts the set and removes the duplicates.
+func sortedSet(set []int) []int {
+	if len(set) == 0 {
+		return nil
+	}
+	sort.Ints(set)
+	result := set[:1]
+	for _, i := range set[1:] {
+		if i != result[len(result)-1] {
+			result = append(result, i)
+		}
+	}
+	return result
+}
@@ -0,0 +1,169 @@
+package syntax
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestBuildGlushkov(t *testing.T) {
+	tests := []struct {
+		pattern string
+		want    string
+	}{
+		{``, `nullable first=[] last=[]`},
+		{`a`, `a first=[0] last=[0] follow=[0:[]]`},
+		{`abc`, `abc first=[0] last=[2] follow=[0:[1] 1:[2] 2:[]]`},
+		{`a|bc`, `abc first=[0 1] last=[0 2] follow=[0:[] 1:[2] 2:[]]`},
+		{`(a|b)*ab`, `abab first=[0 1 2] last=[3] follow=[0:[0 1 2] 1:[0 1 2] 2:[3] 3:[]]`},
+		{`a?b*`, `nullable ab first=[0 1] last=[0 1] follow=[0:[1] 1:[1]]`},
+		{`(?:ab)+`, `ab first=[0] last=[1] follow=[0:[1] 1:[0]]`},
+		{`a{2}`, `aa first=[0] last=[1] follow=[0:[1] 1:[]]`},
+		{`a{1,3}`, `aaa first=[0] last=[0 1 2] follow=[0:[1] 1:[2] 2:[]]`},
+		{`a{2,}`, `aa first=[0] last=[1] follow=[0:[1] 1:[1]]`},
+		{`a{0,}`, `nullable a first=[0] last=[0] follow=[0:[0]]`},
+		{`x(?:^)*y`, `xy first=[0] last=[1] follow=[0:[1] 1:[]]`},
+		{`[a-z]\d.`, `[a-z]\d. first=[0] last=[2] follow=[0:[1] 1:[2] 2:[]]`},
+		{`\Qa.\E`, `\Qa.\E\Qa.\E first=[0] last=[1] follow=[0:[1] 1:[]]`},
+		{`^a\b(?=b)$`, `a first=[0] last=[0] follow=[0:[]]`},
+		{`(?i)a(?#c)(?>b)c*+`, `abc first=[0] last=[1 2] follow=[0:[1] 1:[2] 2:[2]]`},
+	}
+
+	p := NewParser(nil)
+	for _, test := range tests {
+		re, err := p.Parse(test.pattern)
+		if err != nil {
+			t.Fatalf("parse(%q): %v", test.pattern, err)
+		}
+		g, err := BuildGlushkov(re)
+		if err != nil {
+			t.Fatalf("glushkov(%q): %v", test.pattern, err)
+		}
+		if have := formatGlushkov(re.Pattern, g); have != test.want {
+			t.Errorf("glushkov(%q):\nhave: %s\nwant: %s", test.pattern, have, test.want)
+		}
+	}
+}
+
+func TestBuildGlushkovMatch(t *testing.T) {
+	patterns := []string{
+		`(a|b)*abb`,
+		`(ab|a)(c|bcd)`,
+		`x(?:y|z)+x?`,
+		`a{2,4}b?`,
+		`(?:a?b?){2}c`,
+		`(?:ab){0,2}(?:a|ba)*`,
+		`((a|)b)+`,
+	}
+	inputs := []string{
+		"", "a", "b", "ab", "abb", "aabb", "babb", "abab", "ac", "abcd", "abc",
+		"xy", "xyzx", "xyx", "xx", "aa", "aaaa", "aaaaa", "aab", "c", "bc", "abbc",
+		"ababa", "abba", "ba", "bb", "abbb",
+	}
+
+	p := NewParser(nil)
+	for _, pattern := range patterns {
+		re, err := p.Parse(pattern)
+		if err != nil {
+			t.Fatalf("parse(%q): %v", pattern, err)
+		}
+		g, err := BuildGlushkov(re)
+		if err != nil {
+			t.Fatalf("glushkov(%q): %v", pattern, err)
+		}
+		stdre := regexp.MustCompile(`^(?:` + pattern + `)$`)
+		for _, s := range inputs {
+			if have, want := glushkovMatch(g, s), stdre.MatchString(s); have != want {
+				t.Errorf("glushkov(%q) match %q: have %v, want %v", pattern, s, have, want)
+			}
+		}
+	}
+}
+
+func TestBuildGlushkovErrors(t *testing.T) {
+	tests := []struct {
+		pattern string
+		dialect Dialect
+		want    string
+	}{
+		{`(a)\1`, DialectPCRE, `\1: backreferences are not supported`},
+		{`(?P<x>a)(?P=x)`, DialectPython, `(?P=x): backreferences are not supported`},
+		{`(a)(?1)`, DialectPCRE, `(?1): recursive patterns are not supported`},
+		{`(?~a)`, DialectOnig, `(?~a): absent operators are not supported`},
+		{`\X+`, DialectPCRE, `\X: escape \X can match several chars`},
+		{`(?:ab){6000}`, DialectPCRE, `a: the automaton exceeds the limit of 10000 positions`},
+	}
+
+	for _, test := range tests {
+		p := NewParser(&ParserOptions{Dialect: test.dialect})
+		re, err := p.Parse(test.pattern)
+		if err != nil {
+			t.Fatalf("parse(%q): %v", test.pattern, err)
+		}
+		g, err := BuildGlushkov(re)
+		if g != nil || err == nil {
+			t.Fatalf("glushkov(%q): expected an error", test.pattern)
+		}
+		errs := err.(ErrorList)
+		have := errs[0].Text + ": " + errs[0].Message
+		if errs[0].Code != ErrUnsupported || have != test.want {
+			t.Errorf("glushkov(%q):\nhave: %s\nwant: %s", test.pattern, have, test.want)
+		}
+	}
+}
+
+func formatGlushkov(pattern string, g *Glushkov) string {
+	var parts []string
+	if g.Nullable {
+		parts = append(parts, "nullable")
+	}
+	var positions strings.Builder
+	for _, e := range g.Positions {
+		positions.WriteString(patternText(pattern, e.Pos))
+	}
+	if positions.Len() != 0 {
+		parts = append(parts, positions.String())
+	}
+	parts = append(parts, fmt.Sprintf("first=%v last=%v", g.First, g.Last))
+	if len(g.Follow) != 0 {
+		follow := make([]string, len(g.Follow))
+		for i, set := range g.Follow {
+			follow[i] = fmt.Sprintf("%d:%v", i, set)
+		}
+		parts = append(parts, "follow=["+strings.Join(follow, " ")+"]")
+	}
+	return strings.Join(parts, " ")
+}
+
+// glushkovMatch runs the automaton over s.
+// Only the OpChar positions are supported.
+func glushkovMatch(g *Glushkov, s string) bool {
+	var states []int
+	for i, r := range s {
+		candidates := g.First
+		if i != 0 {
+			candidates = nil
+			for _, state := range states {
+				candidates = append(candidates, g.Follow[state]...)
+			}
+		}
+		states = states[:0:0]
+		for _, pos := range candidates {
+			if c, ok := exprRune(g.Positions[pos]); ok && c == r {
+				states = append(states, pos)
+			}
+		}
+	}
+	if s == "" {
+		return g.Nullable
+	}
+	for _, state := range states {
+		for _, last := range g.Last {
+			if state == last {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,123 @@
+package syntax
+
+import (
+	"errors"
+	"strconv"
+	"unicode/utf8"
+)
+
+// RegexpGo is a pattern parsed from a Go string literal by ParseGoString.
+type RegexpGo struct {
+	Pattern string `json:"pattern"`
+	Expr    Expr   `json:"expr"`
+
+	// Literal is the source Go string literal, including the quotes.
+	Literal string `json:"literal"`
+
+	// Raw is true for the raw string literals: `...`.
+	Raw bool `json:"raw,omitempty"`
+
+	// offsets[i] is a Literal offset of the Pattern byte i.
+	// The last element is an offset of the closing quote.
+	offsets []int
+}
+
+// Clone returns a deep copy of re.
+// See Regexp.Clone for more info.
+func (re *RegexpGo) Clone() *RegexpGo {
+	clone := *re
+	clone.Expr = re.Expr.Clone()
+	return &clone
+}
+
+// CaptureGroups returns the re capturing groups ordered by their index.
+// See Regexp.CaptureGroups for more info.
+func (re *RegexpGo) CaptureGroups() []CaptureGroup {
+	return captureGroups(&re.Expr, nil)
+}
+
+// LiteralOffset maps the Pattern offset to the Literal offset.
+//
+// For the interpreted string literals, all bytes of an escape sequence
+// are mapped to the escape beginning: in `"\x41b"`, the pattern offset 1
+// (the `b` char) is mapped to 5 and the offset 0 is mapped to 1.
+// The pattern end offset is mapped to the closing quote.
+func (re *RegexpGo) LiteralOffset(offset Offset) int {
+	if int(offset) >= len(re.offsets) {
+		return len(re.Literal) - 1
+	}
+	return re.offsets[offset]
+}
+
+// LiteralPos maps the Pattern span, like Expr.Pos or ParseError.Pos,
+// to the Literal span. See LiteralOffset for more info.
+func (re *RegexpGo) LiteralPos(pos Position) Position {
+	return Position{
+		Begin: Offset(re.LiteralOffset(pos.Begin)),
+		End:   Offset(re.LiteralOffset(pos.End)),
+	}
+}
+
+// ParseGoString parses a pattern from the Go string literal source,
+// like `"\\d+"` (with the quotes) or "`\\d+`".
+//
+// The literal is unquoted and the result is parsed like with Parse.
+// The Expr and ParseError positions refer to the unquoted Pattern;
+// use LiteralPos to map them back to the Literal.
+func (p *Parser) ParseGoString(literal string) (*RegexpGo, error) {
+	if uint64(len(literal)) > maxPatternLen {
+		return nil, ParseError{
+			Code:    ErrPatternTooLong,
+			Message: "literal is too long: " + strconv.Itoa(len(literal)) + " bytes",
+		}
+	}
+	golit, err := newGoString(literal)
+	if err != nil {
+		return nil, err
+	}
+	re, err := p.Parse(golit.Pattern)
+	if re != nil {
+		golit.Expr = re.Expr
+	}
+	return golit, err
+}
+
+func newGoString(literal string) (*RegexpGo, error) {
+	pattern, err := strconv.Unquote(literal)
+	if err != nil || literal[0] == '\'' {
+		return nil, errors.New("invalid Go string literal: " + literal)
+	}
+
+	golit := &RegexpGo{
+		Pattern: pattern,
+		Literal: literal,
+		Raw:     literal[0] == '`',
+		offsets: make([]int, 0, len(pattern)+1),
+	}
+	s := literal[1 : len(literal)-1]
+	offset := 1
+	for s != "" {
+		n := 1
+		width := 1
+		switch {
+		case golit.Raw:
+			// Carriage returns are removed from the raw literals.
+			if s[0] == '\r' {
+				n = 0
+			}
+		case s[0] == '\\':
+			value, multibyte, tail, _ := strconv.UnquoteChar(s, '"')
+			if multibyte {
+				n = utf8.RuneLen(value)
+			}
+			width = len(s) - len(tail)
+		}
+		for i := 0; i < n; i++ {
+			golit.offsets = append(golit.offsets, offset)
+		}
+		offset += width
+		s = s[width:]
+	}
+	golit.offsets = append(golit.offsets, offset)
+	return golit, nil
+}
@@ -0,0 +1,94 @@
+package syntax
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseGoString(t *testing.T) {
+	tests := []struct {
+		literal string
+		pattern string
+		raw     bool
+		offsets []int
+	}{
+		{"``", ``, true, []int{1}},
+		{`""`, ``, false, []int{1}},
+		{"`a\\d`", `a\d`, true, []int{1, 2, 3, 4}},
+		{"`a\r\nb`", "a\nb", true, []int{1, 3, 4, 5}},
+		{`"a\\d"`, `a\d`, false, []int{1, 2, 4, 5}},
+		{`"\x41éé"`, "Aéé", false, []int{1, 5, 6, 7, 8, 9}},
+		{`"\u00e9\n"`, "é\n", false, []int{1, 1, 7, 9}},
+		{`"[\t ]+"`, "[\t ]+", false, []int{1, 2, 4, 5, 6, 7}},
+	}
+
+	p := NewParser(nil)
+	for _, test := range tests {
+		re, err := p.ParseGoString(test.literal)
+		if err != nil {
+			t.Fatalf("parse(%s): %v", test.literal, err)
+		}
+		if re.Pattern != test.pattern || re.Raw != test.raw || re.Literal != test.literal {
+			t.Errorf("parse(%s): unexpected result: %q raw=%v", test.literal, re.Pattern, re.Raw)
+		}
+		if Print(&Regexp{Expr: re.Expr}) != test.pattern {
+			t.Errorf("parse(%s): AST doesn't match the pattern", test.literal)
+		}
+		var offsets []int
+		for i := 0; i <= len(re.Pattern); i++ {
+			offsets = append(offsets, re.LiteralOffset(Offset(i)))
+		}
+		if !reflect.DeepEqual(offsets, test.offsets) {
+			t.Errorf("offsets(%s):\nhave: %v\nwant: %v", test.literal, offsets, test.offsets)
+		}
+	}
+}
+
+func TestParseGoStringPos(t *testing.T) {
+	p := NewParser(nil)
+
+	re, err := p.ParseGoString(`"\\d+|\x41"`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var have []string
+	WalkExpr(&re.Expr, func(e *Expr) bool {
+		pos := re.LiteralPos(e.Pos)
+		have = append(have, re.Literal[pos.Begin:pos.End])
+		return true
+	})
+	want := []string{`\\d+|\x41`, `\\d+`, `\\d`, `d`, `\x41`}
+	if !reflect.DeepEqual(have, want) {
+		t.Errorf("literal spans:\nhave: %q\nwant: %q", have, want)
+	}
+
+	// Errors are reported in the pattern offsets.
+	re, err = p.ParseGoString(`"a\t(b"`)
+	perr, ok := err.(ParseError)
+	if !ok || re == nil {
+		t.Fatalf("expected a ParseError, got %v", err)
+	}
+	pos := re.LiteralPos(perr.Pos)
+	if perr.Code != ErrUnterminatedGroup || pos.Begin != 6 {
+		t.Errorf("unexpected error: %v at %d", err, pos.Begin)
+	}
+}
+
+func TestParseGoStringErrors(t *testing.T) {
+	literals := []string{
+		``,
+		`a`,
+		`"a`,
+		`'a'`,
+		"`a",
+		`"\q"`,
+		`"a"b"`,
+	}
+
+	p := NewParser(nil)
+	for _, literal := range literals {
+		if _, err := p.ParseGoString(literal); err == nil {
+			t.Errorf("parse(%s): expected an error", literal)
+		}
+	}
+}
@@ -0,0 +1,25 @@
+package syntax
+
+import (
+	"fmt"
+)
+
+// MarshalText encodes op as its name, like "Concat".
+// It makes JSON-encoded AST readable for non-Go tools.
+func (op Operation) MarshalText() ([]byte, error) {
+	if op >= OpNone2 {
+		return nil, fmt.Errorf("can't marshal invalid operation %s", op)
+	}
+	return []byte(op.String()), nil
+}
+
+// UnmarshalText decodes op from its name.
+func (op *Operation) UnmarshalText(text []byte) error {
+	for x := OpNone; x < OpNone2; x++ {
+		if x.String() == string(text) {
+			*op = x
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown operation %q", text)
+}
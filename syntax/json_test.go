@@ -0,0 +1,66 @@
+package syntax
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestMarshalJSON(t *testing.T) {
+	re, err := NewParser(nil).Parse(`a|\x{41}+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := json.Marshal(re)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"pattern":"a|\\x{41}+","expr":{"op":"Alt","pos":{"begin":0,"end":9},"args":[` +
+		`{"op":"Char","pos":{"begin":0,"end":1},"value":"a"},` +
+		`{"op":"Plus","pos":{"begin":2,"end":9},"args":[` +
+		`{"op":"EscapeHex","form":1,"pos":{"begin":2,"end":8},"args":[` +
+		`{"op":"String","pos":{"begin":5,"end":7},"value":"41"}],"value":"\\x{41}"}],` +
+		`"value":"\\x{41}+"}],"value":"a|\\x{41}+"}}`
+	if string(data) != want {
+		t.Errorf("marshal result mismatch:\nhave: %s\nwant: %s", data, want)
+	}
+}
+
+func TestUnmarshalJSON(t *testing.T) {
+	patterns := []string{
+		`a|b+`,
+		`(?P<name>[a-z]+?)\d{2,}`,
+		`(?i)x(?:y|z)*\Qa.b\E`,
+		``,
+	}
+
+	p := NewParser(nil)
+	for _, pattern := range patterns {
+		re, err := p.Parse(pattern)
+		if err != nil {
+			t.Fatalf("parse(%q): %v", pattern, err)
+		}
+		data, err := json.Marshal(re)
+		if err != nil {
+			t.Fatalf("marshal(%q): %v", pattern, err)
+		}
+		var decoded Regexp
+		if err := json.Unmarshal(data, &decoded); err != nil {
+			t.Fatalf("unmarshal(%q): %v", pattern, err)
+		}
+		if decoded.Pattern != pattern || !EqualExpr(decoded.Expr, re.Expr) {
+			t.Errorf("unmarshal(%q): result mismatch:\nhave: %s\nwant: %s",
+				pattern, formatSyntax(&decoded), formatSyntax(re))
+		}
+		if have := formatSyntax(&decoded); have != formatSyntax(re) {
+			t.Errorf("unmarshal(%q): syntax mismatch: %s", pattern, have)
+		}
+	}
+}
+
+func TestUnmarshalJSONError(t *testing.T) {
+	var e Expr
+	err := json.Unmarshal([]byte(`{"op":"Foo"}`), &e)
+	if err == nil || err.Error() != `unknown operation "Foo"` {
+		t.Errorf("expected unknown operation error, got %v", err)
+	}
+}
@@ -28,11 +28,17 @@ const (
 	tokEscapeChar
 	tokEscapeMeta
 	tokEscapeOctal
+	tokEscapeOctalFull
 	tokEscapeUni
 	tokEscapeUniFull
 	tokEscapeHex
 	tokEscapeHexFull
+	tokSubroutineCall
+	tokSubroutineCallQuote
 	tokComment
+	tokBad
+	tokEscapeUnicode
+	tokEscapeUnicodeFull
 
 	tokQ                        // \Q
 	tokMinus                    // -
@@ -56,7 +62,15 @@ const (
 	tokLparenPositiveLookbehind // (?<=
 	tokLparenNegativeLookahead  // (?!
 	tokLparenNegativeLookbehind // (?<!
+	tokLparenAbsent             // (?~
+	tokLparenGroup              // \%(
 	tokRparen                   // )
+
+	tokPositiveLookaheadPostfix  // \@=
+	tokNegativeLookaheadPostfix  // \@!
+	tokPositiveLookbehindPostfix // \@<=
+	tokNegativeLookbehindPostfix // \@<!
+	tokAtomicPostfix             // \@>
 )
 
 // reMetachar is a table of meta chars outside of a char class.
@@ -85,6 +99,41 @@ type lexer struct {
 	tokens []token
 	pos    int
 	input  string
+
+	opts lexerOptions
+
+	// freeSpacing reports whether the x flag is currently set.
+	freeSpacing bool
+
+	// freeSpacingStack holds the x flag states of the enclosing groups.
+	freeSpacingStack []bool
+
+	// unclosedCharClass is set when pattern ends inside a char class.
+	// Char class tokens should not be concatenated.
+	unclosedCharClass bool
+
+	// errors are collected in the recover mode; see tryScan.
+	errors []ParseError
+
+	// vimMagic is a current Vim "magic" level; see vim.go.
+	vimMagic vimMagicLevel
+
+	// quantifierTrivia maps the quantifier token offsets to the
+	// free-spacing comments that precede them; see pushQuantifier.
+	quantifierTrivia map[Offset][]token
+}
+
+type lexerOptions struct {
+	freeSpacing  bool
+	recover      bool
+	strictRepeat bool
+	dialect      Dialect
+}
+
+// hasFeature reports whether the selected dialect accepts f.
+// Disabled features are tokenized in a dialect-agnostic way.
+func (l *lexer) hasFeature(f syntaxFeature) bool {
+	return l.opts.dialect.info().features&f != 0
 }
 
 func (l *lexer) HasMoreTokens() bool {
@@ -97,14 +146,20 @@ func (l *lexer) NextToken() token {
 		l.pos++
 		return tok
 	}
-	return token{}
+	return l.eofToken()
 }
 
 func (l *lexer) Peek() token {
 	if l.pos < len(l.tokens) {
 		return l.tokens[l.pos]
 	}
-	return token{}
+	return l.eofToken()
+}
+
+// eofToken returns tokNone that is positioned at the end of the input.
+func (l *lexer) eofToken() token {
+	end := len(l.input)
+	return token{kind: tokNone, pos: newPos(end, end)}
 }
 
 func (l *lexer) scan() {
@@ -116,21 +171,26 @@ func (l *lexer) scan() {
 			l.maybeInsertConcat()
 			continue
 		}
+		if l.freeSpacing && (isSpace(ch) || ch == '#') {
+			l.pushTok(tokComment, l.freeSpaceWidth(l.pos))
+			l.maybeInsertConcat()
+			continue
+		}
 		switch ch {
 		case '\\':
 			l.scanEscape(false)
 		case '.':
 			l.pushTok(tokDot, 1)
 		case '+':
-			l.pushTok(tokPlus, 1)
+			l.pushQuantifier(tokPlus, 1)
 		case '*':
-			l.pushTok(tokStar, 1)
+			l.pushQuantifier(tokStar, 1)
 		case '^':
 			l.pushTok(tokCaret, 1)
 		case '$':
 			l.pushTok(tokDollar, 1)
 		case '?':
-			l.pushTok(tokQuestion, 1)
+			l.pushQuantifier(tokQuestion, 1)
 		case ')':
 			l.pushTok(tokRparen, 1)
 		case '|':
@@ -144,6 +204,9 @@ func (l *lexer) scan() {
 			l.scanCharClass()
 		case '(':
 			if l.byteAt(l.pos+1) == '?' {
+				if l.opts.dialect == DialectPOSIXExtended {
+					throw(newPos(l.pos, l.pos+2), ErrUnsupported, "(?...) groups are not supported in POSIX ERE")
+				}
 				switch {
 				case l.byteAt(l.pos+2) == '>':
 					l.pushTok(tokLparenAtomic, len("(?>"))
@@ -155,12 +218,14 @@ func (l *lexer) scan() {
 					l.pushTok(tokLparenPositiveLookbehind, len("(?<="))
 				case l.byteAt(l.pos+2) == '<' && l.byteAt(l.pos+3) == '!':
 					l.pushTok(tokLparenNegativeLookbehind, len("(?<!"))
+				case l.byteAt(l.pos+2) == '~' && l.hasFeature(featAbsentGroup):
+					l.pushTok(tokLparenAbsent, len("(?~"))
 				default:
 					if l.tryScanComment(l.pos + 2) {
 					} else if l.tryScanGroupName(l.pos + 2) {
 					} else if l.tryScanGroupFlags(l.pos + 2) {
 					} else {
-						throw(newPos(l.pos, l.pos+1), "group token is incomplete")
+						throw(newPos(l.pos, l.pos+1), ErrIncompleteGroup, "group token is incomplete")
 					}
 				}
 			} else {
@@ -168,8 +233,11 @@ func (l *lexer) scan() {
 			}
 		case '{':
 			if j := l.repeatWidth(l.pos + 1); j >= 0 {
-				l.pushTok(tokRepeat, len("{")+j)
+				l.pushQuantifier(tokRepeat, len("{")+j)
 			} else {
+				if l.opts.strictRepeat {
+					l.checkMalformedRepeat()
+				}
 				l.pushTok(tokChar, 1)
 			}
 		default:
@@ -196,7 +264,12 @@ func (l *lexer) scanCharClass() {
 		}
 		switch ch {
 		case '\\':
-			l.scanEscape(true)
+			if l.opts.dialect == DialectPOSIXBasic || l.opts.dialect == DialectPOSIXExtended {
+				// POSIX bracket expressions have no escapes.
+				l.pushTok(tokChar, 1)
+			} else {
+				l.scanEscape(true)
+			}
 		case '[':
 			isPosixClass := false
 			if l.byteAt(l.pos+1) == ':' {
@@ -218,22 +291,28 @@ func (l *lexer) scanCharClass() {
 			l.pushTok(tokChar, 1)
 		}
 	}
+
+	// The pattern ended inside a char class; the parser will report it.
+	l.unclosedCharClass = true
 }
 
 func (l *lexer) scanEscape(insideCharClass bool) {
 	s := l.input
 	if l.pos+1 >= len(s) {
-		throw(newPos(l.pos, l.pos+1), `unexpected end of pattern: trailing '\'`)
+		throw(newPos(l.pos, l.pos+1), ErrTrailingBackslash, `unexpected end of pattern: trailing '\'`)
+	}
+	if l.opts.dialect == DialectPOSIXExtended && !insideCharClass {
+		l.checkEscapeERE()
 	}
 	switch {
 	case s[l.pos+1] == 'p' || s[l.pos+1] == 'P':
 		if l.pos+2 >= len(s) {
-			throw(newPos(l.pos, l.pos+2), "unexpected end of pattern: expected uni-class-short or '{'")
+			throw(newPos(l.pos, l.pos+2), ErrIncompleteEscape, "unexpected end of pattern: expected uni-class-short or '{'")
 		}
 		if s[l.pos+2] == '{' {
 			j := strings.IndexByte(s[l.pos+2:], '}')
 			if j < 0 {
-				throw(newPos(l.pos, l.pos+2), "can't find closing '}'")
+				throw(newPos(l.pos, l.pos+2), ErrUnterminatedEscape, "can't find closing '}'")
 			}
 			l.pushTok(tokEscapeUniFull, len(`\p{`)+j)
 		} else {
@@ -241,12 +320,12 @@ func (l *lexer) scanEscape(insideCharClass bool) {
 		}
 	case s[l.pos+1] == 'x':
 		if l.pos+2 >= len(s) {
-			throw(newPos(l.pos, l.pos+2), "unexpected end of pattern: expected hex-digit or '{'")
+			throw(newPos(l.pos, l.pos+2), ErrIncompleteEscape, "unexpected end of pattern: expected hex-digit or '{'")
 		}
 		if s[l.pos+2] == '{' {
 			j := strings.IndexByte(s[l.pos+2:], '}')
 			if j < 0 {
-				throw(newPos(l.pos, l.pos+2), "can't find closing '}'")
+				throw(newPos(l.pos, l.pos+2), ErrUnterminatedEscape, "can't find closing '}'")
 			}
 			l.pushTok(tokEscapeHexFull, len(`\x{`)+j)
 		} else {
@@ -256,6 +335,36 @@ func (l *lexer) scanEscape(insideCharClass bool) {
 				l.pushTok(tokEscapeHex, len(`\xF`))
 			}
 		}
+	case s[l.pos+1] == 'g' && !insideCharClass && l.hasFeature(featSubroutineCall) && (l.byteAt(l.pos+2) == '<' || l.byteAt(l.pos+2) == '\''):
+		kind := tokSubroutineCall
+		endCh := byte('>')
+		errMsg := "can't find closing '>'"
+		if s[l.pos+2] == '\'' {
+			kind = tokSubroutineCallQuote
+			endCh = '\''
+			errMsg = `can't find closing "'"`
+		}
+		j := strings.IndexByte(s[l.pos+3:], endCh)
+		if j < 0 {
+			throw(newPos(l.pos, l.pos+3), ErrUnterminatedEscape, errMsg)
+		}
+		l.pushTok(kind, len(`\g<>`)+j)
+	case s[l.pos+1] == 'u' && l.hasFeature(featEscapeUnicode) && l.isUnicodeEscape():
+		if l.byteAt(l.pos+2) == '{' {
+			j := strings.IndexByte(s[l.pos+2:], '}')
+			l.pushTok(tokEscapeUnicodeFull, len(`\u{`)+j)
+		} else {
+			l.pushTok(tokEscapeUnicode, len(`\uFFFF`))
+		}
+	case s[l.pos+1] == 'c' && l.hasFeature(featEscapeControl) && isLetter(l.byteAt(l.pos+2)):
+		// `\cX` control char escape.
+		l.pushTok(tokEscapeChar, len(`\cX`))
+	case s[l.pos+1] == 'o' && l.byteAt(l.pos+2) == '{' && l.hasFeature(featEscapeOctalFull):
+		j := strings.IndexByte(s[l.pos+2:], '}')
+		if j < 0 {
+			throw(newPos(l.pos, l.pos+2), ErrUnterminatedEscape, "can't find closing '}'")
+		}
+		l.pushTok(tokEscapeOctalFull, len(`\o{`)+j)
 	case isOctalDigit(s[l.pos+1]):
 		digits := 1
 		if isOctalDigit(l.byteAt(l.pos + 2)) {
@@ -266,6 +375,9 @@ func (l *lexer) scanEscape(insideCharClass bool) {
 			}
 		}
 		l.pushTok(tokEscapeOctal, len(`\`)+digits)
+	case s[l.pos+1] == 'E' && l.hasFeature(featQuote):
+		// Stray `\E` that doesn't close any `\Q`.
+		l.pushTok(tokQ, len(`\E`))
 	case s[l.pos+1] == 'Q':
 		size := len(s) - l.pos // Until the pattern ends
 		j := l.stringIndex(l.pos+2, `\E`)
@@ -287,7 +399,7 @@ func (l *lexer) scanEscape(insideCharClass bool) {
 				kind = tokEscapeMeta
 			}
 		} else {
-			if reMetachar[ch] {
+			if reMetachar[ch] || (l.freeSpacing && (isSpace(ch) || ch == '#')) {
 				kind = tokEscapeMeta
 			}
 		}
@@ -295,8 +407,35 @@ func (l *lexer) scanEscape(insideCharClass bool) {
 	}
 }
 
+// isUnicodeEscape reports whether `\u` at the current position is followed
+// by 4 hex digits or by `{hex}` (ECMAScript only).
+func (l *lexer) isUnicodeEscape() bool {
+	s := l.input[l.pos+len(`\u`):]
+	if strings.HasPrefix(s, "{") && l.opts.dialect == DialectECMAScript {
+		j := strings.IndexByte(s, '}')
+		if j < 2 {
+			return false
+		}
+		for i := 1; i < j; i++ {
+			if !isHexDigit(s[i]) {
+				return false
+			}
+		}
+		return true
+	}
+	if len(s) < 4 {
+		return false
+	}
+	for i := 0; i < 4; i++ {
+		if !isHexDigit(s[i]) {
+			return false
+		}
+	}
+	return true
+}
+
 func (l *lexer) maybeInsertConcat() {
-	if l.isConcatPos() {
+	if l.isConcatPos() && !l.unclosedCharClass {
 		last := len(l.tokens) - 1
 		tok := l.tokens[last]
 		l.tokens[last].kind = tokConcat
@@ -308,12 +447,63 @@ func (l *lexer) Init(s string) {
 	l.pos = 0
 	l.tokens = l.tokens[:0]
 	l.input = s
-
-	l.scan()
+	l.freeSpacing = l.opts.freeSpacing
+	l.freeSpacingStack = l.freeSpacingStack[:0]
+	l.vimMagic = vimMagic
+	l.unclosedCharClass = false
+	l.errors = l.errors[:0]
+	l.quantifierTrivia = nil
+
+	if l.opts.recover {
+		for !l.tryScan() {
+		}
+	} else {
+		l.scanDialect()
+	}
 
 	l.pos = 0
 }
 
+func (l *lexer) scanDialect() {
+	switch {
+	case l.opts.dialect == DialectVim:
+		l.scanVim()
+	case l.opts.dialect == DialectPOSIXBasic:
+		l.scanBRE()
+	default:
+		l.scan()
+	}
+}
+
+// tryScan is a recover mode version of scanDialect.
+// A failed token is recorded as tokBad and the scanning
+// should be continued from the next position.
+func (l *lexer) tryScan() (done bool) {
+	defer func() {
+		r := recover()
+		if r == nil {
+			return
+		}
+		err, ok := r.(ParseError)
+		if !ok {
+			panic(r)
+		}
+		l.errors = append(l.errors, err)
+		end := int(err.Pos.End)
+		if end <= l.pos {
+			end = l.pos + 1
+		}
+		if end > len(l.input) {
+			end = len(l.input)
+		}
+		l.pushTok(tokBad, end-l.pos)
+		l.maybeInsertConcat()
+	}()
+
+	l.scanDialect()
+	return true
+}
+
 func (l *lexer) tryScanGroupName(pos int) bool {
 	tok := tokLparenName
 	endCh := byte('>')
@@ -366,6 +556,28 @@ func (l *lexer) tryScanComment(pos int) bool {
 	return true
 }
 
+// freeSpaceWidth returns the length of whitespace and #-comments
+// sequence that starts at pos. Used only when the x flag is set.
+func (l *lexer) freeSpaceWidth(pos int) int {
+	j := pos
+	for j < len(l.input) {
+		ch := l.input[j]
+		switch {
+		case isSpace(ch):
+			j++
+		case ch == '#':
+			end := strings.IndexByte(l.input[j:], '\n')
+			if end < 0 {
+				return len(l.input) - pos
+			}
+			j += end + len("\n")
+		default:
+			return j - pos
+		}
+	}
+	return j - pos
+}
+
 func (l *lexer) repeatWidth(pos int) int {
 	j := pos
 	for isDigit(l.byteAt(j)) {
@@ -390,6 +602,21 @@ func (l *lexer) repeatWidth(pos int) int {
 	return -1
 }
 
+// checkMalformedRepeat reports a `{...}` sequence at the current
+// position that looks like a repetition, but is not a valid one.
+func (l *lexer) checkMalformedRepeat() {
+	for j := l.pos + 1; j < len(l.input); j++ {
+		ch := l.input[j]
+		switch {
+		case ch == '}':
+			s := l.input[l.pos : j+1]
+			throw(newPos(l.pos, j+1), ErrMalformedRepeat, "malformed repeat "+s+": expected {n}, {n,} or {n,m}")
+		case !isAlphanumeric(ch) && ch != ',' && ch != ' ':
+			return
+		}
+	}
+}
+
 func (l *lexer) stringIndex(offset int, s string) int {
 	if offset < len(l.input) {
 		return strings.Index(l.input[offset:], s)
@@ -407,11 +634,92 @@ func (l *lexer) byteAt(pos int) byte {
 func (l *lexer) pushTok(kind tokenKind, size int) {
 	l.tokens = append(l.tokens, token{
 		kind: kind,
-		pos:  Position{Begin: uint16(l.pos), End: uint16(l.pos + size)},
+		pos:  Position{Begin: Offset(l.pos), End: Offset(l.pos + size)},
 	})
+	switch kind {
+	case tokLparenFlags:
+		l.enterGroupWithFlags(l.input[l.pos : l.pos+size])
+	case tokRparen:
+		l.leaveGroup()
+	case tokLparen, tokLparenName, tokLparenNameAngle, tokLparenNameQuote, tokLparenAtomic,
+		tokLparenPositiveLookahead, tokLparenPositiveLookbehind,
+		tokLparenNegativeLookahead, tokLparenNegativeLookbehind, tokLparenAbsent, tokLparenGroup:
+		l.enterGroup()
+	}
 	l.pos += size
 }
 
+// pushQuantifier is like pushTok, but it makes the quantifier
+// apply to the preceding element instead of the free-spacing comment.
+// So `a # comment\n *` is identical to `a*` when the x flag is set.
+//
+// The free-spacing comments between the element and the quantifier
+// are removed from the tokens stream and recorded into quantifierTrivia,
+// so the parser can keep them on the quantifier expression.
+func (l *lexer) pushQuantifier(kind tokenKind, size int) {
+	if l.opts.dialect == DialectPOSIXExtended {
+		l.checkQuantifierERE(kind)
+	}
+	i := len(l.tokens)
+	for i >= 2 && l.isFreeSpaceTok(l.tokens[i-1]) && l.tokens[i-2].kind == tokConcat {
+		i -= 2
+	}
+	if i != len(l.tokens) {
+		var trivia []token
+		for j := i + 1; j < len(l.tokens); j += 2 {
+			trivia = append(trivia, l.tokens[j])
+		}
+		if l.quantifierTrivia == nil {
+			l.quantifierTrivia = make(map[Offset][]token)
+		}
+		l.quantifierTrivia[Offset(l.pos)] = trivia
+		l.tokens = l.tokens[:i]
+	}
+	l.pushTok(kind, size)
+}
+
+func (l *lexer) isFreeSpaceTok(tok token) bool {
+	return tok.kind == tokComment && l.input[tok.pos.Begin] != '('
+}
+
+func (l *lexer) enterGroup() {
+	l.freeSpacingStack = append(l.freeSpacingStack, l.freeSpacing)
+}
+
+func (l *lexer) enterGroupWithFlags(tok string) {
+	flags := tok[len("(?"):]
+	freeSpacing := l.freeSpacing
+	if strings.HasPrefix(flags, "^") {
+		// `(?^)` resets all flags to their defaults (unset).
+		freeSpacing = false
+	}
+	enable := true
+	for i := 0; i < len(flags); i++ {
+		switch flags[i] {
+		case '-':
+			enable = false
+		case 'x':
+			freeSpacing = enable
+		}
+	}
+	if strings.HasSuffix(flags, ":") {
+		// `(?x:re)` form: flags are scoped to the group.
+		l.enterGroup()
+		l.freeSpacing = freeSpacing
+	} else {
+		// `(?x)` form: flags are in effect until the enclosing group ends.
+		l.freeSpacing = freeSpacing
+		l.enterGroup()
+	}
+}
+
+func (l *lexer) leaveGroup() {
+	if n := len(l.freeSpacingStack); n != 0 {
+		l.freeSpacing = l.freeSpacingStack[n-1]
+		l.freeSpacingStack = l.freeSpacingStack[:n-1]
+	}
+}
+
 func (l *lexer) isConcatPos() bool {
 	if len(l.tokens) < 2 {
 		return false
@@ -430,7 +738,8 @@ const (
 )
 
 var concatTable = [256]byte{
-	tokPipe: concatX | concatY,
+	tokPipe:   concatX | concatY,
+	tokConcat: concatX,
 
 	tokLparen:                   concatX,
 	tokLparenFlags:              concatX,
@@ -444,6 +753,8 @@ var concatTable = [256]byte{
 	tokLparenPositiveLookbehind: concatX,
 	tokLparenNegativeLookahead:  concatX,
 	tokLparenNegativeLookbehind: concatX,
+	tokLparenAbsent:             concatX,
+	tokLparenGroup:              concatX,
 
 	tokRparen:   concatY,
 	tokRbracket: concatY,
@@ -451,4 +762,10 @@ var concatTable = [256]byte{
 	tokStar:     concatY,
 	tokQuestion: concatY,
 	tokRepeat:   concatY,
+
+	tokPositiveLookaheadPostfix:  concatY,
+	tokNegativeLookaheadPostfix:  concatY,
+	tokPositiveLookbehindPostfix: concatY,
+	tokNegativeLookbehindPostfix: concatY,
+	tokAtomicPostfix:             concatY,
 }
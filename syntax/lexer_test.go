@@ -86,7 +86,7 @@ func TestLexer(t *testing.T) {
 
 		{`-`, `Char`},
 		{`[\-]`, `[ EscapeMeta ]`},
-		{`a[]a`, `Char Concat [ Char Concat Char`},
+		{`a[]a`, `Char Concat [ Char Char`},
 		{`[\^a]a`, `[ EscapeChar Char ] Concat Char`},
 		{`[^a]a`, `[^ Char ] Concat Char`},
 		{`a[^abc]a`, `Char Concat [^ Char Char Char ] Concat Char`},
@@ -136,6 +136,17 @@ func TestLexer(t *testing.T) {
 		{`\777`, `EscapeOctal`},
 		{`\78`, `EscapeOctal Concat Char`},
 		{`\778`, `EscapeOctal Concat Char`},
+		{`\o{777}`, `EscapeOctalFull`},
+
+		{`\g<name>`, `SubroutineCall`},
+		{`a\g'1'b`, `Char Concat SubroutineCallQuote Concat Char`},
+		{`\g<-1>+`, `SubroutineCall +`},
+		{`[\g<a>]`, `[ EscapeChar Char Char Char ]`},
+		{`\ga`, `EscapeChar Concat Char`},
+
+		{`\o{}a`, `EscapeOctalFull Concat Char`},
+		{`\oa`, `EscapeChar Concat Char`},
+		{`[\o{1}-\o{7}]`, `[ EscapeOctalFull - EscapeOctalFull ]`},
 
 		{`\xFF`, `EscapeHex`},
 		{`\xab`, `EscapeHex`},
@@ -165,11 +176,24 @@ func TestLexer(t *testing.T) {
 		{`x\Q`, `Char Concat \Q`},
 		{`x\Q.`, `Char Concat \Q`},
 		{`x\Q..`, `Char Concat \Q`},
+		{`x\E`, `Char Concat \Q`},
+		{`[\Q]\E]`, `[ \Q ]`},
 		{`\Q\E`, `\Q`},
 		{`\Q..\E`, `\Q`},
 		{`x\Q\Ey`, `Char Concat \Q Concat Char`},
 		{`x\Q..\Ey`, `Char Concat \Q Concat Char`},
 		{`\Q\E\Q\E`, `\Q Concat \Q`},
+
+		{`(?x)a b`, `(?flags ) Concat Char Concat Comment Concat Char`},
+		{`(?x)a #c
+*`, `(?flags ) Concat Char *`},
+		{`(?x)a * ?`, `(?flags ) Concat Char * ?`},
+		{`(?x)a * ?b`, `(?flags ) Concat Char * ? Concat Char`},
+		{`(?x)\ \#`, `(?flags ) Concat EscapeMeta Concat EscapeMeta`},
+		{`(?x:a )b c`, `(?flags Char Concat Comment ) Concat Char Concat Char Concat Char`},
+		{`((?x)a )b c`, `( (?flags ) Concat Char Concat Comment ) Concat Char Concat Char Concat Char`},
+		{`(?x)a(?-x) b`, `(?flags ) Concat Char Concat (?flags ) Concat Char Concat Char`},
+		{`(?x)[ #]`, `(?flags ) Concat [ Char Char ]`},
 	}
 
 	removeBrackets := func(s string) string {
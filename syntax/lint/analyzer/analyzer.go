@@ -0,0 +1,154 @@
+// Package analyzer provides a go/analysis analyzer that checks
+// the regexp patterns in the Go source code.
+//
+// It's a separate module, so the syntax package
+// doesn't depend on golang.org/x/tools.
+package analyzer
+
+import (
+	"go/ast"
+	"go/constant"
+	"go/token"
+	"go/types"
+	"strings"
+
+	"github.com/quasilyte/regex/syntax"
+	"github.com/quasilyte/regex/syntax/lint"
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/analysis/passes/inspect"
+	"golang.org/x/tools/go/ast/inspector"
+)
+
+// Analyzer reports the syntax errors and lint issues in the constant
+// patterns passed to regexp.Compile and regexp.MustCompile.
+//
+// The patterns are parsed as RE2 and checked with lint.Validate
+// and lint.ClassRedundancy rules.
+// Suggested fixes are provided for the raw string literal patterns.
+var Analyzer = &analysis.Analyzer{
+	Name:     "regexplint",
+	Doc:      "check regexp patterns passed to regexp.Compile and regexp.MustCompile",
+	Requires: []*analysis.Analyzer{inspect.Analyzer},
+	Run:      run,
+}
+
+func run(pass *analysis.Pass) (interface{}, error) {
+	inspect := pass.ResultOf[inspect.Analyzer].(*inspector.Inspector)
+
+	c := checker{
+		pass:   pass,
+		parser: syntax.NewParser(&syntax.ParserOptions{Dialect: syntax.DialectRE2}),
+		runner: lint.NewRunner(nil,
+			lint.Validate(&syntax.ValidateOptions{Dialect: syntax.DialectRE2, Warnings: true}),
+			lint.ClassRedundancy()),
+	}
+	inspect.Preorder([]ast.Node{(*ast.CallExpr)(nil)}, func(n ast.Node) {
+		call := n.(*ast.CallExpr)
+		if len(call.Args) == 1 && isCompileFunc(pass.TypesInfo, call.Fun) {
+			c.checkPattern(call.Args[0])
+		}
+	})
+	return nil, nil
+}
+
+func isCompileFunc(info *types.Info, fun ast.Expr) bool {
+	sel, ok := fun.(*ast.SelectorExpr)
+	if !ok {
+		return false
+	}
+	fn, ok := info.Uses[sel.Sel].(*types.Func)
+	if !ok || fn.Pkg() == nil || fn.Pkg().Path() != "regexp" {
+		return false
+	}
+	switch fn.Name() {
+	case "Compile", "MustCompile":
+		return true
+	default:
+		return false
+	}
+}
+
+type checker struct {
+	pass   *analysis.Pass
+	parser *syntax.Parser
+	runner *lint.Runner
+}
+
+func (c *checker) checkPattern(arg ast.Expr) {
+	tv, ok := c.pass.TypesInfo.Types[arg]
+	if !ok || tv.Value == nil || tv.Value.Kind() != constant.String {
+		return
+	}
+	re, pm, err := c.parse(arg, constant.StringVal(tv.Value))
+	if err != nil {
+		switch err := err.(type) {
+		case syntax.ParseError:
+			c.reportError(pm, err)
+		case syntax.ErrorList:
+			for _, e := range err {
+				c.reportError(pm, e)
+			}
+		}
+		return
+	}
+
+	for _, issue := range c.runner.Run(re) {
+		diag := analysis.Diagnostic{
+			Pos:      pm.pos(issue.Pos.Begin),
+			End:      pm.pos(issue.Pos.End),
+			Category: issue.Rule,
+			Message:  "regexp: " + issue.Message,
+		}
+		if issue.Fix != nil && pm.lit != nil && pm.lit.Raw && !strings.Contains(issue.Fix.Replacement, "`") {
+			diag.SuggestedFixes = []analysis.SuggestedFix{{
+				Message: "apply the suggested pattern fix",
+				TextEdits: []analysis.TextEdit{{
+					Pos:     pm.pos(issue.Fix.Pos.Begin),
+					End:     pm.pos(issue.Fix.Pos.End),
+					NewText: []byte(issue.Fix.Replacement),
+				}},
+			}}
+		}
+		c.pass.Report(diag)
+	}
+}
+
+// parse parses the arg pattern.
+// For the string literals, the positions are mapped to the literal source.
+func (c *checker) parse(arg ast.Expr, pattern string) (*syntax.Regexp, *posMapper, error) {
+	pm := &posMapper{arg: arg}
+	if lit, ok := arg.(*ast.BasicLit); ok && lit.Kind == token.STRING {
+		golit, err := c.parser.ParseGoString(lit.Value)
+		if golit != nil {
+			pm.lit = golit
+			return &syntax.Regexp{Pattern: golit.Pattern, Expr: golit.Expr}, pm, err
+		}
+	}
+	re, err := c.parser.Parse(pattern)
+	return re, pm, err
+}
+
+func (c *checker) reportError(pm *posMapper, err syntax.ParseError) {
+	c.pass.Report(analysis.Diagnostic{
+		Pos:      pm.pos(err.Pos.Begin),
+		End:      pm.pos(err.Pos.End),
+		Category: "syntax",
+		Message:  "regexp: " + err.Message,
+	})
+}
+
+// posMapper maps the pattern offsets to the Go source positions.
+type posMapper struct {
+	arg ast.Expr
+
+	// lit is a parsed string literal arg.
+	// For the patterns that are not literals, it's nil.
+	lit *syntax.RegexpGo
+}
+
+func (pm *posMapper) pos(offset syntax.Offset) token.Pos {
+	if pm.lit == nil {
+		return pm.arg.Pos()
+	}
+	return pm.arg.Pos() + token.Pos(pm.lit.LiteralOffset(offset))
+}
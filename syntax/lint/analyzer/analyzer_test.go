@@ -0,0 +1,49 @@
+package analyzer
+
+import (
+	"go/ast"
+	"go/token"
+	"reflect"
+	"testing"
+
+	"github.com/quasilyte/regex/syntax"
+	"golang.org/x/tools/go/analysis/analysistest"
+)
+
+func TestAnalyzer(t *testing.T) {
+	analysistest.Run(t, analysistest.TestData(), Analyzer, "a")
+}
+
+func TestAnalyzerSuggestedFixes(t *testing.T) {
+	analysistest.RunWithSuggestedFixes(t, analysistest.TestData(), Analyzer, "fix")
+}
+
+func TestPosMapper(t *testing.T) {
+	tests := []struct {
+		lit     string
+		pattern string
+		want    []int
+	}{
+		{"`a\\d`", `a\d`, []int{1, 2, 3, 4}},
+		{`"a\\d"`, `a\d`, []int{1, 2, 4, 5}},
+		{`"\x41éé"`, "Aéé", []int{1, 5, 6, 7, 8, 9}},
+		{"`a\r\nb`", "a\nb", []int{1, 3, 4, 5}},
+		{`"\n\t"`, "\n\t", []int{1, 3, 5}},
+	}
+
+	c := checker{parser: syntax.NewParser(nil)}
+	for _, test := range tests {
+		lit := &ast.BasicLit{ValuePos: 10, Kind: token.STRING, Value: test.lit}
+		_, pm, err := c.parse(lit, test.pattern)
+		if err != nil {
+			t.Fatalf("parse(%s): %v", test.lit, err)
+		}
+		var offsets []int
+		for i := 0; i <= len(test.pattern); i++ {
+			offsets = append(offsets, int(pm.pos(syntax.Offset(i))-lit.Pos()))
+		}
+		if !reflect.DeepEqual(offsets, test.want) {
+			t.Errorf("offsets(%s):\nhave: %v\nwant: %v", test.lit, offsets, test.want)
+		}
+	}
+}
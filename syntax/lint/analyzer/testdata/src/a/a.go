@@ -0,0 +1,22 @@
+package a
+
+import (
+	"regexp"
+)
+
+const digits = `[0-9]+`
+
+func _() {
+	regexp.MustCompile(`^\w+$`)
+	regexp.MustCompile(digits)
+
+	regexp.MustCompile(`[z-a]`)      // want `regexp: invalid char range z-a: bounds are reversed`
+	regexp.MustCompile("x\\y")       // want `regexp: invalid escape \\y in RE2`
+	regexp.MustCompile(`(?<=a)b`)    // want `regexp: lookbehind assertions are not supported in RE2`
+	regexp.MustCompile(`a{1}bc`)     // want `regexp: repeat \{1\} is redundant`
+	regexp.MustCompile("[a\\da]")    // want `regexp: a is already matched by a`
+	regexp.MustCompile(`[\da0-5]`)   // want `regexp: 0-5 is already matched by \\d`
+	regexp.Compile(digits + `{5,2}`) // want `regexp: invalid repeat \{5,2\}: min is greater than max`
+
+	regexp.MatchString(`[z-a]`, "")
+}
@@ -0,0 +1,11 @@
+package fix
+
+import (
+	"regexp"
+)
+
+var (
+	_ = regexp.MustCompile(`a{1}bc`)       // want `regexp: repeat \{1\} is redundant`
+	_ = regexp.MustCompile(`x[a\da]y`)     // want `regexp: a is already matched by a`
+	_ = regexp.MustCompile("x[a\\da]y{1}") // want `regexp: a is already matched by a` `regexp: repeat \{1\} is redundant`
+)
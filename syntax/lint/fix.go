@@ -0,0 +1,59 @@
+package lint
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/quasilyte/regex/syntax"
+)
+
+// Fix is a suggested issue fix: a pattern part replacement.
+type Fix struct {
+	// Pos is a span of the pattern part to be replaced.
+	Pos syntax.Position
+
+	// Replacement is a new text for the Pos span.
+	// An empty replacement removes the span.
+	Replacement string
+}
+
+// ApplyFixes returns a pattern with the issues fixes applied.
+// Issues without a fix are ignored.
+//
+// The fixes are applied in the order of their positions;
+// a fix that overlaps with the previously applied one is skipped.
+// Identical fixes are applied only once.
+// Use the Runner again on the result to get the remaining fixes.
+func ApplyFixes(pattern string, issues []Issue) string {
+	var fixes []Fix
+	for _, issue := range issues {
+		if issue.Fix != nil {
+			fixes = append(fixes, *issue.Fix)
+		}
+	}
+	sort.SliceStable(fixes, func(i, j int) bool {
+		return fixes[i].Pos.Begin < fixes[j].Pos.Begin
+	})
+
+	var b strings.Builder
+	offset := 0
+	var prev *Fix
+	for i := range fixes {
+		fix := &fixes[i]
+		if int(fix.Pos.End) > len(pattern) || fix.Pos.Begin > fix.Pos.End {
+			continue
+		}
+		if int(fix.Pos.Begin) < offset {
+			continue
+		}
+		if prev != nil && *prev == *fix && fix.Pos.Begin == fix.Pos.End {
+			continue // Identical insertion
+		}
+		b.WriteString(pattern[offset:fix.Pos.Begin])
+		b.WriteString(fix.Replacement)
+		offset = int(fix.Pos.End)
+		prev = fix
+	}
+	b.WriteString(pattern[offset:])
+	return b.String()
+}
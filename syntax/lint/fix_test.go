@@ -0,0 +1,52 @@
+package lint
+
+import (
+	"testing"
+
+	"github.com/quasilyte/regex/syntax"
+)
+
+func TestApplyFixes(t *testing.T) {
+	validate := Validate(&syntax.ValidateOptions{Warnings: true})
+	runner := NewRunner(nil, validate, ClassRedundancy(), Backtracking())
+
+	tests := []struct {
+		pattern string
+		want    string
+	}{
+		{`abc`, `abc`},
+		{`(a+)+`, `(a+)+`},
+		{`a{1}b{1,1}?`, `ab?`},
+		{`[aa-z]`, `[a-z]`},
+		{`x[a\d0-5a]y`, `x[\da]y`},
+		{`[aa]{1}|[bb]`, `[a]|[b]`},
+	}
+
+	p := syntax.NewParser(nil)
+	for _, test := range tests {
+		re, err := p.Parse(test.pattern)
+		if err != nil {
+			t.Fatalf("parse(%q): %v", test.pattern, err)
+		}
+		have := ApplyFixes(test.pattern, runner.Run(re))
+		if have != test.want {
+			t.Errorf("fix(%q):\nhave: %q\nwant: %q", test.pattern, have, test.want)
+		}
+	}
+}
+
+func TestApplyFixesOverlapping(t *testing.T) {
+	issues := []Issue{
+		{Fix: &Fix{Pos: syntax.Position{Begin: 2, End: 4}, Replacement: "y"}},
+		{},
+		{Fix: &Fix{Pos: syntax.Position{Begin: 0, End: 3}, Replacement: "x"}},
+		{Fix: &Fix{Pos: syntax.Position{Begin: 5, End: 5}, Replacement: "!"}},
+		{Fix: &Fix{Pos: syntax.Position{Begin: 5, End: 5}, Replacement: "!"}},
+		{Fix: &Fix{Pos: syntax.Position{Begin: 6, End: 10}, Replacement: "z"}},
+	}
+	have := ApplyFixes("abcdef", issues)
+	want := "xde!f"
+	if have != want {
+		t.Errorf("fix:\nhave: %q\nwant: %q", have, want)
+	}
+}
@@ -0,0 +1,136 @@
+// Package lint provides a framework for the regexp pattern linters
+// that work on top of the syntax package AST.
+//
+// A lint configuration is a set of rules, either the ones provided
+// by this package or the custom Rule implementations,
+// that are executed by a Runner.
+package lint
+
+import (
+	"sort"
+
+	"github.com/quasilyte/regex/syntax"
+)
+
+// Severity is an issue importance level.
+type Severity byte
+
+//go:generate stringer -type=Severity -trimprefix=Severity
+const (
+	// SeverityInfo is used for the style suggestions.
+	SeverityInfo Severity = iota
+
+	// SeverityWarning is used for the patterns that are likely to be a mistake.
+	SeverityWarning
+
+	// SeverityError is used for the patterns that are rejected
+	// or misinterpreted by the regexp engine.
+	SeverityError
+)
+
+// Issue is a single lint rule finding.
+type Issue struct {
+	// Rule is a name of the rule that reported the issue.
+	// It's filled by the Runner.
+	Rule string
+
+	Severity Severity
+
+	// Pos is a span of the pattern part that caused the issue.
+	Pos syntax.Position
+
+	// Text is the pattern part that is described by Pos.
+	// If empty, it's filled by the Runner.
+	Text string
+
+	Message string
+
+	// Fix is a suggested issue fix, if any.
+	// See ApplyFixes.
+	Fix *Fix
+}
+
+// Rule is a single pattern check.
+type Rule interface {
+	// Name returns a rule identifier, like "backtracking".
+	// It's used to configure the rule severity.
+	Name() string
+
+	// Check returns the issues found in re.
+	Check(re *syntax.Regexp) []Issue
+}
+
+// NewRule returns a Rule that uses the check function
+// to find the issues.
+func NewRule(name string, check func(re *syntax.Regexp) []Issue) Rule {
+	return &funcRule{name: name, check: check}
+}
+
+type funcRule struct {
+	name  string
+	check func(re *syntax.Regexp) []Issue
+}
+
+func (r *funcRule) Name() string                    { return r.name }
+func (r *funcRule) Check(re *syntax.Regexp) []Issue { return r.check(re) }
+
+type Config struct {
+	// Severity overrides the issues severity for the rules by their names.
+	Severity map[string]Severity
+
+	// MinSeverity filters out the issues with a lower severity.
+	MinSeverity Severity
+}
+
+// Runner executes a set of rules over the patterns.
+type Runner struct {
+	config Config
+	rules  []Rule
+}
+
+// NewRunner returns a runner that checks the patterns with the given rules.
+// A nil config is identical to a zero config.
+func NewRunner(config *Config, rules ...Rule) *Runner {
+	r := &Runner{rules: rules}
+	if config != nil {
+		r.config = *config
+	}
+	return r
+}
+
+// Run executes all runner rules over re.
+//
+// The issues are sorted by their positions.
+// Issues produced by the same rule at the same position keep
+// their relative order.
+func (r *Runner) Run(re *syntax.Regexp) []Issue {
+	var issues []Issue
+	for _, rule := range r.rules {
+		name := rule.Name()
+		severity, overridden := r.config.Severity[name]
+		for _, issue := range rule.Check(re) {
+			issue.Rule = name
+			if overridden {
+				issue.Severity = severity
+			}
+			if issue.Severity < r.config.MinSeverity {
+				continue
+			}
+			if issue.Text == "" {
+				issue.Text = patternText(re.Pattern, issue.Pos)
+			}
+			issues = append(issues, issue)
+		}
+	}
+	sort.SliceStable(issues, func(i, j int) bool {
+		return issues[i].Pos.Begin < issues[j].Pos.Begin
+	})
+	return issues
+}
+
+func patternText(pattern string, pos syntax.Position) string {
+	if int(pos.End) > len(pattern) || pos.Begin > pos.End {
+		return ""
+	}
+	return pattern[pos.Begin:pos.End]
+}
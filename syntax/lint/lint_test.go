@@ -0,0 +1,80 @@
+package lint
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/quasilyte/regex/syntax"
+)
+
+func TestRunner(t *testing.T) {
+	validate := Validate(&syntax.ValidateOptions{Dialect: syntax.DialectRE2, Warnings: true})
+	rules := []Rule{validate, Backtracking(), ClassRedundancy()}
+
+	tests := []struct {
+		pattern string
+		config  *Config
+		want    []string
+	}{
+		{`[a-z]+\d`, nil, nil},
+
+		{`(a+)+[z-a]{1}[aa]`, nil, []string{
+			`Warning backtracking 0-5 (a+)+: nested unbounded quantifier a+`,
+			`Error validate 6-9 z-a: invalid char range z-a: bounds are reversed`,
+			`Warning validate 10-13 {1}: repeat {1} is redundant`,
+			`Warning classRedundancy 15-16 a: a is already matched by a`,
+		}},
+
+		{`\d+\d+[aa]`, &Config{MinSeverity: SeverityWarning}, []string{
+			`Warning classRedundancy 8-9 a: a is already matched by a`,
+		}},
+
+		{`\d+\d+[aa]`, &Config{Severity: map[string]Severity{"backtracking": SeverityError}}, []string{
+			`Error backtracking 0-6 \d+\d+: quantifiers \d+ and \d+ can match the same input`,
+			`Warning classRedundancy 8-9 a: a is already matched by a`,
+		}},
+	}
+
+	p := syntax.NewParser(&syntax.ParserOptions{Dialect: syntax.DialectRE2})
+	for _, test := range tests {
+		re, err := p.Parse(test.pattern)
+		if err != nil {
+			t.Fatalf("parse(%q): %v", test.pattern, err)
+		}
+		var have []string
+		for _, issue := range NewRunner(test.config, rules...).Run(re) {
+			have = append(have, fmt.Sprintf("%s %s %d-%d %s: %s",
+				issue.Severity, issue.Rule, issue.Pos.Begin, issue.Pos.End, issue.Text, issue.Message))
+		}
+		if !reflect.DeepEqual(have, test.want) {
+			t.Errorf("run(%q):\nhave: %q\nwant: %q", test.pattern, have, test.want)
+		}
+	}
+}
+
+func TestNewRule(t *testing.T) {
+	rule := NewRule("noDot", func(re *syntax.Regexp) []Issue {
+		var issues []Issue
+		syntax.WalkExpr(&re.Expr, func(e *syntax.Expr) bool {
+			if e.Op == syntax.OpDot {
+				issues = append(issues, Issue{Pos: e.Pos, Message: "dot is forbidden"})
+			}
+			return true
+		})
+		return issues
+	})
+
+	re, err := syntax.NewParser(nil).Parse(`a.(b.)`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	issues := NewRunner(nil, rule).Run(re)
+	want := []Issue{
+		{Rule: "noDot", Pos: syntax.Position{Begin: 1, End: 2}, Text: ".", Message: "dot is forbidden"},
+		{Rule: "noDot", Pos: syntax.Position{Begin: 4, End: 5}, Text: ".", Message: "dot is forbidden"},
+	}
+	if !reflect.DeepEqual(issues, want) {
+		t.Errorf("run:\nhave: %+v\nwant: %+v", issues, want)
+	}
+}
@@ -0,0 +1,111 @@
+package lint
+
+import (
+	"strings"
+
+	"github.com/quasilyte/regex/syntax"
+)
+
+// Validate returns a rule that reports syntax.Validate errors.
+// The warnings enabled by opts.Warnings are reported with SeverityWarning.
+// Redundant `{1}` repeats are reported with a fix that removes them.
+//
+// Rule name: "validate".
+func Validate(opts *syntax.ValidateOptions) Rule {
+	return NewRule("validate", func(re *syntax.Regexp) []Issue {
+		err := syntax.Validate(re, opts)
+		if err == nil {
+			return nil
+		}
+		var issues []Issue
+		for _, e := range err.(syntax.ErrorList) {
+			issue := Issue{
+				Severity: SeverityError,
+				Pos:      e.Pos,
+				Text:     e.Text,
+				Message:  e.Message,
+			}
+			switch e.Code {
+			case syntax.ErrRedundantRepeat:
+				issue.Severity = SeverityWarning
+				if strings.HasSuffix(e.Message, " is redundant") {
+					issue.Fix = &Fix{Pos: e.Pos}
+				}
+			case syntax.ErrForwardBackreference:
+				issue.Severity = SeverityWarning
+			}
+			issues = append(issues, issue)
+		}
+		return issues
+	})
+}
+
+// Backtracking returns a rule that reports syntax.CheckBacktracking risks.
+// Exponential blowups are reported with SeverityWarning,
+// polynomial ones are reported with SeverityInfo.
+//
+// Rule name: "backtracking".
+func Backtracking() Rule {
+	return NewRule("backtracking", func(re *syntax.Regexp) []Issue {
+		var issues []Issue
+		for _, risk := range syntax.CheckBacktracking(re) {
+			severity := SeverityInfo
+			if risk.Blowup == syntax.BlowupExponential {
+				severity = SeverityWarning
+			}
+			issues = append(issues, Issue{
+				Severity: severity,
+				Pos:      risk.Pos,
+				Text:     risk.Text,
+				Message:  risk.Message,
+			})
+		}
+		return issues
+	})
+}
+
+// ClassRedundancy returns a rule that reports the char class elements
+// that duplicate or overlap with the other elements, see syntax.NormalizeCharClasses.
+// The issues are reported with SeverityWarning along with a fix
+// that replaces the class with its normalized form: `[aa-z]` => `[a-z]`.
+//
+// Rule name: "classRedundancy".
+func ClassRedundancy() Rule {
+	return NewRule("classRedundancy", func(re *syntax.Regexp) []Issue {
+		normalized, redundant := syntax.NormalizeCharClasses(re)
+		if len(redundant) == 0 {
+			return nil
+		}
+
+		// Classes positions are preserved by the normalization.
+		var classes []*syntax.Expr
+		syntax.WalkExpr(&normalized.Expr, func(e *syntax.Expr) bool {
+			if e.Op == syntax.OpCharClass || e.Op == syntax.OpNegCharClass {
+				classes = append(classes, e)
+				return false
+			}
+			return true
+		})
+
+		var issues []Issue
+		for _, r := range redundant {
+			issue := Issue{
+				Severity: SeverityWarning,
+				Pos:      r.Pos,
+				Text:     r.Text,
+				Message:  r.Message,
+			}
+			for _, class := range classes {
+				if class.Begin() <= r.Pos.Begin && r.Pos.End <= class.End() {
+					issue.Fix = &Fix{
+						Pos:         class.Pos,
+						Replacement: syntax.Print(&syntax.Regexp{Expr: *class}),
+					}
+					break
+				}
+			}
+			issues = append(issues, issue)
+		}
+		return issues
+	})
+}
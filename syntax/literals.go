@@ -0,0 +1,262 @@
+package syntax
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// LiteralInfo describes the literal strings that every match of a regexp contains.
+//
+// It can be used for prefiltering: a text that doesn't contain
+// any of the required strings can't be matched by the regexp.
+type LiteralInfo struct {
+	// Prefix is the longest string every match starts with.
+	Prefix string
+
+	// Suffix is the longest string every match ends with.
+	Suffix string
+
+	// Substrings are the strings that every match contains in addition
+	// to the Prefix and Suffix, in the order they appear in the pattern.
+	Substrings []string
+
+	// Exact reports whether every match is equal to the Prefix
+	// (which is equal to the Suffix in this case).
+	Exact bool
+}
+
+// maxLiteralLen limits the length of the strings produced by the repetitions.
+const maxLiteralLen = 1024
+
+// AnalyzeLiterals reports the literal strings that are guaranteed to be
+// matched by re: the longest prefix, the longest suffix and the
+// mandatory substrings between them.
+//
+// Chars that are matched case-insensitively (via `i` flag) are not
+// considered to be literals. Zero-width assertions, like `^` and `\b`,
+// are ignored, so `^foo\b` has the exact "foo" literal.
+func AnalyzeLiterals(re *Regexp) LiteralInfo {
+	foldCase := flagEnabled(re.Flags, 'i', false)
+	info := literalsOf(&re.Expr, &foldCase)
+	result := LiteralInfo{
+		Prefix: info.prefix,
+		Suffix: info.suffix,
+		Exact:  info.exact,
+	}
+	seen := make(map[string]bool)
+	for _, s := range info.inner {
+		if s == "" || seen[s] {
+			continue
+		}
+		seen[s] = true
+		result.Substrings = append(result.Substrings, s)
+	}
+	return result
+}
+
+// exprLiterals is an AnalyzeLiterals intermediate result for a single expression.
+//
+// For exact expressions, prefix and suffix are equal to the matched string.
+type exprLiterals struct {
+	exact  bool
+	prefix string
+	suffix string
+	inner  []string
+}
+
+func exactLiterals(s string) exprLiterals {
+	return exprLiterals{exact: true, prefix: s, suffix: s}
+}
+
+// literalsConcat combines the sequence of expressions literals.
+type literalsConcat struct {
+	result  exprLiterals
+	run     string
+	flushed bool
+}
+
+func (c *literalsConcat) add(info exprLiterals) {
+	c.run += info.prefix
+	if info.exact {
+		return
+	}
+	if c.flushed {
+		c.result.inner = append(c.result.inner, c.run)
+	} else {
+		c.result.prefix = c.run
+		c.flushed = true
+	}
+	c.result.inner = append(c.result.inner, info.inner...)
+	c.run = info.suffix
+}
+
+func (c *literalsConcat) done() exprLiterals {
+	if !c.flushed {
+		return exactLiterals(c.run)
+	}
+	c.result.suffix = c.run
+	return c.result
+}
+
+func literalsOf(e *Expr, foldCase *bool) exprLiterals {
+	switch e.Op {
+	case OpCaret, OpDollar, OpComment,
+		OpPositiveLookahead, OpNegativeLookahead, OpPositiveLookbehind, OpNegativeLookbehind:
+		return exactLiterals("")
+
+	case OpFlagOnlyGroup:
+		*foldCase = flagEnabled(e.Args[0].Value, 'i', *foldCase)
+		return exactLiterals("")
+
+	case OpGroupWithFlags:
+		groupFoldCase := flagEnabled(e.Args[1].Value, 'i', *foldCase)
+		return literalsOf(&e.Args[0], &groupFoldCase)
+
+	case OpCapture, OpNamedCapture, OpGroup, OpAtomicGroup, OpNonGreedy, OpPossessive:
+		groupFoldCase := *foldCase
+		return literalsOf(&e.Args[0], &groupFoldCase)
+
+	case OpConcat, OpLiteral:
+		var c literalsConcat
+		for i := range e.Args {
+			c.add(literalsOf(&e.Args[i], foldCase))
+		}
+		return c.done()
+
+	case OpQuote:
+		var c literalsConcat
+		for _, r := range e.Args[0].Value {
+			c.add(runeLiterals(r, *foldCase))
+		}
+		return c.done()
+
+	case OpAlt:
+		return alternationLiterals(e, foldCase)
+
+	case OpPlus:
+		info := literalsOf(&e.Args[0], foldCase)
+		info.exact = false
+		return info
+
+	case OpRepeat:
+		min, max := e.RepeatBounds()
+		info := literalsOf(&e.Args[0], foldCase)
+		if min == 0 {
+			return exprLiterals{}
+		}
+		if !info.exact {
+			return info
+		}
+		if len(info.prefix)*min > maxLiteralLen {
+			return exprLiterals{prefix: info.prefix, suffix: info.suffix}
+		}
+		s := strings.Repeat(info.prefix, min)
+		if min == max {
+			return exactLiterals(s)
+		}
+		return exprLiterals{prefix: s, suffix: s}
+
+	case OpChar, OpEscapeMeta, OpEscapeChar, OpEscapeHex, OpEscapeOctal:
+		if e.Op == OpEscapeChar {
+			if min, max := escapeCharWidth(e.Args[0].Value); min == 0 && max == 0 {
+				return exactLiterals("")
+			}
+		}
+		r, ok := exprRune(e)
+		if !ok {
+			return exprLiterals{}
+		}
+		return runeLiterals(r, *foldCase)
+	}
+
+	return exprLiterals{}
+}
+
+func runeLiterals(r rune, foldCase bool) exprLiterals {
+	if foldCase && unicode.SimpleFold(r) != r {
+		return exprLiterals{}
+	}
+	return exactLiterals(string(r))
+}
+
+func alternationLiterals(e *Expr, foldCase *bool) exprLiterals {
+	var result exprLiterals
+	for i := range e.Args {
+		info := literalsOf(&e.Args[i], foldCase)
+		if i == 0 {
+			result = info
+			continue
+		}
+		result.exact = result.exact && info.exact && result.prefix == info.prefix
+		result.prefix = commonPrefix(result.prefix, info.prefix)
+		result.suffix = commonSuffix(result.suffix, info.suffix)
+		// Inner strings are not tracked across the alternatives.
+		result.inner = nil
+	}
+	return result
+}
+
+// exprRune returns a char matched by a single char expression.
+func exprRune(e *Expr) (rune, bool) {
+	switch e.Op {
+	case OpChar:
+		r, _ := utf8.DecodeRuneInString(e.Value)
+		return r, true
+	case OpEscapeMeta:
+		r, _ := utf8.DecodeRuneInString(e.Args[0].Value)
+		return r, true
+	case OpEscapeHex:
+		code, err := strconv.ParseUint(e.Args[0].Value, 16, 32)
+		return rune(code), err == nil && code <= unicode.MaxRune
+	case OpEscapeOctal:
+		v := e.Args[0].Value
+		if e.Form == FormDefault && len(v) == 1 && v != "0" {
+			return 0, false // A backreference
+		}
+		code, err := strconv.ParseUint(v, 8, 32)
+		return rune(code), err == nil && code <= unicode.MaxRune
+	case OpEscapeChar:
+		switch v := e.Args[0].Value; v {
+		case "a":
+			return '\a', true
+		case "f":
+			return '\f', true
+		case "t":
+			return '\t', true
+		case "n":
+			return '\n', true
+		case "r":
+			return '\r', true
+		default:
+			if r, size := utf8.DecodeRuneInString(v); size == len(v) && !isAlphanumeric(v[0]) {
+				return r, true
+			}
+		}
+	}
+	return 0, false
+}
+
+func commonPrefix(x, y string) string {
+	n := 0
+	for n < len(x) && n < len(y) && x[n] == y[n] {
+		n++
+	}
+	// Don't cut the multi-byte chars.
+	for n > 0 && n < len(x) && !utf8.RuneStart(x[n]) {
+		n--
+	}
+	return x[:n]
+}
+
+func commonSuffix(x, y string) string {
+	n := 0
+	for n < len(x) && n < len(y) && x[len(x)-1-n] == y[len(y)-1-n] {
+		n++
+	}
+	for n > 0 && !utf8.RuneStart(x[len(x)-n]) {
+		n--
+	}
+	return x[len(x)-n:]
+}
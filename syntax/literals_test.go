@@ -0,0 +1,54 @@
+package syntax
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestAnalyzeLiterals(t *testing.T) {
+	tests := []struct {
+		pattern string
+		want    LiteralInfo
+	}{
+		{``, LiteralInfo{Exact: true}},
+		{`abc`, LiteralInfo{Prefix: "abc", Suffix: "abc", Exact: true}},
+		{`^foo\b$`, LiteralInfo{Prefix: "foo", Suffix: "foo", Exact: true}},
+		{`a\.b\x41\101\t\Q*+\E`, LiteralInfo{Prefix: "a.bAA\t*+", Suffix: "a.bAA\t*+", Exact: true}},
+		{`ab{3}`, LiteralInfo{Prefix: "abbb", Suffix: "abbb", Exact: true}},
+
+		{`abc.*xyz`, LiteralInfo{Prefix: "abc", Suffix: "xyz"}},
+		{`a\d+b[xy]cd\w?e`, LiteralInfo{Prefix: "a", Suffix: "e", Substrings: []string{"b", "cd"}}},
+		{`x(?:ab)+y`, LiteralInfo{Prefix: "xab", Suffix: "aby"}},
+		{`x(a.b)y`, LiteralInfo{Prefix: "xa", Suffix: "by"}},
+		{`.(foo.bar).`, LiteralInfo{Substrings: []string{"foo", "bar"}}},
+		{`.foo.foo.`, LiteralInfo{Substrings: []string{"foo"}}},
+		{`a{2,}b`, LiteralInfo{Prefix: "aa", Suffix: "aab"}},
+		{`a{0,2}b`, LiteralInfo{Prefix: "", Suffix: "b"}},
+		{`\1abc`, LiteralInfo{Suffix: "abc"}},
+
+		{`foo|bar`, LiteralInfo{}},
+		{`foo|foo`, LiteralInfo{Prefix: "foo", Suffix: "foo", Exact: true}},
+		{`prefix1|prefix2`, LiteralInfo{Prefix: "prefix"}},
+		{`a(?:bc|xc)d`, LiteralInfo{Prefix: "a", Suffix: "cd"}},
+		{`фы|фу`, LiteralInfo{Prefix: "ф"}},
+		{`ыф|уф`, LiteralInfo{Suffix: "ф"}},
+
+		{`(?i)abc`, LiteralInfo{}},
+		{`(?i)a1b`, LiteralInfo{Substrings: []string{"1"}}},
+		{`a(?i)b(?-i)c`, LiteralInfo{Prefix: "a", Suffix: "c"}},
+		{`(?i:a)b`, LiteralInfo{Suffix: "b"}},
+		{`(?:(?i)a)b`, LiteralInfo{Suffix: "b"}},
+	}
+
+	p := NewParser(nil)
+	for _, test := range tests {
+		re, err := p.Parse(test.pattern)
+		if err != nil {
+			t.Fatalf("parse(%q): %v", test.pattern, err)
+		}
+		have := AnalyzeLiterals(re)
+		if !reflect.DeepEqual(have, test.want) {
+			t.Errorf("literals(%q):\nhave: %+v\nwant: %+v", test.pattern, have, test.want)
+		}
+	}
+}
@@ -0,0 +1,165 @@
+package syntax
+
+import "unicode/utf8"
+
+// lookbehindRule describes what kind of expressions can be used inside lookbehind.
+// Regexp engines differ a lot here, so the rule is selected by Dialect.
+type lookbehindRule byte
+
+const (
+	// lookbehindAny permits any lookbehind operands.
+	// This is how .NET and ECMAScript engines work.
+	lookbehindAny lookbehindRule = iota
+
+	// lookbehindBounded requires lookbehind operand to have a finite max length.
+	// This is how Java engine works.
+	lookbehindBounded
+
+	// lookbehindFixedAlternatives requires every top-level lookbehind
+	// alternative to be fixed-length, but the lengths may differ.
+	// This is how PCRE and Oniguruma engines work.
+	lookbehindFixedAlternatives
+
+	// lookbehindFixed requires lookbehind operand to be fixed-length.
+	// This is how Python engine works.
+	lookbehindFixed
+)
+
+func (p *Parser) checkLookbehinds(e *Expr) {
+	for i := range e.Args {
+		p.checkLookbehinds(&e.Args[i])
+	}
+	if e.Op != OpPositiveLookbehind && e.Op != OpNegativeLookbehind {
+		return
+	}
+
+	x := e.Args[0]
+	switch p.dialect.lookbehind {
+	case lookbehindBounded:
+		if _, max := exprWidth(x); max < 0 {
+			p.fail(e.Pos, ErrLookbehindUnbounded, "lookbehind assertion has unbounded length")
+		}
+	case lookbehindFixedAlternatives:
+		fixed := isFixedWidth(x)
+		if x.Op == OpAlt {
+			fixed = true
+			for _, alt := range x.Args {
+				fixed = fixed && isFixedWidth(alt)
+			}
+		}
+		if !fixed {
+			p.fail(e.Pos, ErrLookbehindNotFixed, "lookbehind assertion is not fixed length")
+		}
+	case lookbehindFixed:
+		if !isFixedWidth(x) {
+			p.fail(e.Pos, ErrLookbehindNotFixed, "lookbehind assertion is not fixed length")
+		}
+	}
+}
+
+func isFixedWidth(e Expr) bool {
+	min, max := exprWidth(e)
+	return min == max
+}
+
+// exprWidth returns the min and max number of chars that can be matched by e.
+// If there is no upper bound, max is -1.
+func exprWidth(e Expr) (min, max int) {
+	switch e.Op {
+	case OpCaret, OpDollar, OpComment, OpFlagOnlyGroup,
+		OpPositiveLookahead, OpNegativeLookahead, OpPositiveLookbehind, OpNegativeLookbehind:
+		return 0, 0
+
+	case OpLiteral:
+		return len(e.Args), len(e.Args)
+
+	case OpQuote:
+		n := utf8.RuneCountInString(e.Args[0].Value)
+		return n, n
+
+	case OpEscapeChar:
+		return escapeCharWidth(e.Args[0].Value)
+
+	case OpConcat:
+		for _, a := range e.Args {
+			amin, amax := exprWidth(a)
+			min += amin
+			if max >= 0 {
+				max = addWidth(max, amax)
+			}
+		}
+		return min, max
+
+	case OpAlt:
+		min, max = exprWidth(e.Args[0])
+		for _, a := range e.Args[1:] {
+			amin, amax := exprWidth(a)
+			if amin < min {
+				min = amin
+			}
+			if max >= 0 && (amax < 0 || amax > max) {
+				max = amax
+			}
+		}
+		return min, max
+
+	case OpStar:
+		return 0, -1
+	case OpPlus:
+		min, _ := exprWidth(e.Args[0])
+		return min, -1
+	case OpQuestion:
+		_, max := exprWidth(e.Args[0])
+		return 0, max
+
+	case OpRepeat:
+		min, max := exprWidth(e.Args[0])
+		rmin, rmax := e.RepeatBounds()
+		min *= rmin
+		if max >= 0 {
+			max = mulWidth(max, rmax)
+		}
+		return min, max
+
+	case OpNonGreedy, OpPossessive, OpCapture, OpNamedCapture,
+		OpGroup, OpGroupWithFlags, OpAtomicGroup:
+		return exprWidth(e.Args[0])
+
+	case OpAbsentGroup, OpSubroutineCall:
+		return 0, -1
+
+	default:
+		// All other ops match exactly one char.
+		return 1, 1
+	}
+}
+
+func escapeCharWidth(s string) (min, max int) {
+	switch s {
+	case "b", "B", "A", "z", "Z", "G", "K", "<", ">", "zs", "ze":
+		return 0, 0
+	case "R":
+		return 1, 2 // `\r\n` or any single newline char
+	case "X":
+		return 1, -1 // Extended grapheme cluster
+	default:
+		return 1, 1
+	}
+}
+
+func addWidth(x, y int) int {
+	if y < 0 {
+		return -1
+	}
+	return x + y
+}
+
+func mulWidth(x, y int) int {
+	if y < 0 {
+		if x == 0 {
+			return 0
+		}
+		return -1
+	}
+	return x * y
+}
@@ -0,0 +1,75 @@
+package syntax
+
+import (
+	"testing"
+)
+
+func TestLookbehindRules(t *testing.T) {
+	const (
+		unbounded = "lookbehind assertion has unbounded length"
+		notFixed  = "lookbehind assertion is not fixed length"
+	)
+
+	tests := []struct {
+		pattern string
+
+		// Expected errors for lookbehindBounded, lookbehindFixedAlternatives
+		// and lookbehindFixed rules respectively; empty string means no error.
+		want [3]string
+	}{
+		{`(?<=abc)x`, [3]string{}},
+		{`(?<!a\db[xy]\pL.)x`, [3]string{}},
+		{`(?<=a{3})x`, [3]string{}},
+		{`(?<=\bfoo\b)x`, [3]string{}},
+		{`(?<=(?:ab|cd))x`, [3]string{}},
+		{`(?<=\Qa.b\E)x`, [3]string{}},
+		{`(?<=ab|c)x`, [3]string{"", "", notFixed}},
+		{`(?<!(?:ab|c))x`, [3]string{"", notFixed, notFixed}},
+		{`(?<=a?)x`, [3]string{"", notFixed, notFixed}},
+		{`(?<=a{1,3})x`, [3]string{"", notFixed, notFixed}},
+		{`(?<=a+)x`, [3]string{unbounded, notFixed, notFixed}},
+		{`(?<=a|b*)x`, [3]string{unbounded, notFixed, notFixed}},
+		{`(?<=a{2,})x`, [3]string{unbounded, notFixed, notFixed}},
+		{`x(?<=(?<=a*)b)`, [3]string{unbounded, notFixed, notFixed}},
+	}
+
+	rules := []lookbehindRule{
+		lookbehindBounded,
+		lookbehindFixedAlternatives,
+		lookbehindFixed,
+	}
+	for i, rule := range rules {
+		p := NewParser(nil)
+		p.dialect = &dialectInfo{features: featAll, lookbehind: rule}
+		for _, test := range tests {
+			_, err := p.Parse(test.pattern)
+			have := ""
+			if err != nil {
+				have = err.Error()
+			}
+			if have != test.want[i] {
+				t.Errorf("rule=%d parse(%q):\nhave: %q\nwant: %q",
+					rule, test.pattern, have, test.want[i])
+			}
+		}
+	}
+
+	p := NewParser(nil)
+	for _, test := range tests {
+		if _, err := p.Parse(test.pattern); err != nil {
+			t.Errorf("parse(%q): unexpected error with lookbehindAny: %v", test.pattern, err)
+		}
+	}
+}
+
+func TestLookbehindErrorPos(t *testing.T) {
+	p := NewParser(&ParserOptions{Dialect: DialectPython})
+	_, err := p.Parse(`ab(?<=x+)c`)
+	perr, ok := err.(ParseError)
+	if !ok {
+		t.Fatalf("expected ParseError, got %v", err)
+	}
+	if perr.Pos.Begin != 2 || perr.Pos.End != 9 {
+		t.Errorf("error pos mismatch: have %d:%d, want 2:9", perr.Pos.Begin, perr.Pos.End)
+	}
+}
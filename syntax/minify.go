@@ -0,0 +1,205 @@
+package syntax
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Minify returns a shorter pattern that is equivalent to re.
+//
+// The following transformations are applied:
+//
+//   - comments are removed
+//   - redundant `(?:re)` groups are collapsed: `(?:a)*` => `a*`
+//   - repetitions are shortened: `a{1}` => `a`, `a{0,1}` => `a?`, `a{2,2}` => `a{2}`
+//   - char classes are shortened: `[0-9]` => `\d`, `[a-zA-Z0-9_]` => `\w`, `[x]` => `x`
+//
+// Class shortcuts assume ASCII semantics of `\d` and `\w` (like in RE2 and PCRE).
+// re is not modified.
+func Minify(re *Regexp) string {
+	e := re.Expr.Clone()
+	minifyExpr(&e)
+	if e.Op == OpGroup && canUnwrapGroup(nil, &e.Args[0]) {
+		e = e.Args[0]
+	}
+	return Print(&Regexp{Expr: e})
+}
+
+func minifyExpr(e *Expr) {
+	for i := range e.Args {
+		minifyExpr(&e.Args[i])
+	}
+
+	switch e.Op {
+	case OpConcat:
+		// Unwrapped groups can add more args than they remove,
+		// so the args can't be filtered in place.
+		args := make([]Expr, 0, len(e.Args))
+		for _, a := range e.Args {
+			switch {
+			case a.Op == OpConcat:
+				// Removed comments and nested concatenations.
+				args = append(args, a.Args...)
+				continue
+			case a.Op == OpGroup && canUnwrapGroup(e, &a.Args[0]):
+				if a.Args[0].Op == OpConcat {
+					args = append(args, a.Args[0].Args...)
+					continue
+				}
+				a = a.Args[0]
+			}
+			args = append(args, a)
+		}
+		e.Args = args
+
+	case OpCharClass, OpNegCharClass:
+		minifyCharClass(e)
+
+	case OpRepeat:
+		minifyRepeat(e)
+
+	case OpNonGreedy, OpPossessive:
+		if !e.Args[0].IsQuantifier() {
+			// `a{1}?` was turned into `a`.
+			*e = e.Args[0]
+		}
+
+	case OpComment:
+		*e = Expr{Op: OpConcat}
+
+	default:
+		for i := range e.Args {
+			a := &e.Args[i]
+			if a.Op == OpGroup && canUnwrapGroup(e, &a.Args[0]) {
+				*a = a.Args[0]
+			}
+		}
+	}
+}
+
+// canUnwrapGroup reports whether `(?:body)` group can be replaced
+// by its body when used as a parent arg.
+func canUnwrapGroup(parent, body *Expr) bool {
+	if body.Op == OpConcat && len(body.Args) == 1 {
+		body = &body.Args[0]
+	}
+	items := []Expr{*body}
+	if body.Op == OpConcat {
+		items = body.Args
+	}
+	for _, item := range items {
+		// Flags would leak out of the group.
+		if item.Op == OpFlagOnlyGroup {
+			return false
+		}
+	}
+	if parent == nil {
+		return true
+	}
+
+	switch parent.Op {
+	case OpStar, OpPlus, OpQuestion, OpRepeat:
+		switch body.Op {
+		case OpChar, OpDot, OpCharClass, OpNegCharClass,
+			OpEscapeChar, OpEscapeMeta, OpEscapeUni, OpEscapeClass, OpEscapeHex, OpEscapeOctal,
+			OpCapture, OpNamedCapture, OpGroup, OpGroupWithFlags, OpAtomicGroup:
+			return true
+		default:
+			return false
+		}
+	case OpConcat:
+		// `(?:\1)0` can't be printed as `\10`.
+		if len(items) != 0 {
+			last := items[len(items)-1]
+			if last.Op == OpEscapeOctal || (last.Op == OpEscapeHex && last.Form == FormDefault) {
+				return false
+			}
+		}
+		return body.Op != OpAlt
+	case OpNonGreedy, OpPossessive:
+		return false
+	default:
+		return true
+	}
+}
+
+func minifyCharClass(e *Expr) {
+	negated := e.Op == OpNegCharClass
+
+	for i := range e.Args {
+		if isCharRange(&e.Args[i], '0', '9') {
+			e.Args[i] = newEscapeClass("d", false)
+		}
+	}
+
+	if len(e.Args) == 4 {
+		var lower, upper, digit, underscore bool
+		for i := range e.Args {
+			a := &e.Args[i]
+			switch {
+			case isCharRange(a, 'a', 'z'):
+				lower = true
+			case isCharRange(a, 'A', 'Z'):
+				upper = true
+			case a.Op == OpEscapeClass && !a.Negated && a.Args[0].Value == "d":
+				digit = true
+			case a.Op == OpChar && a.Value == "_":
+				underscore = true
+			}
+		}
+		if lower && upper && digit && underscore {
+			e.Args = []Expr{newEscapeClass("w", false)}
+		}
+	}
+
+	if len(e.Args) != 1 {
+		return
+	}
+	a := e.Args[0]
+	switch {
+	case a.Op == OpEscapeClass && !a.Negated:
+		if negated {
+			// `[^\d]` => `\D`.
+			a = newEscapeClass(strings.ToUpper(a.Args[0].Value), true)
+		}
+		*e = a
+	case !negated && a.Op == OpChar && len(a.Value) == 1 && isAlphanumeric(a.Value[0]):
+		*e = a
+	}
+}
+
+func minifyRepeat(e *Expr) {
+	s := e.Args[1].Value
+	if s == "" || s[0] != '{' {
+		// Vim and BRE forms are left as is.
+		return
+	}
+	min, max := repeatBounds(s)
+	switch {
+	case min == 1 && max == 1:
+		*e = e.Args[0]
+	case min == 0 && max == 1:
+		*e = Expr{Op: OpQuestion, Args: []Expr{e.Args[0]}}
+	case min == 0 && max == -1:
+		*e = Expr{Op: OpStar, Args: []Expr{e.Args[0]}}
+	case min == 1 && max == -1:
+		*e = Expr{Op: OpPlus, Args: []Expr{e.Args[0]}}
+	case min == max:
+		e.Args[1].Value = "{" + strconv.Itoa(min) + "}"
+	}
+}
+
+func isCharRange(e *Expr, lo, hi byte) bool {
+	return e.Op == OpCharRange &&
+		e.Args[0].Op == OpChar && e.Args[0].Value == string(lo) &&
+		e.Args[1].Op == OpChar && e.Args[1].Value == string(hi)
+}
+
+func newEscapeClass(s string, negated bool) Expr {
+	return Expr{
+		Op:      OpEscapeClass,
+		Negated: negated,
+		Args:    []Expr{{Op: OpString, Value: s}},
+		Value:   `\` + s,
+	}
+}
@@ -0,0 +1,80 @@
+package syntax
+
+import (
+	"testing"
+)
+
+func TestMinify(t *testing.T) {
+	tests := []struct {
+		pattern string
+		want    string
+	}{
+		{``, ``},
+		{`abc`, `abc`},
+		{`a(?#comment)b`, `ab`},
+		{`(?#x)|a`, `|a`},
+
+		{`(?:a)`, `a`},
+		{`(?:a|b)`, `a|b`},
+		{`(?:a)*`, `a*`},
+		{`(?:ab)*`, `(?:ab)*`},
+		{`(?:a*)*`, `(?:a*)*`},
+		{`x(?:ab)y`, `xaby`},
+		{`(?:a*b)c`, `a*bc`},
+		{`x(?:a|b)y`, `x(?:a|b)y`},
+		{`x(?:)y`, `xy`},
+		{`((?:a|b))`, `(a|b)`},
+		{`(?:(?i)a)b`, `(?:(?i)a)b`},
+		{`(?:\1)0`, `(?:\1)0`},
+		{`(?:[0-9])+`, `\d+`},
+
+		{`a{1}`, `a`},
+		{`a{1,1}b`, `ab`},
+		{`a{0,1}`, `a?`},
+		{`a{0,}`, `a*`},
+		{`a{1,}?`, `a+?`},
+		{`a{1}?`, `a`},
+		{`a{2,2}`, `a{2}`},
+		{`a{2,3}`, `a{2,3}`},
+
+		{`[0-9]`, `\d`},
+		{`[^0-9]`, `\D`},
+		{`[0-9a-f]`, `[\da-f]`},
+		{`[a-zA-Z0-9_]`, `\w`},
+		{`[^_0-9A-Za-z]`, `\W`},
+		{`[\s]`, `\s`},
+		{`[^\s]`, `\S`},
+		{`[x]`, `x`},
+		{`[.]`, `[.]`},
+		{`[^x]`, `[^x]`},
+	}
+
+	p := NewParser(nil)
+	for _, test := range tests {
+		re, err := p.Parse(test.pattern)
+		if err != nil {
+			t.Fatalf("parse(%q): %v", test.pattern, err)
+		}
+		have := Minify(re)
+		if have != test.want {
+			t.Errorf("minify(%q):\nhave: %s\nwant: %s", test.pattern, have, test.want)
+		}
+		if Print(re) != test.pattern {
+			t.Errorf("minify(%q): original AST was modified", test.pattern)
+		}
+		if _, err := p.Parse(have); err != nil {
+			t.Errorf("parse minified %q: %v", have, err)
+		}
+	}
+}
+
+func TestMinifyFreeSpacing(t *testing.T) {
+	p := NewParser(&ParserOptions{FreeSpacing: true})
+	re, err := p.Parse("a b  # comment\n (?: c ) {1}")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if have := Minify(re); have != "abc" {
+		t.Errorf("minify: have %q, want %q", have, "abc")
+	}
+}
@@ -64,13 +64,18 @@ const (
 	OpString
 
 	// OpQuote is a \Q...\E enclosed literal.
+	// Can be used inside char classes as well: `[\Q]-\E]`.
 	// Examples: `\Q.?\E` `\Q?q[]=1`
 	// FormQuoteUnclosed: `\Qabc`
+	// FormQuoteStrayEnd: `\E` that doesn't close any quote; Args[0] is empty
 	// Args[0] - literal value (OpString)
 	OpQuote
 
 	// OpEscapeChar is a single char escape.
-	// Examples: `\d` `\a` `\n`
+	// Examples: `\a` `\n` `\b`
+	// Class shorthands like `\d` are represented by OpEscapeClass.
+	// ECMAScript control char escapes like `\cA` are represented by OpEscapeChar as well.
+	// FormEscapeNoPrefix examples (Vim very magic mode): `<` `>` `%V`
 	// Args[0] - escaped value (OpString)
 	OpEscapeChar
 
@@ -81,19 +86,27 @@ const (
 
 	// OpEscapeOctal is an octal char code escape (up to 3 digits).
 	// Examples: `\123` `\12`
+	// FormEscapeOctalFull examples: `\o{777}` `\o{1234}`
 	// Args[0] - escaped value (OpString)
 	OpEscapeOctal
 
 	// OpEscapeHex is a hex char code escape.
 	// Examples: `\x7F` `\xF7`
 	// FormEscapeHexFull examples: `\x{10FFFF}` `\x{F}`.
+	// FormEscapeUnicode examples: `\u00E9` `\uFFFF`.
+	// FormEscapeUnicodeFull examples (ECMAScript only): `\u{1F600}` `\u{41}`.
 	// Args[0] - escaped value (OpString)
 	OpEscapeHex
 
 	// OpEscapeUni is a Unicode char class escape.
 	// Examples: `\pS` `\pL` `\PL`
 	// FormEscapeUniFull examples: `\p{Greek}` `\p{Symbol}` `\p{^L}`
+	// FormEscapeUniProperty examples: `\p{Script=Greek}` `\p{gc=Lu}`
 	// Args[0] - escaped value (OpString)
+	// Expr.Negated reports whether the class is negated.
+	// For FormEscapeUniProperty:
+	// Args[0] - property name (OpString)
+	// Args[1] - property value (OpString)
 	OpEscapeUni
 
 	// OpCharClass is a char class enclosed in [].
@@ -119,7 +132,7 @@ const (
 	// OpRepeat is a {min,max} repetition quantifier.
 	// Examples: `x{5}` `x{min,max}` `x{min,}`
 	// Args[0] - repeated expression
-	// Args[1] - repeat count (OpString)
+	// Args[1] - repeat count (OpString); Expr.RepeatBounds returns it as numbers
 	OpRepeat
 
 	// OpCapture is `(re)` capturing group.
@@ -142,6 +155,7 @@ const (
 
 	// OpGroupWithFlags is `(?flags:re)` non-capturing group.
 	// Examples: `(?i:abc)` `(?i:x|y)`
+	// FormFlagsReset examples: `(?^:abc)` `(?^i:x|y)`
 	// Args[0] - enclosed expression (OpConcat with 0 args for empty group)
 	// Args[1] - flags (OpString)
 	OpGroupWithFlags
@@ -173,13 +187,40 @@ const (
 
 	// OpFlagOnlyGroup is `(?flags)` form that affects current group flags.
 	// Examples: `(?i)` `(?i-m)` `(?-im)`
+	// FormFlagsReset examples: `(?^)` `(?^i)` `(?^im)`
 	// Args[0] - flags (OpString)
 	OpFlagOnlyGroup
 
 	// OpComment is a group-like regexp comment expression.
 	// Examples: `(?#text)` `(?#)`
+	// FormCommentFreeSpacing examples (x flag is set): `# text\n` `  `
 	OpComment
 
+	// OpSubroutineCall is a Ruby/Oniguruma style `\g<name>` subroutine call.
+	// Examples: `\g<foo>` `\g<1>` `\g<-1>`
+	// FormSubroutineCallQuote examples: `\g'foo'` `\g'1'`
+	// Args[0] - referenced group name or number (OpString)
+	OpSubroutineCall
+
+	// OpAbsentGroup is `(?~re)` Oniguruma absent operator.
+	// It matches any string that doesn't contain re as a substring.
+	// Only recognized with DialectOnig.
+	// Examples: `(?~abc)` `(?~)`
+	// Args[0] - enclosed expression (OpConcat with 0 args for empty group)
+	OpAbsentGroup
+
+	// OpBad is a part of the pattern that can't be parsed.
+	// Only produced when ParserOptions.Recover is set.
+	// Value is the unparsed source text; it can be empty if something is missing.
+	// Examples: `\` in `a\` `)` in `a)b`
+	OpBad
+
+	// OpEscapeClass is a char class shorthand escape.
+	// Examples: `\d` `\D` `\w` `\W` `\s` `\S`
+	// Args[0] - escaped value (OpString)
+	// Expr.Negated is set for the uppercase forms.
+	OpEscapeClass
+
 	// OpNone2 is a sentinel value that is never part of the AST.
 	// OpNone and OpNone2 can be used to cover all ops in a range.
 	OpNone2
@@ -192,4 +233,13 @@ const (
 	FormNamedCaptureAngle
 	FormNamedCaptureQuote
 	FormQuoteUnclosed
+	FormCommentFreeSpacing
+	FormFlagsReset
+	FormEscapeOctalFull
+	FormSubroutineCallQuote
+	FormQuoteStrayEnd
+	FormEscapeUnicode
+	FormEscapeUnicodeFull
+	FormEscapeUniProperty
+	FormEscapeNoPrefix
 )
@@ -2,79 +2,269 @@ package syntax
 
 import (
 	"errors"
+	"strconv"
 	"strings"
+	"unicode/utf8"
 )
 
 type ParserOptions struct {
 	// NoLiterals disables OpChar merging into OpLiteral.
 	NoLiterals bool
+
+	// Dialect selects the regexp flavor to be parsed.
+	// By default (DialectDefault), both RE2 and PCRE syntax is accepted.
+	// Values that are not one of the Dialect constants are treated as DialectDefault.
+	Dialect Dialect
+
+	// Recover enables the error-tolerant parsing mode.
+	// Instead of stopping at the first error, the parser inserts OpBad
+	// expressions at the failure points and continues.
+	// Parse returns the partial AST along with ErrorList in this mode.
+	Recover bool
+
+	// FreeSpacing makes the parser behave as if the pattern started with `(?x)`.
+	// When x flag is set, whitespace and #-comments are parsed as OpComment.
+	FreeSpacing bool
+
+	// AttachComments makes the parser move the OpComment expressions
+	// into the Regexp.Comments of the expressions that follow them:
+	// `(?#x)a` is parsed as OpChar with a single comment.
+	// Comments that are not followed by anything, like in `a(?#x)`,
+	// are kept in the tree.
+	//
+	// This way, the comments are not lost when the expressions are moved
+	// around by the AST rewrites.
+	AttachComments bool
+
+	// DupNames permits several capturing groups to have the same name,
+	// like the PCRE `J` modifier does.
+	// By default, a reused name is reported as ErrDuplicateGroupName
+	// unless the dialect always permits it (.NET and Oniguruma).
+	// ParsePCRE enables it for the patterns with the `J` modifier.
+	DupNames bool
+
+	// StrictRepeat makes the parser report the `{...}` sequences that
+	// look like a malformed repetition, like `.{a}`, `a{,5}` or `a{1, 2}`,
+	// as ErrMalformedRepeat instead of parsing them as literal chars.
+	// A `{` that is not followed by the letters, digits, commas or spaces
+	// and a closing `}`, like in `{"key":`, is still a literal char.
+	StrictRepeat bool
+
+	// MaxDepth limits the expressions nesting depth, so the adversarial
+	// patterns like `((((...))))`, `a****...` or `||||...a` can't exhaust the stack.
+	// Every group, stacked quantifier and leading `|` adds a nesting level.
+	// Deeper patterns are rejected with ErrNestingTooDeep.
+	// If zero, 1000 is used (like in regexp/syntax).
+	MaxDepth int
+
+	// MaxPatternSize limits the pattern length in bytes.
+	// Longer patterns are rejected with ErrPatternTooLong.
+	// If zero, only the Offset type limit is checked.
+	MaxPatternSize int
+
+	// ArenaSize is a number of Exprs that are preallocated by the parser
+	// and reused between Parse calls.
+	// If zero, 256 is used.
+	// A negative value disables the arena, so every Expr is allocated separately.
+	ArenaSize int
+
+	// GrowArena makes the parser add another ArenaSize chunk to the arena
+	// when it's exhausted, so the arena grows up to the largest parsed pattern size.
+	// By default, the Exprs that don't fit the arena are allocated separately.
+	GrowArena bool
 }
 
+// defaultMaxDepth is a ParserOptions.MaxDepth default value.
+const defaultMaxDepth = 1000
+
+// defaultArenaSize is a ParserOptions.ArenaSize default value.
+const defaultArenaSize = 256
+
 func NewParser(opts *ParserOptions) *Parser {
 	return newParser(opts)
 }
 
+// Parser reuses its memory between Parse calls, so it must not be
+// used by several goroutines at once; use ParserPool for that.
 type Parser struct {
-	out      Regexp
-	lexer    lexer
-	exprPool []Expr
+	out   Regexp
+	lexer lexer
+
+	// exprPool is an arena of ArenaSize chunks.
+	exprPool [][]Expr
 
 	prefixParselets [256]prefixParselet
 	infixParselets  [256]infixParselet
 
 	charClass []Expr
+	// exprStack is reused by the non-recursive AST passes.
+	exprStack []*Expr
 	allocated uint
+	depth     int
+
+	opts    ParserOptions
+	dialect *dialectInfo
 
-	opts ParserOptions
+	errors ErrorList
 }
 
 // ParsePCRE parses PHP-style pattern with delimiters.
 // An example of such pattern is `/foo/i`.
+//
+// The modifiers that have an inline flag equivalent, like `i` or `x`,
+// are passed to ParseFlags and recorded into the result Flags field.
+// If the modifiers contain `x`, the pattern is parsed in the
+// free-spacing mode, like with ParserOptions.FreeSpacing.
+//
+// The accepted modifiers are `imsxnUJ`, `A`, `D`, `X`, `u` and `S`.
+// Like in PHP, spaces and newlines among them are ignored.
+// Other modifiers are reported as an error.
 func (p *Parser) ParsePCRE(pattern string) (*RegexpPCRE, error) {
 	pcre, err := p.newPCRE(pattern)
 	if err != nil {
 		return nil, err
 	}
-	if pcre.HasModifier('x') {
-		return nil, errors.New("'x' modifier is not supported")
+	var flags strings.Builder
+	for i := 0; i < len(pcre.Modifiers); i++ {
+		if ch := pcre.Modifiers[i]; strings.IndexByte(pcreInlineFlags, ch) != -1 {
+			flags.WriteByte(ch)
+		}
+	}
+	if pcre.DupNames {
+		dupNames := p.opts.DupNames
+		p.opts.DupNames = true
+		defer func() { p.opts.DupNames = dupNames }()
 	}
-	re, err := p.Parse(pcre.Pattern)
+	re, err := p.ParseFlags(pcre.Pattern, flags.String())
 	if re != nil {
 		pcre.Expr = re.Expr
+		pcre.Flags = re.Flags
+		pcre.Comments = re.Comments
+		pcre.QuantifierComments = re.QuantifierComments
+		pcre.captureIndexes = re.captureIndexes
 	}
 	return pcre, err
 }
 
+// pcreInlineFlags lists the PCRE modifiers that can be
+// expressed as `(?flags)` inside the pattern.
+const pcreInlineFlags = "imsxnUJ"
+
 func (p *Parser) Parse(pattern string) (result *Regexp, err error) {
-	defer func() {
-		r := recover()
-		if r == nil {
-			return
-		}
-		if err2, ok := r.(ParseError); ok {
-			err = err2
-			return
-		}
-		panic(r)
-	}()
+	defer p.catchError(&err)
+
+	if err := p.checkPatternLen(pattern); err != nil {
+		return nil, err
+	}
 
+	p.errors = nil
+	p.depth = 0
+	p.out.Pattern = pattern
+	p.out.Flags = ""
+	p.out.Comments = nil
+	p.out.QuantifierComments = nil
+	p.out.captureIndexes = nil
 	p.lexer.Init(pattern)
+	p.errors = append(p.errors, p.lexer.errors...)
 	p.allocated = 0
-	p.out.Pattern = pattern
 	if pattern == "" {
 		p.out.Expr = *p.newExpr(OpConcat, Position{})
 	} else {
-		p.out.Expr = *p.parseExpr(0)
+		root := p.parseExpr(0)
+		if p.opts.Recover {
+			root = p.parseTrailing(root)
+		}
+		p.out.Expr = *root
 	}
 
 	if !p.opts.NoLiterals {
 		p.mergeChars(&p.out.Expr)
 	}
 	p.setValues(&p.out.Expr)
+	p.numberCaptures(&p.out.Expr)
+	if !p.opts.DupNames && !p.dialect.dupNames {
+		p.checkGroupNames(&p.out.Expr)
+	}
+	if p.opts.AttachComments {
+		p.attachComments(&p.out.Expr)
+	}
+
+	if p.opts.Dialect != DialectDefault {
+		p.checkDialect(&p.out.Expr)
+	}
+	if p.dialect.lookbehind != lookbehindAny {
+		p.checkLookbehinds(&p.out.Expr)
+	}
 
+	if len(p.errors) != 0 {
+		for i := range p.errors {
+			p.errors[i].Text = p.errorText(p.errors[i].Pos)
+		}
+		return &p.out, p.errors
+	}
 	return &p.out, nil
 }
 
+// ParseBytes is like Parse, but the pattern is a byte slice.
+//
+// The pattern is not copied to avoid the allocation: the result Pattern,
+// Expr values and ParseError texts refer to the pattern memory,
+// so it must not be modified while the result (or its clones) is in use.
+func (p *Parser) ParseBytes(pattern []byte) (*Regexp, error) {
+	return p.Parse(bytesToString(pattern))
+}
+
+// ParseFlags is like Parse, but the pattern is parsed as if it
+// started with a `(?flags)` group: ParseFlags(`a b`, "ix") is like Parse(`(?ix)a b`).
+// It's useful when the flags come from an external source,
+// like the PCRE modifiers or a language API argument.
+//
+// The x flag enables the free-spacing mode; other flags don't affect
+// the parsing. The flags are recorded into the result Flags field,
+// so the analyses, like AnalyzeLiterals, can take them into account.
+func (p *Parser) ParseFlags(pattern, flags string) (*Regexp, error) {
+	if flagEnabled(flags, 'x', false) {
+		freeSpacing := p.lexer.opts.freeSpacing
+		p.lexer.opts.freeSpacing = true
+		defer func() { p.lexer.opts.freeSpacing = freeSpacing }()
+	}
+	re, err := p.Parse(pattern)
+	if re != nil {
+		re.Flags = flags
+	}
+	return re, err
+}
+
+// catchError converts a thrown ParseError into the *err value.
+// It must be called via defer.
+func (p *Parser) catchError(err *error) {
+	r := recover()
+	if r == nil {
+		return
+	}
+	if err2, ok := r.(ParseError); ok {
+		err2.Text = p.errorText(err2.Pos)
+		*err = err2
+		return
+	}
+	panic(r)
+}
+
+func (p *Parser) checkPatternLen(pattern string) error {
+	max := uint64(maxPatternLen)
+	if p.opts.MaxPatternSize > 0 && uint64(p.opts.MaxPatternSize) < max {
+		max = uint64(p.opts.MaxPatternSize)
+	}
+	if uint64(len(pattern)) > max {
+		return ParseError{
+			Code: ErrPatternTooLong,
+			Message: "pattern is too long: " + strconv.Itoa(len(pattern)) +
+				" bytes, max is " + strconv.FormatUint(max, 10),
+		}
+	}
+	return nil
+}
+
 type prefixParselet func(token) *Expr
 
 type infixParselet func(*Expr, token) *Expr
@@ -85,7 +275,21 @@ func newParser(opts *ParserOptions) *Parser {
 	if opts != nil {
 		p.opts = *opts
 	}
-	p.exprPool = make([]Expr, 256)
+	if p.opts.MaxDepth == 0 {
+		p.opts.MaxDepth = defaultMaxDepth
+	}
+	if p.opts.ArenaSize == 0 {
+		p.opts.ArenaSize = defaultArenaSize
+	}
+	if p.opts.ArenaSize > 0 {
+		p.exprPool = [][]Expr{make([]Expr, p.opts.ArenaSize)}
+	}
+	p.opts.Dialect = p.opts.Dialect.orDefault()
+	p.dialect = p.opts.Dialect.info()
+	p.lexer.opts.freeSpacing = p.opts.FreeSpacing
+	p.lexer.opts.recover = p.opts.Recover
+	p.lexer.opts.strictRepeat = p.opts.StrictRepeat
+	p.lexer.opts.dialect = p.opts.Dialect
 
 	for tok, op := range tok2op {
 		if op != 0 {
@@ -93,12 +297,28 @@ func newParser(opts *ParserOptions) *Parser {
 		}
 	}
 
+	p.prefixParselets[tokComment] = func(tok token) *Expr {
+		form := FormDefault
+		if p.out.Pattern[tok.pos.Begin] != '(' {
+			form = FormCommentFreeSpacing
+		}
+		return p.newExprForm(OpComment, form, tok.pos)
+	}
+
+	p.prefixParselets[tokBad] = func(tok token) *Expr {
+		return p.newExpr(OpBad, tok.pos)
+	}
+
 	p.prefixParselets[tokQ] = func(tok token) *Expr {
+		if !strings.HasPrefix(p.tokenValue(tok), `\Q`) {
+			lit := p.newExpr(OpString, Position{Begin: tok.pos.Begin, End: tok.pos.Begin})
+			return p.newExprForm(OpQuote, FormQuoteStrayEnd, tok.pos, lit)
+		}
 		litPos := tok.pos
-		litPos.Begin += uint16(len(`\Q`))
+		litPos.Begin += Offset(len(`\Q`))
 		form := FormQuoteUnclosed
 		if strings.HasSuffix(p.tokenValue(tok), `\E`) {
-			litPos.End -= uint16(len(`\E`))
+			litPos.End -= Offset(len(`\E`))
 			form = FormDefault
 		}
 		lit := p.newExpr(OpString, litPos)
@@ -107,24 +327,76 @@ func newParser(opts *ParserOptions) *Parser {
 
 	p.prefixParselets[tokEscapeHexFull] = func(tok token) *Expr {
 		litPos := tok.pos
-		litPos.Begin += uint16(len(`\x{`))
-		litPos.End -= uint16(len(`}`))
+		litPos.Begin += Offset(len(`\x{`))
+		litPos.End -= Offset(len(`}`))
 		lit := p.newExpr(OpString, litPos)
 		return p.newExprForm(OpEscapeHex, FormEscapeHexFull, tok.pos, lit)
 	}
-	p.prefixParselets[tokEscapeUniFull] = func(tok token) *Expr {
+	p.prefixParselets[tokEscapeUnicode] = func(tok token) *Expr {
+		litPos := tok.pos
+		litPos.Begin += Offset(len(`\u`))
+		lit := p.newExpr(OpString, litPos)
+		return p.newExprForm(OpEscapeHex, FormEscapeUnicode, tok.pos, lit)
+	}
+	p.prefixParselets[tokEscapeUnicodeFull] = func(tok token) *Expr {
 		litPos := tok.pos
-		litPos.Begin += uint16(len(`\p{`))
-		litPos.End -= uint16(len(`}`))
+		litPos.Begin += Offset(len(`\u{`))
+		litPos.End -= Offset(len(`}`))
 		lit := p.newExpr(OpString, litPos)
-		return p.newExprForm(OpEscapeUni, FormEscapeUniFull, tok.pos, lit)
+		return p.newExprForm(OpEscapeHex, FormEscapeUnicodeFull, tok.pos, lit)
+	}
+	p.prefixParselets[tokEscapeOctalFull] = func(tok token) *Expr {
+		litPos := tok.pos
+		litPos.Begin += Offset(len(`\o{`))
+		litPos.End -= Offset(len(`}`))
+		lit := p.newExpr(OpString, litPos)
+		return p.newExprForm(OpEscapeOctal, FormEscapeOctalFull, tok.pos, lit)
+	}
+	p.prefixParselets[tokEscapeUniFull] = func(tok token) *Expr {
+		litPos := tok.pos
+		litPos.Begin += Offset(len(`\p{`))
+		litPos.End -= Offset(len(`}`))
+		lit := p.out.Pattern[litPos.Begin:litPos.End]
+		var e *Expr
+		if eq := strings.IndexByte(lit, '='); eq != -1 {
+			namePos := Position{Begin: litPos.Begin, End: litPos.Begin + Offset(eq)}
+			valuePos := Position{Begin: namePos.End + 1, End: litPos.End}
+			name := p.newExpr(OpString, namePos)
+			value := p.newExpr(OpString, valuePos)
+			e = p.newExprForm(OpEscapeUni, FormEscapeUniProperty, tok.pos, name, value)
+		} else {
+			e = p.newExprForm(OpEscapeUni, FormEscapeUniFull, tok.pos, p.newExpr(OpString, litPos))
+		}
+		e.Negated = strings.HasPrefix(p.tokenValue(tok), `\P`) != strings.HasPrefix(lit, "^")
+		return e
 	}
 
 	p.prefixParselets[tokEscapeHex] = func(tok token) *Expr { return p.parseEscape(OpEscapeHex, `\x`, tok) }
 	p.prefixParselets[tokEscapeOctal] = func(tok token) *Expr { return p.parseEscape(OpEscapeOctal, `\`, tok) }
-	p.prefixParselets[tokEscapeChar] = func(tok token) *Expr { return p.parseEscape(OpEscapeChar, `\`, tok) }
+	p.prefixParselets[tokEscapeChar] = func(tok token) *Expr {
+		e := p.parseEscape(OpEscapeChar, `\`, tok)
+		switch p.tokenValue(tok) {
+		case `\d`, `\w`, `\s`:
+			e.Op = OpEscapeClass
+		case `\D`, `\W`, `\S`:
+			e.Op = OpEscapeClass
+			e.Negated = true
+		}
+		return e
+	}
 	p.prefixParselets[tokEscapeMeta] = func(tok token) *Expr { return p.parseEscape(OpEscapeMeta, `\`, tok) }
-	p.prefixParselets[tokEscapeUni] = func(tok token) *Expr { return p.parseEscape(OpEscapeUni, `\p`, tok) }
+	p.prefixParselets[tokEscapeUni] = func(tok token) *Expr {
+		e := p.parseEscape(OpEscapeUni, `\p`, tok)
+		e.Negated = strings.HasPrefix(p.tokenValue(tok), `\P`)
+		return e
+	}
+
+	p.prefixParselets[tokSubroutineCall] = func(tok token) *Expr {
+		return p.parseSubroutineCall(FormDefault, tok)
+	}
+	p.prefixParselets[tokSubroutineCallQuote] = func(tok token) *Expr {
+		return p.parseSubroutineCall(FormSubroutineCallQuote, tok)
+	}
 
 	p.prefixParselets[tokLparen] = func(tok token) *Expr { return p.parseGroup(OpCapture, tok) }
 	p.prefixParselets[tokLparenAtomic] = func(tok token) *Expr { return p.parseGroup(OpAtomicGroup, tok) }
@@ -132,6 +404,8 @@ func newParser(opts *ParserOptions) *Parser {
 	p.prefixParselets[tokLparenNegativeLookahead] = func(tok token) *Expr { return p.parseGroup(OpNegativeLookahead, tok) }
 	p.prefixParselets[tokLparenPositiveLookbehind] = func(tok token) *Expr { return p.parseGroup(OpPositiveLookbehind, tok) }
 	p.prefixParselets[tokLparenNegativeLookbehind] = func(tok token) *Expr { return p.parseGroup(OpNegativeLookbehind, tok) }
+	p.prefixParselets[tokLparenAbsent] = func(tok token) *Expr { return p.parseGroup(OpAbsentGroup, tok) }
+	p.prefixParselets[tokLparenGroup] = func(tok token) *Expr { return p.parseGroup(OpGroup, tok) }
 
 	p.prefixParselets[tokLparenName] = func(tok token) *Expr {
 		return p.parseNamedCapture(FormDefault, tok)
@@ -147,7 +421,9 @@ func newParser(opts *ParserOptions) *Parser {
 
 	p.prefixParselets[tokPipe] = func(tok token) *Expr {
 		// We need prefix pipe parselet to handle `(|x)` syntax.
+		p.enter(tok.pos, "alternations")
 		right := p.parseExpr(1)
+		p.depth--
 		return p.newExpr(OpAlt, tok.pos, p.newEmpty(tok.pos), right)
 	}
 	p.prefixParselets[tokLbracket] = func(tok token) *Expr {
@@ -159,19 +435,29 @@ func newParser(opts *ParserOptions) *Parser {
 
 	p.infixParselets[tokRepeat] = func(left *Expr, tok token) *Expr {
 		repeatLit := p.newExpr(OpString, tok.pos)
-		return p.newExpr(OpRepeat, combinePos(left.Pos, tok.pos), left, repeatLit)
+		p.checkRepeatBounds(tok)
+		return p.newQuantifier(OpRepeat, left, tok, repeatLit)
 	}
 	p.infixParselets[tokStar] = func(left *Expr, tok token) *Expr {
-		return p.newExpr(OpStar, combinePos(left.Pos, tok.pos), left)
+		return p.newQuantifier(OpStar, left, tok)
 	}
 	p.infixParselets[tokConcat] = func(left *Expr, tok token) *Expr {
-		right := p.parseExpr(2)
-		if left.Op == OpConcat {
-			left.Args = append(left.Args, *right)
-			left.Pos.End = right.End()
-			return left
-		}
-		return p.newExpr(OpConcat, combinePos(left.Pos, right.Pos), left, right)
+		return p.appendConcat(left, p.parseExpr(2))
+	}
+	p.infixParselets[tokPositiveLookaheadPostfix] = func(left *Expr, tok token) *Expr {
+		return p.newExpr(OpPositiveLookahead, combinePos(left.Pos, tok.pos), left)
+	}
+	p.infixParselets[tokNegativeLookaheadPostfix] = func(left *Expr, tok token) *Expr {
+		return p.newExpr(OpNegativeLookahead, combinePos(left.Pos, tok.pos), left)
+	}
+	p.infixParselets[tokPositiveLookbehindPostfix] = func(left *Expr, tok token) *Expr {
+		return p.newExpr(OpPositiveLookbehind, combinePos(left.Pos, tok.pos), left)
+	}
+	p.infixParselets[tokNegativeLookbehindPostfix] = func(left *Expr, tok token) *Expr {
+		return p.newExpr(OpNegativeLookbehind, combinePos(left.Pos, tok.pos), left)
+	}
+	p.infixParselets[tokAtomicPostfix] = func(left *Expr, tok token) *Expr {
+		return p.newExpr(OpAtomicGroup, combinePos(left.Pos, tok.pos), left)
 	}
 	p.infixParselets[tokPipe] = p.parseAlt
 	p.infixParselets[tokMinus] = p.parseMinus
@@ -181,11 +467,20 @@ func newParser(opts *ParserOptions) *Parser {
 	return &p
 }
 
+// setValues assigns the Value of every e subexpression.
+// An explicit stack is used instead of the recursion,
+// so deep trees can't exhaust the goroutine stack.
 func (p *Parser) setValues(e *Expr) {
-	for i := range e.Args {
-		p.setValues(&e.Args[i])
+	stack := append(p.exprStack[:0], e)
+	for len(stack) != 0 {
+		e := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		e.Value = p.exprValue(e)
+		for i := range e.Args {
+			stack = append(stack, &e.Args[i])
+		}
 	}
-	e.Value = p.exprValue(e)
+	p.exprStack = stack
 }
 
 func (p *Parser) tokenValue(tok token) string {
@@ -196,10 +491,24 @@ func (p *Parser) exprValue(e *Expr) string {
 	return p.out.Pattern[e.Begin():e.End()]
 }
 
+// mergeChars turns the OpChar sequences inside concatenations into OpLiteral.
+// Like setValues, it doesn't use the recursion.
 func (p *Parser) mergeChars(e *Expr) {
-	for i := range e.Args {
-		p.mergeChars(&e.Args[i])
+	stack := append(p.exprStack[:0], e)
+	for len(stack) != 0 {
+		e := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		p.mergeConcatChars(e)
+		for i := range e.Args {
+			stack = append(stack, &e.Args[i])
+		}
 	}
+	p.exprStack = stack
+}
+
+// mergeConcatChars merges the e OpChar arguments if e is OpConcat.
+// The nested expressions are not visited.
+func (p *Parser) mergeConcatChars(e *Expr) {
 	if e.Op != OpConcat || len(e.Args) < 2 {
 		return
 	}
@@ -233,6 +542,99 @@ func (p *Parser) mergeChars(e *Expr) {
 	}
 }
 
+// numberCaptures records the capture group indexes if they
+// don't follow the order of the opening parentheses.
+// In .NET, named groups are numbered after the unnamed ones
+// and the groups with the same name share the index.
+func (p *Parser) numberCaptures(root *Expr) {
+	if p.opts.Dialect != DialectDotNet {
+		return
+	}
+	var unnamed, named []*Expr
+	WalkExpr(root, func(e *Expr) bool {
+		switch e.Op {
+		case OpCapture:
+			unnamed = append(unnamed, e)
+		case OpNamedCapture:
+			named = append(named, e)
+		}
+		return true
+	})
+	if len(named) == 0 {
+		return
+	}
+	p.out.captureIndexes = make(map[Position]int, len(unnamed)+len(named))
+	n := 0
+	for _, e := range unnamed {
+		n++
+		p.out.captureIndexes[e.Pos] = n
+	}
+	indexes := make(map[string]int, len(named))
+	for _, e := range named {
+		index, ok := indexes[e.Args[1].Value]
+		if !ok {
+			n++
+			index = n
+			indexes[e.Args[1].Value] = index
+		}
+		p.out.captureIndexes[e.Pos] = index
+	}
+}
+
+// checkGroupNames reports the named groups that reuse the name of a previous group.
+func (p *Parser) checkGroupNames(root *Expr) {
+	var names map[string]bool
+	WalkExpr(root, func(e *Expr) bool {
+		if e.Op != OpNamedCapture {
+			return true
+		}
+		name := e.Args[1].Value
+		if names[name] {
+			p.fail(e.Args[1].Pos, ErrDuplicateGroupName, "duplicate capture group name "+name)
+		}
+		if names == nil {
+			names = make(map[string]bool)
+		}
+		names[name] = true
+		return true
+	})
+}
+
+// attachComments moves the OpComment concatenation items
+// into the p.out.Comments of the items that follow them.
+func (p *Parser) attachComments(e *Expr) {
+	for i := range e.Args {
+		p.attachComments(&e.Args[i])
+	}
+	if e.Op != OpConcat {
+		return
+	}
+
+	args := e.Args[:0]
+	var comments []Expr
+	for _, a := range e.Args {
+		if a.Op == OpComment {
+			comments = append(comments, a)
+			continue
+		}
+		if comments != nil {
+			if p.out.Comments == nil {
+				p.out.Comments = make(map[Position][]Expr)
+			}
+			p.out.Comments[a.Pos] = comments
+			comments = nil
+		}
+		args = append(args, a)
+	}
+	// Trailing comments have nothing to be attached to.
+	args = append(args, comments...)
+	if len(args) == 1 {
+		*e = args[0]
+	} else {
+		e.Args = args
+	}
+}
+
 func (p *Parser) newEmpty(pos Position) *Expr {
 	return p.newExpr(OpConcat, pos)
 }
@@ -257,39 +659,142 @@ func (p *Parser) newExpr(op Operation, pos Position, args ...*Expr) *Expr {
 }
 
 func (p *Parser) allocExpr() *Expr {
-	i := p.allocated
-	if i < uint(len(p.exprPool)) {
-		p.allocated++
-		return &p.exprPool[i]
+	if p.opts.ArenaSize < 0 {
+		return &Expr{}
+	}
+	size := uint(p.opts.ArenaSize)
+	chunk := p.allocated / size
+	if chunk == uint(len(p.exprPool)) {
+		if !p.opts.GrowArena {
+			return &Expr{}
+		}
+		// The allocated Exprs are referenced by pointers,
+		// so the existing chunks can't be reallocated.
+		p.exprPool = append(p.exprPool, make([]Expr, size))
 	}
-	return &Expr{}
+	e := &p.exprPool[chunk][p.allocated%size]
+	p.allocated++
+	return e
 }
 
 func (p *Parser) expect(kind tokenKind) Position {
 	tok := p.lexer.NextToken()
 	if tok.kind != kind {
-		throwExpectedFound(tok.pos, kind.String(), tok.kind.String())
+		p.fail(tok.pos, ErrUnterminatedGroup, "expected '"+kind.String()+"', found '"+tok.kind.String()+"'")
+		// Act as if the expected token was found.
+		return p.missingTokenPos(tok)
 	}
 	return tok.pos
 }
 
+// fail reports a parse error.
+// In the recover mode, the error is recorded and the parsing continues.
+// checkRepeatBounds reports the repeat tok bounds that can't be
+// represented as int, like `a{99999999999999999999}`, or that are not
+// numbers at all, like BRE `a\{x\}`.
+func (p *Parser) checkRepeatBounds(tok token) {
+	s := p.tokenValue(tok)
+	_, _, err := parseRepeatBounds(s)
+	switch {
+	case err == nil:
+	case errors.Is(err, strconv.ErrRange):
+		p.fail(tok.pos, ErrRepeatTooLarge, "repeat "+s+" bounds overflow int")
+	default:
+		p.fail(tok.pos, ErrMalformedRepeat, "malformed repeat "+s+": expected {n}, {n,} or {n,m}")
+	}
+}
+
+func (p *Parser) fail(pos Position, code ErrorCode, message string) {
+	if !p.opts.Recover {
+		throw(pos, code, message)
+	}
+	p.errors = append(p.errors, ParseError{Pos: pos, Code: code, Message: message})
+}
+
+// errorText returns the pattern part that is described by pos.
+func (p *Parser) errorText(pos Position) string {
+	begin, end := int(pos.Begin), int(pos.End)
+	if end > len(p.out.Pattern) {
+		end = len(p.out.Pattern)
+	}
+	if begin > end {
+		begin = end
+	}
+	return p.out.Pattern[begin:end]
+}
+
+// missingTokenPos returns an empty position right before tok.
+// tok is pushed back, so it can be consumed by the enclosing expression parser.
+func (p *Parser) missingTokenPos(tok token) Position {
+	if tok.kind != tokNone {
+		p.lexer.pos--
+	}
+	return Position{Begin: tok.pos.Begin, End: tok.pos.Begin}
+}
+
+// parseTrailing handles the tokens that are left after the
+// top-level expression is parsed, like `)` in `a)b`.
+func (p *Parser) parseTrailing(left *Expr) *Expr {
+	for p.lexer.HasMoreTokens() {
+		tok := p.lexer.NextToken()
+		switch tok.kind {
+		case tokConcat:
+			continue
+		case tokRparen:
+			p.fail(tok.pos, ErrUnexpectedToken, "unexpected token: "+tok.String())
+			left = p.appendConcat(left, p.newExpr(OpBad, tok.pos))
+		default:
+			p.lexer.pos--
+			left = p.appendConcat(left, p.parseExpr(0))
+		}
+	}
+	return left
+}
+
+func (p *Parser) appendConcat(left, right *Expr) *Expr {
+	if left.Op == OpConcat {
+		left.Args = append(left.Args, *right)
+		left.Pos.End = right.End()
+		return left
+	}
+	return p.newExpr(OpConcat, combinePos(left.Pos, right.Pos), left, right)
+}
+
 func (p *Parser) parseExpr(precedence int) *Expr {
 	tok := p.lexer.NextToken()
 	prefix := p.prefixParselets[tok.kind]
+	var left *Expr
 	if prefix == nil {
-		throwUnexpectedToken(tok.pos, tok.String())
+		p.fail(tok.pos, ErrUnexpectedToken, "unexpected token: "+tok.String())
+		left = p.parseBad(tok)
+	} else {
+		left = prefix(tok)
 	}
-	left := prefix(tok)
 
+	// Quantifiers and postfix assertions wrap the left expression,
+	// so every such operator adds a nesting level.
+	depth := p.depth
 	for precedence < p.precedenceOf(p.lexer.Peek()) {
 		tok := p.lexer.NextToken()
+		if p.precedenceOf(tok) == 3 {
+			p.enter(tok.pos, "quantifiers")
+		} else {
+			p.depth = depth
+		}
 		infix := p.infixParselets[tok.kind]
 		left = infix(left, tok)
 	}
+	p.depth = depth
 
 	return left
 }
 
+// parseBad is used in the recover mode to handle unexpected tokens.
+// For the unexpected pattern end, an empty OpBad is returned.
+func (p *Parser) parseBad(tok token) *Expr {
+	return p.newExpr(OpBad, tok.pos)
+}
+
 func (p *Parser) parsePrefixElementary(tok token) *Expr {
 	return p.newExpr(tok2op[tok.kind], tok.pos)
 }
@@ -306,7 +811,9 @@ func (p *Parser) parseCharClass(op Operation, tok token) *Expr {
 			break
 		}
 		if next.kind == tokNone {
-			throw(tok.pos, "unterminated '['")
+			p.fail(tok.pos, ErrUnterminatedClass, "unterminated '['")
+			endPos = p.missingTokenPos(next)
+			break
 		}
 	}
 
@@ -330,11 +837,18 @@ func (p *Parser) isValidCharRangeOperand(e *Expr) bool {
 	switch e.Op {
 	case OpEscapeHex, OpEscapeOctal, OpEscapeMeta, OpChar:
 		return true
+	case OpQuote:
+		// `[\Qa\E-z]` is a valid range, but only if exactly one char is quoted.
+		return e.Form == FormDefault && utf8.RuneCountInString(p.exprValue(&e.Args[0])) == 1
 	case OpEscapeChar:
-		switch p.exprValue(e) {
+		v := p.exprValue(e)
+		switch v {
 		case `\\`, `\|`, `\*`, `\+`, `\?`, `\.`, `\[`, `\^`, `\$`, `\(`, `\)`:
 			return true
 		}
+		// `\cA` control char escape.
+		return len(v) == 3 && v[1] == 'c'
+
 	}
 	return false
 }
@@ -345,7 +859,7 @@ func (p *Parser) parsePlus(left *Expr, tok token) *Expr {
 	case OpPlus, OpStar, OpQuestion, OpRepeat:
 		op = OpPossessive
 	}
-	return p.newExpr(op, combinePos(left.Pos, tok.pos), left)
+	return p.newQuantifier(op, left, tok)
 }
 
 func (p *Parser) parseQuestion(left *Expr, tok token) *Expr {
@@ -354,7 +868,27 @@ func (p *Parser) parseQuestion(left *Expr, tok token) *Expr {
 	case OpPlus, OpStar, OpQuestion, OpRepeat:
 		op = OpNonGreedy
 	}
-	return p.newExpr(op, combinePos(left.Pos, tok.pos), left)
+	return p.newQuantifier(op, left, tok)
+}
+
+// newQuantifier creates a quantifier expression that wraps left.
+// The free-spacing comments between left and tok are recorded
+// into the p.out.QuantifierComments.
+func (p *Parser) newQuantifier(op Operation, left *Expr, tok token, args ...*Expr) *Expr {
+	e := p.newExpr(op, combinePos(left.Pos, tok.pos), append([]*Expr{left}, args...)...)
+	trivia := p.lexer.quantifierTrivia[tok.pos.Begin]
+	if len(trivia) == 0 {
+		return e
+	}
+	comments := make([]Expr, len(trivia))
+	for i, t := range trivia {
+		comments[i] = Expr{Op: OpComment, Form: FormCommentFreeSpacing, Pos: t.pos, Value: p.tokenValue(t)}
+	}
+	if p.out.QuantifierComments == nil {
+		p.out.QuantifierComments = make(map[Position][]Expr)
+	}
+	p.out.QuantifierComments[e.Pos] = comments
+	return e
 }
 
 func (p *Parser) parseAlt(left *Expr, tok token) *Expr {
@@ -379,7 +913,20 @@ func (p *Parser) parseGroupItem(tok token) *Expr {
 		// This is needed to handle `() syntax.`
 		return p.newEmpty(tok.pos)
 	}
-	return p.parseExpr(0)
+	p.enter(tok.pos, "groups")
+	x := p.parseExpr(0)
+	p.depth--
+	return x
+}
+
+// enter increases the nesting depth.
+// what describes the nested expressions for the error message.
+func (p *Parser) enter(pos Position, what string) {
+	p.depth++
+	if p.depth > p.opts.MaxDepth {
+		// Not recoverable: the parsing can't continue without the recursion.
+		throw(pos, ErrNestingTooDeep, what+" nesting is too deep: max depth is "+strconv.Itoa(p.opts.MaxDepth))
+	}
 }
 
 func (p *Parser) parseGroup(op Operation, tok token) *Expr {
@@ -395,8 +942,8 @@ func (p *Parser) parseNamedCapture(form Form, tok token) *Expr {
 		prefixLen = len("(?P<")
 	}
 	name := p.newExpr(OpString, Position{
-		Begin: tok.pos.Begin + uint16(prefixLen),
-		End:   tok.pos.End - uint16(len(">")),
+		Begin: tok.pos.Begin + Offset(prefixLen),
+		End:   tok.pos.End - Offset(len(">")),
 	})
 	x := p.parseGroupItem(tok)
 	result := p.newExprForm(OpNamedCapture, form, tok.pos, x, name)
@@ -404,26 +951,40 @@ func (p *Parser) parseNamedCapture(form Form, tok token) *Expr {
 	return result
 }
 
+func (p *Parser) parseSubroutineCall(form Form, tok token) *Expr {
+	name := p.newExpr(OpString, Position{
+		Begin: tok.pos.Begin + Offset(len(`\g<`)),
+		End:   tok.pos.End - Offset(len(`>`)),
+	})
+	return p.newExprForm(OpSubroutineCall, form, tok.pos, name)
+}
+
 func (p *Parser) parseGroupWithFlags(tok token) *Expr {
 	var result *Expr
 	val := p.out.Pattern[tok.pos.Begin+1 : tok.pos.End]
+	form := FormDefault
+	flagsBegin := tok.pos.Begin + Offset(len("(?"))
+	if strings.HasPrefix(val, "?^") {
+		form = FormFlagsReset
+		flagsBegin += Offset(len("^"))
+	}
 	switch {
 	case !strings.HasSuffix(val, ":"):
 		flags := p.newExpr(OpString, Position{
-			Begin: tok.pos.Begin + uint16(len("(?")),
+			Begin: flagsBegin,
 			End:   tok.pos.End,
 		})
-		result = p.newExpr(OpFlagOnlyGroup, tok.pos, flags)
+		result = p.newExprForm(OpFlagOnlyGroup, form, tok.pos, flags)
 	case val == "?:":
 		x := p.parseGroupItem(tok)
 		result = p.newExpr(OpGroup, tok.pos, x)
 	default:
 		flags := p.newExpr(OpString, Position{
-			Begin: tok.pos.Begin + uint16(len("(?")),
-			End:   tok.pos.End - uint16(len(":")),
+			Begin: flagsBegin,
+			End:   tok.pos.End - Offset(len(":")),
 		})
 		x := p.parseGroupItem(tok)
-		result = p.newExpr(OpGroupWithFlags, tok.pos, x, flags)
+		result = p.newExprForm(OpGroupWithFlags, form, tok.pos, x, flags)
 	}
 	result.Pos.End = p.expect(tokRparen).End
 	return result
@@ -431,9 +992,15 @@ func (p *Parser) parseGroupWithFlags(tok token) *Expr {
 
 func (p *Parser) parseEscape(op Operation, prefix string, tok token) *Expr {
 	litPos := tok.pos
-	litPos.Begin += uint16(len(prefix))
+	form := FormDefault
+	if p.out.Pattern[tok.pos.Begin] == '\\' {
+		litPos.Begin += Offset(len(prefix))
+	} else {
+		// Vim "very magic" escapes like `<` have no prefix.
+		form = FormEscapeNoPrefix
+	}
 	lit := p.newExpr(OpString, litPos)
-	return p.newExpr(op, tok.pos, lit)
+	return p.newExprForm(op, form, tok.pos, lit)
 }
 
 func (p *Parser) precedenceOf(tok token) int {
@@ -444,6 +1011,9 @@ func (p *Parser) precedenceOf(tok token) int {
 		return 2
 	case tokPlus, tokStar, tokQuestion, tokRepeat:
 		return 3
+	case tokPositiveLookaheadPostfix, tokNegativeLookaheadPostfix,
+		tokPositiveLookbehindPostfix, tokNegativeLookbehindPostfix, tokAtomicPostfix:
+		return 3
 	default:
 		return 0
 	}
@@ -477,11 +1047,10 @@ func (p *Parser) newPCRE(source string) (*RegexpPCRE, error) {
 	}
 
 	const delimLen = 1
-	j := strings.LastIndexByte(source[delimLen:], endDelim)
+	j := pcreDelimEnd(source, delim, endDelim)
 	if j == -1 {
 		return nil, errors.New("can't find '" + string(endDelim) + "' ending delimiter")
 	}
-	j += delimLen
 
 	pcre := &RegexpPCRE{
 		Pattern:   source[delimLen:j],
@@ -489,6 +1058,9 @@ func (p *Parser) newPCRE(source string) (*RegexpPCRE, error) {
 		Delim:     [2]byte{delim, endDelim},
 		Modifiers: source[j+delimLen:],
 	}
+	if err := pcre.setModifierFlags(); err != nil {
+		return nil, err
+	}
 	return pcre, nil
 }
 
@@ -501,3 +1073,25 @@ var tok2op = [256]Operation{
 	tokPosixClass: OpPosixClass,
 	tokComment:    OpComment,
 }
+
+// pcreDelimEnd returns the source index of the ending delimiter, or -1.
+//
+// Like in PHP, escaped delimiters are skipped: `/a\/b/`.
+// Bracket-style delimiters can be nested: `{a{2}}`.
+func pcreDelimEnd(source string, delim, endDelim byte) int {
+	depth := 0
+	for i := 1; i < len(source); i++ {
+		switch ch := source[i]; {
+		case ch == '\\':
+			i++ // Skip the escaped char
+		case ch == endDelim:
+			if depth == 0 {
+				return i
+			}
+			depth--
+		case ch == delim:
+			depth++
+		}
+	}
+	return -1
+}
@@ -2,6 +2,7 @@ package syntax
 
 import (
 	"fmt"
+	"reflect"
 	"regexp/syntax"
 	"strings"
 	"testing"
@@ -15,6 +16,9 @@ func TestParserErrors(t *testing.T) {
 		{`\`, `unexpected end of pattern: trailing '\'`},
 		{`\x`, `unexpected end of pattern: expected hex-digit or '{'`},
 		{`\x{12`, `can't find closing '}'`},
+		{`\o{12`, `can't find closing '}'`},
+		{`\g<name`, `can't find closing '>'`},
+		{`\g'name`, `can't find closing "'"`},
 		{`(abc`, `expected ')', found 'None'`},
 		{`[abc`, `unterminated '['`},
 		{`[]`, `unterminated '['`},
@@ -41,7 +45,7 @@ func TestParserErrors(t *testing.T) {
 }
 
 func writeExpr(t *testing.T, w *strings.Builder, re *Regexp, e Expr) {
-	assertBeginPos := func(e Expr, begin uint16) {
+	assertBeginPos := func(e Expr, begin Offset) {
 		if e.Begin() != begin {
 			t.Errorf("`%s`: %s begin pos mismatch:\nhave: `%s` (begin=%d)\nwant: `%s` (begin=%d)",
 				re.Pattern, e.Op,
@@ -49,7 +53,7 @@ func writeExpr(t *testing.T, w *strings.Builder, re *Regexp, e Expr) {
 				re.Pattern[begin:e.End()], begin)
 		}
 	}
-	assertEndPos := func(e Expr, end uint16) {
+	assertEndPos := func(e Expr, end Offset) {
 		if e.End() != end {
 			t.Errorf("`%s`: %s end pos mismatch:\nhave: `%s` (end=%d)\nwant: `%s` (end=%d)",
 				re.Pattern, e.Op,
@@ -59,46 +63,77 @@ func writeExpr(t *testing.T, w *strings.Builder, re *Regexp, e Expr) {
 	}
 
 	switch e.Op {
-	case OpChar, OpString, OpPosixClass, OpDot, OpCaret, OpDollar, OpComment:
+	case OpChar, OpString, OpPosixClass, OpDot, OpCaret, OpDollar, OpComment, OpBad:
 		w.WriteString(e.Value)
 
 	case OpQuote:
-		assertBeginPos(e, e.Args[0].Begin()-uint16(len(`\Q`)))
+		if e.Form == FormQuoteStrayEnd {
+			assertBeginPos(e, e.Args[0].Begin())
+			w.WriteString(`\E`)
+			break
+		}
+		assertBeginPos(e, e.Args[0].Begin()-Offset(len(`\Q`)))
 		w.WriteString(`\Q`)
 		writeExpr(t, w, re, e.Args[0])
 		if e.Form != FormQuoteUnclosed {
 			w.WriteString(`\E`)
 		}
 
-	case OpEscapeOctal, OpEscapeChar, OpEscapeMeta:
-		assertBeginPos(e, e.Args[0].Begin()-uint16(len(`\`)))
+	case OpEscapeOctal, OpEscapeChar, OpEscapeMeta, OpEscapeClass:
+		if e.Form == FormEscapeOctalFull {
+			assertBeginPos(e, e.Args[0].Begin()-Offset(len(`\o{`)))
+			assertEndPos(e, e.Args[0].End()+Offset(len(`}`)))
+			w.WriteString(`\o{`)
+			writeExpr(t, w, re, e.Args[0])
+			w.WriteString(`}`)
+			break
+		}
+		assertBeginPos(e, e.Args[0].Begin()-Offset(len(`\`)))
 		w.WriteString(`\`)
 		writeExpr(t, w, re, e.Args[0])
 
 	case OpEscapeUni:
 		switch e.Form {
 		case FormEscapeUniFull:
-			assertBeginPos(e, e.Args[0].Begin()-uint16(len(`\p{`)))
-			assertEndPos(e, e.Args[0].End()+uint16(len(`}`)))
+			assertBeginPos(e, e.Args[0].Begin()-Offset(len(`\p{`)))
+			assertEndPos(e, e.Args[0].End()+Offset(len(`}`)))
+			w.WriteString(`\p{`)
+			writeExpr(t, w, re, e.Args[0])
+			w.WriteString(`}`)
+		case FormEscapeUniProperty:
+			assertBeginPos(e, e.Args[0].Begin()-Offset(len(`\p{`)))
+			assertEndPos(e, e.Args[1].End()+Offset(len(`}`)))
 			w.WriteString(`\p{`)
 			writeExpr(t, w, re, e.Args[0])
+			w.WriteString(`=`)
+			writeExpr(t, w, re, e.Args[1])
 			w.WriteString(`}`)
 		default:
-			assertBeginPos(e, e.Args[0].Begin()-uint16(len(`\p`)))
+			assertBeginPos(e, e.Args[0].Begin()-Offset(len(`\p`)))
 			w.WriteString(`\p`)
 			writeExpr(t, w, re, e.Args[0])
 		}
 
 	case OpEscapeHex:
 		switch e.Form {
+		case FormEscapeUnicode:
+			assertBeginPos(e, e.Args[0].Begin()-Offset(len(`\u`)))
+			w.WriteString(`\u`)
+			writeExpr(t, w, re, e.Args[0])
+		case FormEscapeUnicodeFull:
+			assertBeginPos(e, e.Args[0].Begin()-Offset(len(`\u{`)))
+			assertEndPos(e, e.Args[0].End()+Offset(len(`}`)))
+			w.WriteString(`\u{`)
+			writeExpr(t, w, re, e.Args[0])
+			w.WriteString(`}`)
 		case FormEscapeHexFull:
-			assertBeginPos(e, e.Args[0].Begin()-uint16(len(`\x{`)))
-			assertEndPos(e, e.Args[0].End()+uint16(len(`}`)))
+			assertBeginPos(e, e.Args[0].Begin()-Offset(len(`\x{`)))
+			assertEndPos(e, e.Args[0].End()+Offset(len(`}`)))
 			w.WriteString(`\x{`)
 			writeExpr(t, w, re, e.Args[0])
 			w.WriteString(`}`)
 		default:
-			assertBeginPos(e, e.Args[0].Begin()-uint16(len(`\x`)))
+			assertBeginPos(e, e.Args[0].Begin()-Offset(len(`\x`)))
 			w.WriteString(`\x`)
 			writeExpr(t, w, re, e.Args[0])
 		}
@@ -110,6 +145,15 @@ func writeExpr(t *testing.T, w *strings.Builder, re *Regexp, e Expr) {
 			writeExpr(t, w, re, a)
 		}
 
+	case OpSubroutineCall:
+		assertBeginPos(e, e.Args[0].Begin()-Offset(len(`\g<`)))
+		assertEndPos(e, e.Args[0].End()+Offset(len(`>`)))
+		if e.Form == FormSubroutineCallQuote {
+			fmt.Fprintf(w, `\g'%s'`, e.Args[0].Value)
+		} else {
+			fmt.Fprintf(w, `\g<%s>`, e.Args[0].Value)
+		}
+
 	case OpCharRange:
 		assertBeginPos(e, e.Args[0].Begin())
 		assertEndPos(e, e.Args[1].End())
@@ -133,18 +177,24 @@ func writeExpr(t *testing.T, w *strings.Builder, re *Regexp, e Expr) {
 	case OpFlagOnlyGroup:
 		assertEndPos(e, e.Args[0].End()+1)
 		w.WriteString("(?")
+		if e.Form == FormFlagsReset {
+			w.WriteByte('^')
+		}
 		w.WriteString(e.Args[0].Value)
 		w.WriteByte(')')
 
 	case OpGroupWithFlags:
 		assertEndPos(e, e.Args[0].End()+1)
 		w.WriteString("(?")
+		if e.Form == FormFlagsReset {
+			w.WriteByte('^')
+		}
 		w.WriteString(e.Args[1].Value)
 		w.WriteByte(':')
 		writeExpr(t, w, re, e.Args[0])
 		w.WriteByte(')')
 
-	case OpCapture, OpGroup, OpAtomicGroup, OpPositiveLookahead, OpNegativeLookahead, OpPositiveLookbehind, OpNegativeLookbehind:
+	case OpCapture, OpGroup, OpAtomicGroup, OpPositiveLookahead, OpNegativeLookahead, OpPositiveLookbehind, OpNegativeLookbehind, OpAbsentGroup:
 		assertEndPos(e, e.Args[0].End()+1)
 		w.WriteByte('(')
 		switch e.Op {
@@ -160,6 +210,8 @@ func writeExpr(t *testing.T, w *strings.Builder, re *Regexp, e Expr) {
 			w.WriteString("?<=")
 		case OpNegativeLookbehind:
 			w.WriteString("?<!")
+		case OpAbsentGroup:
+			w.WriteString("?~")
 		}
 		writeExpr(t, w, re, e.Args[0])
 		w.WriteByte(')')
@@ -224,9 +276,10 @@ func TestWriteExpr(t *testing.T) {
 	// They also verify that AST node positions are correct.
 
 	tests := []struct {
-		pat string
-		o1  Operation
-		o2  Operation
+		pat  string
+		o1   Operation
+		o2   Operation
+		opts ParserOptions
 	}{
 		{pat: `(?#?#)$`, o1: OpDollar, o2: OpComment},
 		{pat: `(foobar|baz)*+(?#the comment)`, o1: OpPossessive, o2: OpComment},
@@ -240,16 +293,21 @@ func TestWriteExpr(t *testing.T) {
 		{pat: `x{1,2}y*`, o1: OpRepeat, o2: OpStar},
 		{pat: `x{11,30}y+`, o1: OpRepeat, o2: OpPlus},
 		{pat: `x{1,}$`, o1: OpRepeat, o2: OpDollar},
-		{pat: `\p{Cyrillic}\d`, o1: OpEscapeUni, o2: OpEscapeChar},
+		{pat: `\p{Cyrillic}\d`, o1: OpEscapeUni, o2: OpEscapeClass},
 		{pat: `x\p{Greek}y+?`, o1: OpEscapeUni, o2: OpNonGreedy},
 		{pat: `x\p{L}+y`, o1: OpEscapeUni, o2: OpPlus},
 		{pat: `^\pL`, o1: OpEscapeUni, o2: OpCaret},
 		{pat: `^x\pLy`, o1: OpEscapeUni, o2: OpCaret},
-		{pat: `\d?`, o1: OpEscapeChar, o2: OpQuestion},
+		{pat: `\d?`, o1: OpEscapeClass, o2: OpQuestion},
+		{pat: `\a\b[\W\t]`, o1: OpEscapeChar, o2: OpEscapeClass},
 		{pat: `[\xC0-\xC6]`, o1: OpCharRange, o2: OpEscapeHex},
 		{pat: `\01\xff`, o1: OpEscapeOctal, o2: OpEscapeHex},
+		{pat: `\u00E9\u{1F600}[\u0041-\u{5A}]`, o1: OpEscapeHex, o2: OpCharRange, opts: ParserOptions{Dialect: DialectECMAScript}},
+		{pat: `\cA[\cA-\cZ]`, o1: OpEscapeChar, o2: OpCharRange, opts: ParserOptions{Dialect: DialectECMAScript}},
+		{pat: `\o{17}[\o{0}-\o{7}]`, o1: OpEscapeOctal, o2: OpCharRange},
 		{pat: `\111x\Qabc`, o1: OpEscapeOctal, o2: OpQuote},
 		{pat: `x\Qabc\E.(?:s:..)`, o1: OpQuote, o2: OpGroupWithFlags},
+		{pat: `[\Q]-\E][\Qa\E-z]\E`, o1: OpQuote, o2: OpCharRange},
 		{pat: `(?i:foo[[:^alpha:]])`, o1: OpGroupWithFlags, o2: OpPosixClass},
 		{pat: `a[[:digit:]\]]`, o1: OpPosixClass, o2: OpEscapeMeta},
 		{pat: `(?:fa*)`, o1: OpGroup, o2: OpStar},
@@ -261,6 +319,7 @@ func TestWriteExpr(t *testing.T) {
 		{pat: `x{1,}?.?.`, o1: OpNonGreedy, o2: OpDot},
 		{pat: `(?i)f.o`, o1: OpFlagOnlyGroup, o2: OpDot},
 		{pat: `(?:(?i)[^a-z]o)`, o1: OpFlagOnlyGroup, o2: OpNegCharClass},
+		{pat: `(?^)x(?^i:y)`, o1: OpFlagOnlyGroup, o2: OpGroupWithFlags},
 		{pat: `(?:(?P<foo>x))`, o1: OpString, o2: OpChar},
 		{pat: `(?>atomic){2}.(?=x)`, o1: OpAtomicGroup, o2: OpPositiveLookahead},
 		{pat: `(?:(?>g2)g1(?=))`, o1: OpAtomicGroup, o2: OpPositiveLookahead},
@@ -269,6 +328,12 @@ func TestWriteExpr(t *testing.T) {
 		{pat: `\s*\{weight=(\d+)\}\s(?!\s)*`, o1: OpNegativeLookahead},
 		{pat: `(?!x)[.?,!;:@#$%^&*()]+`, o1: OpNegativeLookahead},
 		{pat: `--(?<var_name>[\\w-]+?):\\s+?(?'var_val'.+?);`, o1: OpNamedCapture},
+		{pat: `(?<a>x)\g<a>+`, o1: OpSubroutineCall, o2: OpPlus},
+		{pat: `(x)\g'1'|y`, o1: OpSubroutineCall, o2: OpAlt},
+		{pat: `(?~abc)+`, o1: OpAbsentGroup, o2: OpPlus, opts: ParserOptions{Dialect: DialectOnig}},
+		{pat: `(?~)|(?~(?~x))`, o1: OpAbsentGroup, o2: OpAlt, opts: ParserOptions{Dialect: DialectOnig}},
+		{pat: `a)b)`, o1: OpBad, o2: OpLiteral, opts: ParserOptions{Recover: true}},
+		{pat: `x|*`, o1: OpBad, o2: OpAlt, opts: ParserOptions{Recover: true}},
 		{pat: `^ *(#{1,6}) *([^\n]+?) *#* *(?:\n|$)`},
 		{pat: `^4\d{12}(\d{3})?$`},
 	}
@@ -289,11 +354,16 @@ func TestWriteExpr(t *testing.T) {
 		return b.String(), nil
 	}
 
-	p := NewParser(nil)
+	parsers := make(map[ParserOptions]*Parser)
 	for _, test := range tests {
+		p := parsers[test.opts]
+		if p == nil {
+			p = NewParser(&test.opts)
+			parsers[test.opts] = p
+		}
 		pattern := "_" + test.pat + "_"
 		re, err := p.Parse(pattern)
-		if err != nil {
+		if err != nil && !test.opts.Recover {
 			t.Fatalf("parse(%q): %v", test.pat, err)
 		}
 		have, err := exprToString(re)
@@ -304,6 +374,11 @@ func TestWriteExpr(t *testing.T) {
 		if have != want {
 			t.Fatalf("result mismatch:\nhave: `%s`\nwant: `%s`", have, want)
 		}
+		if test.opts.Dialect != DialectPOSIXBasic && test.opts.Dialect != DialectVim {
+			if have := Print(re); have != want {
+				t.Errorf("print mismatch:\nhave: `%s`\nwant: `%s`", have, want)
+			}
+		}
 		if test.o1 != 0 {
 			toCover[test.o1]--
 		}
@@ -378,11 +453,15 @@ func TestParser(t *testing.T) {
 		{`x(?i)y`, `{x (flags ?i) y}`},
 		{`x(?i-m)y`, `{x (flags ?i-m) y}`},
 		{`x(?-im)y`, `{x (flags ?-im) y}`},
+		{`x(?^)y`, `{x (flags ?^) y}`},
+		{`x(?^i)y`, `{x (flags ?^i) y}`},
 
 		// Non-capturing groups with flags.
 		{`x(?i:)y`, `{x (group {} ?i) y}`},
 		{`x(?im:.)y`, `{x (group . ?im) y}`},
 		{`x(?i-m:ab)y`, `{x (group ab ?i-m) y}`},
+		{`x(?^:ab)y`, `{x (group ab ?^) y}`},
+		{`x(?^im:ab)y`, `{x (group ab ?^im) y}`},
 
 		// Named captures.
 		{`x(?P<g>)y`, `{x (capture {} g) y}`},
@@ -431,6 +510,7 @@ func TestParser(t *testing.T) {
 		// Full Unicode escapes.
 		{`\p{Greek}\p{L}`, `{\p{Greek} \p{L}}`},
 		{`\P{Greek}\p{^L}`, `{\P{Greek} \p{^L}}`},
+		{`\p{Script=Greek}\P{gc=}`, `{\p{Script=Greek} \P{gc=}}`},
 
 		// Octal escapes.
 		{`\0`, `\0`},
@@ -439,6 +519,9 @@ func TestParser(t *testing.T) {
 		{`\777`, `\777`},
 		{`\78`, `{\7 8}`},
 		{`\778`, `{\77 8}`},
+		{`\o{777}`, `\o{777}`},
+		{`\o{1}2`, `{\o{1} 2}`},
+		{`[\o{60}-\o{71}]`, `[\o{60}-\o{71}]`},
 
 		// Short hex escapes.
 		{`\xfff`, `{\xff f}`},
@@ -533,6 +616,12 @@ func TestParser(t *testing.T) {
 		{`x\Q`, `{x (q \Q)}`},
 		{`x\Qy`, `{x (q \Qy)}`},
 		{`x\Qyz`, `{x (q \Qyz)}`},
+		{`a\Eb`, `{a (q \E) b}`},
+		{`\Qa\E\E`, `{(q \Qa\E) (q \E)}`},
+		{`[\Q]-\E]`, `[(q \Q]-\E)]`},
+		{`[\Q^\E\E]`, `[(q \Q^\E) (q \E)]`},
+		{`[\Qa\E-\Qz\E]`, `[(q \Qa\E)-(q \Qz\E)]`},
+		{`[\Qab\E-z]`, `[(q \Qab\E) - z]`},
 
 		// Incomplete `x|` and `|x` expressions are valid.
 		{`(docker-|)`, `(capture (or docker- {}))`},
@@ -546,6 +635,19 @@ func TestParser(t *testing.T) {
 		{`.xy`, `{. xy}`},
 		{`foo?|bar`, `(or {fo (? o)} bar)`},
 
+		// Subroutine calls. Ruby/Oniguruma-only.
+		{`(?<x>a)\g<x>`, `{(capture a x) (call x)}`},
+		{`(a)\g'1'+`, `{(capture a) (+ (call 1))}`},
+		{`\g<-1>\g<+1>`, `{(call -1) (call +1)}`},
+
+		// Free-spacing mode.
+		{`(?x) a b`, `{(flags ?x) /* */ a /* */ b}`},
+		{`(?x)a+ # comment`, `{(flags ?x) (+ a) /* # comment*/}`},
+		{"(?x)a # comment\n +b", "{(flags ?x) (+ a) b}"},
+		{`(?x)a | b`, `(or {(flags ?x) a /* */} {/* */ b})`},
+		{`(?x:a b)c d`, `{(group {a /* */ b} ?x) c d}`},
+		{`(?x)a\ b`, `{(flags ?x) a \  b}`},
+
 		// Tests from the patterns found in various GitHub projects.
 		{`Adm([^i]|$)`, `{Adm (capture (or [^i] $))}`},
 		{`\.(com|com\.\w{2})$`, `{\. (capture (or com {com \. (repeat \w {2})})) $}`},
@@ -566,6 +668,223 @@ func TestParser(t *testing.T) {
 	}
 }
 
+func TestParserFreeSpacing(t *testing.T) {
+	runParserTests(t, &ParserOptions{FreeSpacing: true}, []parserTest{
+		{` `, `/* */`},
+		{`a b`, `{a /* */ b}`},
+		{`[ ]# comment`, `{[ ] /*# comment*/}`},
+		{`a (?-x) b`, `{a /* */ (flags ?-x)  b}`},
+		{`(?-x: a ) b`, `{(group  a  ?-x) /* */ b}`},
+		{`(?^) a`, `{(flags ?^)  a}`},
+		{`(?^x) a`, `{(flags ?^x) /* */ a}`},
+	})
+}
+
+func TestParserOniguruma(t *testing.T) {
+	runParserTests(t, &ParserOptions{Dialect: DialectOnig}, []parserTest{
+		{`(?~)`, `(absent {})`},
+		{`(?~abc)`, `(absent abc)`},
+		{`/\*(?~\*/)\*/`, `{/ \* (absent {\* /}) \* /}`},
+		{`(?~|abc|.*)`, `(absent (or {} abc (* .)))`},
+	})
+
+	// In other dialects, (?~ is parsed as a flags group.
+	runParserTests(t, nil, []parserTest{
+		{`(?~abc)`, `(flags ?~abc)`},
+	})
+}
+
+func TestParserVim(t *testing.T) {
+	runParserTests(t, &ParserOptions{Dialect: DialectVim}, []parserTest{
+		// Magic (default) mode.
+		{`a*`, `(* a)`},
+		{`*a`, `*a`},
+		{`a\+b\=c\?`, `{(+ a) (? b) (? c)}`},
+		{`\(a\|b\)\+`, `(+ (capture (or a b)))`},
+		{`(a|b)+`, `(a|b)+`},
+		{`\%(ab\)`, `(group ab)`},
+		{`a\{2,3}`, `(repeat a \{2,3})`},
+		{`a\{-}b\{-1,\}`, `{(repeat a \{-}) (repeat b \{-1,\})}`},
+		{`x.\.`, `{x . \.}`},
+		{`[a-z]\+`, `(+ [a-z])`},
+		{`[^a]`, `[^a]`},
+		{`\_[a-z]`, `[a-z]`},
+		{`\_s\_.`, `{\_s \_.}`},
+		{`^a$`, `{^ a $}`},
+		{`^*a`, `{^ *a}`},
+		{`\(^*\)`, `(capture {^ *})`},
+		{`a^b$c`, `a^b$c`},
+		{`\(^a$\|^b$\)`, `(capture (or {^ a $} {^ b $}))`},
+		{`foo\zsbar\ze`, `{foo \zs bar \ze}`},
+		{`\<foo\>`, `{\< foo \>}`},
+		{`\(foo\)\@<=bar`, `{(?<= (capture foo)) bar}`},
+		{`\(foo\)\@<!bar`, `{(?<! (capture foo)) bar}`},
+		{`foo\(bar\)\@=`, `{foo (?= (capture bar))}`},
+		{`foo\(bar\)\@!`, `{foo (?! (capture bar))}`},
+		{`\(a*\)\@>`, `(atomic (capture (* a)))`},
+		{`\(a\)\@123<=b`, `{(?<= (capture a)) b}`},
+
+		// Very magic mode.
+		{`\v(a|b)+`, `{\v (+ (capture (or a b)))}`},
+		{`\v^a{1,2}$`, `{\v ^ (repeat a {1,2}) $}`},
+		{`\v<foo>`, `{\v < foo >}`},
+		{`\vfoo(bar)@!`, `{\v foo (?! (capture bar))}`},
+		{`\v%(a)\(`, `{\v (group a) \(}`},
+		{`\va=\=`, `{\v (? a) \=}`},
+		{`\v%V*`, `{\v (* %V)}`},
+		{`\v%V^a`, `{\v %V ^a}`},
+
+		// Nomagic and very nomagic modes.
+		{`\M.*\.\*`, `{\M .* (* .)}`},
+		{`\V.*[]`, `{\V .*[]}`},
+		{`\V\(a\)\+`, `{\V (+ (capture a))}`},
+		{`a\mb*`, `{a \m (* b)}`},
+	})
+}
+
+func TestParserEscapeValue(t *testing.T) {
+	tests := []struct {
+		dialect Dialect
+		pattern string
+		want    string
+	}{
+		{DialectDefault, `\pL`, `L`},
+		{DialectDefault, `\PL`, `L`},
+		{DialectDefault, `\x41`, `41`},
+		{DialectDefault, `\n`, `n`},
+		{DialectVim, `\<`, `<`},
+		{DialectVim, `\v<`, `<`},
+	}
+
+	for _, test := range tests {
+		re, err := NewParser(&ParserOptions{Dialect: test.dialect}).Parse(test.pattern)
+		if err != nil {
+			t.Fatalf("parse(%q): %v", test.pattern, err)
+		}
+		e := &re.Expr
+		if e.Op == OpConcat {
+			e = &e.Args[len(e.Args)-1]
+		}
+		if have := e.Args[0].Value; have != test.want {
+			t.Errorf("parse(%q): escape value mismatch:\nhave: %q\nwant: %q",
+				test.pattern, have, test.want)
+		}
+	}
+}
+
+func TestParserVimErrors(t *testing.T) {
+	runParserErrorTests(t, &ParserOptions{Dialect: DialectVim}, []parserTest{
+		{`a\`, `unexpected end of pattern: trailing '\'`},
+		{`a\{1`, `can't find closing '}'`},
+		{`a\{x}`, `malformed repeat \{x}: expected {n}, {n,} or {n,m}`},
+		{`a\{-1,99999999999999999999}`, `repeat \{-1,99999999999999999999} bounds overflow int`},
+		{`\(a\)\@`, `expected '=', '!', '>', '<=' or '<!' after '@'`},
+		{`\(a\)\@<`, `expected '=', '!', '>', '<=' or '<!' after '@'`},
+		{`\(a`, `expected ')', found 'None'`},
+		{`a\z`, `unexpected end of pattern: incomplete '\z' escape`},
+	})
+}
+
+func TestParserBRE(t *testing.T) {
+	runParserTests(t, &ParserOptions{Dialect: DialectPOSIXBasic}, []parserTest{
+		{`a*`, `(* a)`},
+		{`*a`, `*a`},
+		{`^*a`, `{^ *a}`},
+		{`\(*a\)`, `(capture *a)`},
+		{`(a|b)+?{1}`, `(a|b)+?{1}`},
+		{`\(a\|b\)\+`, `(+ (capture (or a b)))`},
+		{`a\?`, `(? a)`},
+		{`a\{2,3\}`, `(repeat a \{2,3\})`},
+		{`a\{2\}b`, `{(repeat a \{2\}) b}`},
+		{`^a$`, `{^ a $}`},
+		{`a^b$c`, `a^b$c`},
+		{`\(^a$\|^b$\)`, `(capture (or {^ a $} {^ b $}))`},
+		{`x.\.\*`, `{x . \. \*}`},
+		{`\(a\)\1`, `{(capture a) \1}`},
+		{`[\d]`, `[\ d]`},
+		{`[^]\]`, `[^] \]`},
+		{`[[:alpha:]-]`, `[[:alpha:] -]`},
+		{`\<word\>`, `{\< word \>}`},
+	})
+}
+
+func TestParserBREErrors(t *testing.T) {
+	runParserErrorTests(t, &ParserOptions{Dialect: DialectPOSIXBasic}, []parserTest{
+		{`a\`, `unexpected end of pattern: trailing '\'`},
+		{`a\{1`, `can't find closing '\}'`},
+		{`a\{1}`, `can't find closing '\}'`},
+		{`a\{x\}`, `malformed repeat \{x\}: expected {n}, {n,} or {n,m}`},
+		{`a\{1,+2\}`, `malformed repeat \{1,+2\}: expected {n}, {n,} or {n,m}`},
+		{`\(a`, `expected ')', found 'None'`},
+	})
+}
+
+func TestParserERE(t *testing.T) {
+	runParserTests(t, &ParserOptions{Dialect: DialectPOSIXExtended}, []parserTest{
+		{`(a|b)+`, `(+ (capture (or a b)))`},
+		{`a{2,3}b?`, `{(repeat a {2,3}) (? b)}`},
+		{`^a.\.\{$`, `{^ a . \. \{ $}`},
+		{`[\d]`, `[\ d]`},
+		{`[[:digit:]]+\n`, `{(+ [[:digit:]]) \n}`},
+		{`a**`, `(* (* a))`},
+	})
+}
+
+func TestParserEREErrors(t *testing.T) {
+	runParserErrorTests(t, &ParserOptions{Dialect: DialectPOSIXExtended}, []parserTest{
+		{`(?:a)`, `(?...) groups are not supported in POSIX ERE`},
+		{`a(?=b)`, `(?...) groups are not supported in POSIX ERE`},
+		{`a(?#c)`, `(?...) groups are not supported in POSIX ERE`},
+		{`a*?`, `'?' after a quantifier is not supported in POSIX ERE`},
+		{`a{1,2}?`, `'?' after a quantifier is not supported in POSIX ERE`},
+		{`a++`, `'+' after a quantifier is not supported in POSIX ERE`},
+		{`\d+`, `'\d' escape is not supported in POSIX ERE`},
+		{`a\pL`, `'\p' escape is not supported in POSIX ERE`},
+		{`\Qa\E`, `'\Q' escape is not supported in POSIX ERE`},
+		{`(a)\1`, `'\1' escape is not supported in POSIX ERE`},
+		{`\✓`, `'\✓' escape is not supported in POSIX ERE`},
+	})
+}
+
+type parserTest struct {
+	pattern string
+	want    string
+}
+
+func runParserTests(t *testing.T, opts *ParserOptions, tests []parserTest) {
+	t.Helper()
+	p := NewParser(opts)
+	for _, test := range tests {
+		re, err := p.Parse(test.pattern)
+		if err != nil {
+			t.Fatalf("parse(%q) error: %v", test.pattern, err)
+		}
+		have := formatSyntax(re)
+		if have != test.want {
+			t.Fatalf("parse(%q):\nhave: %s\nwant: %s",
+				test.pattern, have, test.want)
+		}
+	}
+}
+
+// runParserErrorTests is like runParserTests, but it expects
+// every pattern to fail with the test.want error message.
+func runParserErrorTests(t *testing.T, opts *ParserOptions, tests []parserTest) {
+	t.Helper()
+	p := NewParser(opts)
+	for _, test := range tests {
+		_, err := p.Parse(test.pattern)
+		have := "<nil>"
+		if err != nil {
+			have = err.Error()
+		}
+		if have != test.want {
+			t.Errorf("parse(%q):\nhave: %s\nwant: %s",
+				test.pattern, have, test.want)
+		}
+	}
+}
+
 func formatSyntax(re *Regexp) string {
 	return formatExprSyntax(re, re.Expr)
 }
@@ -581,7 +900,7 @@ func formatExprSyntax(re *Regexp, e Expr) string {
 		default:
 			return e.Value
 		}
-	case OpString, OpEscapeChar, OpEscapeMeta, OpEscapeOctal, OpEscapeUni, OpEscapeHex, OpPosixClass:
+	case OpString, OpEscapeChar, OpEscapeMeta, OpEscapeOctal, OpEscapeUni, OpEscapeClass, OpEscapeHex, OpPosixClass:
 		return e.Value
 	case OpRepeat:
 		return fmt.Sprintf("(repeat %s %s)", formatExprSyntax(re, e.Args[0]), e.Args[1].Value)
@@ -593,6 +912,8 @@ func formatExprSyntax(re *Regexp, e Expr) string {
 		return "."
 	case OpQuote:
 		return fmt.Sprintf("(q %s)", e.Value)
+	case OpBad:
+		return fmt.Sprintf("(bad %s)", e.Value)
 	case OpCharRange:
 		return fmt.Sprintf("%s-%s", formatExprSyntax(re, e.Args[0]), formatExprSyntax(re, e.Args[1]))
 	case OpCharClass:
@@ -611,9 +932,17 @@ func formatExprSyntax(re *Regexp, e Expr) string {
 		return fmt.Sprintf("(group %s)", formatExprSyntax(re, e.Args[0]))
 	case OpAtomicGroup:
 		return fmt.Sprintf("(atomic %s)", formatExprSyntax(re, e.Args[0]))
+	case OpAbsentGroup:
+		return fmt.Sprintf("(absent %s)", formatExprSyntax(re, e.Args[0]))
 	case OpGroupWithFlags:
+		if e.Form == FormFlagsReset {
+			return fmt.Sprintf("(group %s ?^%s)", formatExprSyntax(re, e.Args[0]), e.Args[1].Value)
+		}
 		return fmt.Sprintf("(group %s ?%s)", formatExprSyntax(re, e.Args[0]), e.Args[1].Value)
 	case OpFlagOnlyGroup:
+		if e.Form == FormFlagsReset {
+			return fmt.Sprintf("(flags ?^%s)", formatExprSyntax(re, e.Args[0]))
+		}
 		return fmt.Sprintf("(flags ?%s)", formatExprSyntax(re, e.Args[0]))
 	case OpPositiveLookahead:
 		return fmt.Sprintf("(?= %s)", formatExprSyntax(re, e.Args[0]))
@@ -635,6 +964,8 @@ func formatExprSyntax(re *Regexp, e Expr) string {
 		return fmt.Sprintf("(possessive %s)", formatExprSyntax(re, e.Args[0]))
 	case OpComment:
 		return fmt.Sprintf("/*%s*/", e.Value)
+	case OpSubroutineCall:
+		return fmt.Sprintf("(call %s)", e.Args[0].Value)
 	default:
 		return fmt.Sprintf("<op=%d>", e.Op)
 	}
@@ -692,3 +1023,385 @@ func BenchmarkParserStdlib(b *testing.B) {
 		})
 	}
 }
+
+func TestParserLimits(t *testing.T) {
+	deep := func(n int) string {
+		return strings.Repeat("(?:", n) + "a" + strings.Repeat(")", n)
+	}
+
+	tests := []struct {
+		opts    ParserOptions
+		pattern string
+		want    string
+	}{
+		{ParserOptions{}, deep(1000), ""},
+		{ParserOptions{}, deep(1001), "ErrNestingTooDeep 3000-3003 groups nesting is too deep: max depth is 1000"},
+		{ParserOptions{MaxDepth: 2}, `(a)(b)((c))`, ""},
+		{ParserOptions{MaxDepth: 2}, `(a)((?<x>(c)))`, "ErrNestingTooDeep 9-10 groups nesting is too deep: max depth is 2"},
+		{ParserOptions{MaxDepth: 2, Recover: true}, `)((?:(c)))`, "ErrNestingTooDeep 5-6 groups nesting is too deep: max depth is 2"},
+		{ParserOptions{MaxDepth: 100000}, deep(5000), ""},
+		{ParserOptions{MaxDepth: 2}, `a+?|b{2}?|(c*)?`, ""},
+		{ParserOptions{MaxDepth: 2}, `a*??`, "ErrNestingTooDeep 3-4 quantifiers nesting is too deep: max depth is 2"},
+		{ParserOptions{MaxDepth: 2}, `(a+)+`, ""},
+		{ParserOptions{MaxDepth: 2}, `||a`, ""},
+		{ParserOptions{MaxDepth: 2}, `|||a`, "ErrNestingTooDeep 2-3 alternations nesting is too deep: max depth is 2"},
+		{ParserOptions{MaxDepth: 100}, "a" + strings.Repeat("*", 60000), "ErrNestingTooDeep 101-102 quantifiers nesting is too deep: max depth is 100"},
+		{ParserOptions{MaxDepth: 100}, strings.Repeat("|", 60000) + "a", "ErrNestingTooDeep 100-101 alternations nesting is too deep: max depth is 100"},
+		{ParserOptions{MaxPatternSize: 3}, `abc`, ""},
+		{ParserOptions{MaxPatternSize: 3}, `abcd`, "ErrPatternTooLong 0-0 pattern is too long: 4 bytes, max is 3"},
+	}
+
+	for _, test := range tests {
+		opts := test.opts
+		p := NewParser(&opts)
+		_, err := p.Parse(test.pattern)
+		have := ""
+		if err != nil {
+			perr := err.(ParseError)
+			have = fmt.Sprintf("Err%s %d-%d %s", perr.Code, perr.Pos.Begin, perr.Pos.End, perr.Message)
+		}
+		if have != test.want {
+			t.Errorf("parse(%.20q) with %+v:\nhave: %s\nwant: %s", test.pattern, test.opts, have, test.want)
+		}
+	}
+}
+
+func TestParserArena(t *testing.T) {
+	pattern := strings.Repeat(`(a|b+)[cd]`, 50)
+
+	tests := []struct {
+		opts      ParserOptions
+		maxAllocs float64
+	}{
+		{ParserOptions{ArenaSize: 8, GrowArena: true}, 0},
+		{ParserOptions{ArenaSize: 1024}, 0},
+		{ParserOptions{}, 300},
+		{ParserOptions{ArenaSize: -1}, 1000},
+	}
+
+	want, err := NewParser(nil).Parse(pattern)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, test := range tests {
+		opts := test.opts
+		p := NewParser(&opts)
+		re, err := p.Parse(pattern)
+		if err != nil {
+			t.Fatalf("parse with %+v: %v", test.opts, err)
+		}
+		if !EqualExpr(re.Expr, want.Expr) {
+			t.Errorf("parse with %+v: results differ", test.opts)
+		}
+		allocs := testing.AllocsPerRun(10, func() {
+			if _, err := p.Parse(pattern); err != nil {
+				t.Fatal(err)
+			}
+		})
+		if allocs > test.maxAllocs {
+			t.Errorf("parse with %+v: %v allocs, want at most %v", test.opts, allocs, test.maxAllocs)
+		}
+	}
+}
+
+func TestParseBytes(t *testing.T) {
+	pattern := []byte(strings.Repeat(`(a|b+)[cd]\d`, 20))
+
+	want, err := NewParser(nil).Parse(string(pattern))
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := NewParser(&ParserOptions{ArenaSize: 1024})
+	re, err := p.ParseBytes(pattern)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if re.Pattern != string(pattern) || !EqualExpr(re.Expr, want.Expr) {
+		t.Errorf("parse bytes: results differ")
+	}
+	allocs := testing.AllocsPerRun(10, func() {
+		if _, err := p.ParseBytes(pattern); err != nil {
+			t.Fatal(err)
+		}
+	})
+	if allocs != 0 {
+		t.Errorf("parse bytes: %v allocs, want 0", allocs)
+	}
+
+	_, err = p.ParseBytes([]byte(`a(b`))
+	if perr, ok := err.(ParseError); !ok || perr.Code != ErrUnterminatedGroup {
+		t.Errorf("parse bytes: unexpected error %v", err)
+	}
+}
+
+func TestParserAttachComments(t *testing.T) {
+	tests := []struct {
+		opts    ParserOptions
+		pattern string
+		syntax  string
+		want    []string
+	}{
+		{ParserOptions{}, `(?#x)a`, `a`, []string{`(?#x) => a`}},
+		{ParserOptions{}, `ab(?#x)(?#y)c+`, `{ab (+ c)}`, []string{`(?#x) (?#y) => c+`}},
+		{ParserOptions{}, `(?#x)a|b(?#y)`, `(or a {b /*(?#y)*/})`, []string{`(?#x) => a`}},
+		{ParserOptions{}, `((?#x)a)`, `(capture a)`, []string{`(?#x) => a`}},
+		{ParserOptions{FreeSpacing: true}, "# digits\n\\d+ # tail", `{(+ \d) /* # tail*/}`, []string{
+			"# digits\n => \\d+",
+		}},
+	}
+
+	for _, test := range tests {
+		opts := test.opts
+		opts.AttachComments = true
+		p := NewParser(&opts)
+		re, err := p.Parse(test.pattern)
+		if err != nil {
+			t.Fatalf("parse(%q): %v", test.pattern, err)
+		}
+		if have := formatSyntax(re); have != test.syntax {
+			t.Errorf("parse(%q) syntax:\nhave: %s\nwant: %s", test.pattern, have, test.syntax)
+		}
+		var have []string
+		WalkExpr(&re.Expr, func(e *Expr) bool {
+			if len(re.Comments[e.Pos]) == 0 {
+				return true
+			}
+			var comments []string
+			for _, c := range re.Comments[e.Pos] {
+				comments = append(comments, c.Value)
+			}
+			have = append(have, strings.Join(comments, " ")+" => "+e.Value)
+			return true
+		})
+		if !reflect.DeepEqual(have, test.want) {
+			t.Errorf("parse(%q) comments:\nhave: %q\nwant: %q", test.pattern, have, test.want)
+		}
+		if printed := Print(re); printed != test.pattern {
+			t.Errorf("print(%q): have %q", test.pattern, printed)
+		}
+	}
+
+	// Comments are moved along with the expressions they're attached to.
+	re, err := NewParser(&ParserOptions{AttachComments: true}).Parse(`(?#first)a|(?#second)b`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	re.Expr.Args[0], re.Expr.Args[1] = re.Expr.Args[1], re.Expr.Args[0]
+	if have, want := Print(re), `(?#second)b|(?#first)a`; have != want {
+		t.Errorf("print swapped:\nhave: %q\nwant: %q", have, want)
+	}
+	if have, want := Print(re.Clone()), `(?#second)b|(?#first)a`; have != want {
+		t.Errorf("print clone:\nhave: %q\nwant: %q", have, want)
+	}
+}
+
+func TestParserQuantifierComments(t *testing.T) {
+	tests := []struct {
+		pattern string
+		syntax  string
+		want    []string
+	}{
+		{"a *", `(* a)`, []string{`" " => "a *"`}},
+		{"a #c\n* b", `{(* a) /* */ b}`, []string{`" #c\n" => "a #c\n*"`}},
+		{"a + ? [b] {2} #c", `{(non-greedy (+ a)) /* */ (repeat [b] {2}) /* #c*/}`, []string{
+			`" " => "a + ?"`,
+			`" " => "a +"`,
+			`" " => "[b] {2}"`,
+		}},
+		{"(?-x:a *)", `(group {a (*  )} ?-x)`, nil},
+	}
+
+	p := NewParser(&ParserOptions{FreeSpacing: true})
+	for _, test := range tests {
+		re, err := p.Parse(test.pattern)
+		if err != nil {
+			t.Fatalf("parse(%q): %v", test.pattern, err)
+		}
+		if have := formatSyntax(re); have != test.syntax {
+			t.Errorf("parse(%q) syntax:\nhave: %s\nwant: %s", test.pattern, have, test.syntax)
+		}
+		var have []string
+		WalkExpr(&re.Expr, func(e *Expr) bool {
+			for _, c := range re.QuantifierComments[e.Pos] {
+				have = append(have, fmt.Sprintf("%q => %q", c.Value, e.Value))
+			}
+			return true
+		})
+		if !reflect.DeepEqual(have, test.want) {
+			t.Errorf("parse(%q) comments:\nhave: %q\nwant: %q", test.pattern, have, test.want)
+		}
+	}
+}
+
+func TestParseFlags(t *testing.T) {
+	tests := []struct {
+		pattern string
+		flags   string
+		want    string
+	}{
+		{`a b`, ``, `a b`},
+		{`a b`, `i`, `a b`},
+		{`a b`, `x`, `{a /* */ b}`},
+		{`a b`, `ix`, `{a /* */ b}`},
+		{`a b`, `-x`, `a b`},
+		{`a(?-x) b`, `x`, `{a (flags ?-x)  b}`},
+	}
+
+	p := NewParser(nil)
+	for _, test := range tests {
+		re, err := p.ParseFlags(test.pattern, test.flags)
+		if err != nil {
+			t.Fatalf("parse(%q, %q): %v", test.pattern, test.flags, err)
+		}
+		if have := formatSyntax(re); have != test.want {
+			t.Errorf("parse(%q, %q):\nhave: %s\nwant: %s", test.pattern, test.flags, have, test.want)
+		}
+		if re.Flags != test.flags {
+			t.Errorf("parse(%q, %q): flags mismatch: have %q", test.pattern, test.flags, re.Flags)
+		}
+	}
+
+	// Flags don't leak into the subsequent Parse calls.
+	re, err := p.Parse(`a b`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if have := formatSyntax(re); have != `a b` || re.Flags != "" {
+		t.Errorf("parse after ParseFlags: have %s with %q flags", have, re.Flags)
+	}
+}
+
+func TestParserUnicodeProperty(t *testing.T) {
+	tests := []struct {
+		pattern string
+		name    string
+		value   string
+		class   string
+	}{
+		{`\p{Script=Greek}`, `Script`, `Greek`, `\p{Greek}`},
+		{`\p{sc=Greek}`, `sc`, `Greek`, `\p{Greek}`},
+		{`\P{gc=Lu}`, `gc`, `Lu`, `\P{Lu}`},
+		{`\p{General_Category=Uppercase_Letter}`, `General_Category`, `Uppercase_Letter`, `\p{Lu}`},
+		{`\p{^sc=Greek}`, `^sc`, `Greek`, `\P{Greek}`},
+	}
+
+	p := NewParser(nil)
+	for _, test := range tests {
+		re, err := p.Parse(test.pattern)
+		if err != nil {
+			t.Fatalf("parse(%q): %v", test.pattern, err)
+		}
+		e := re.Expr
+		if e.Op != OpEscapeUni || e.Form != FormEscapeUniProperty {
+			t.Fatalf("parse(%q): unexpected %s expr", test.pattern, e.Op)
+		}
+		if e.Args[0].Value != test.name || e.Args[1].Value != test.value {
+			t.Errorf("parse(%q): have %q=%q, want %q=%q",
+				test.pattern, e.Args[0].Value, e.Args[1].Value, test.name, test.value)
+		}
+
+		have, err := ClassRanges(&e)
+		if err != nil {
+			t.Fatalf("ranges(%q): %v", test.pattern, err)
+		}
+		classRe, err := NewParser(nil).Parse(test.class)
+		if err != nil {
+			t.Fatalf("parse(%q): %v", test.class, err)
+		}
+		want, err := ClassRanges(&classRe.Expr)
+		if err != nil {
+			t.Fatalf("ranges(%q): %v", test.class, err)
+		}
+		if !reflect.DeepEqual(have, want) {
+			t.Errorf("ranges(%q): doesn't match %s ranges", test.pattern, test.class)
+		}
+	}
+}
+
+func TestParserUnicodeNegated(t *testing.T) {
+	tests := []struct {
+		pattern string
+		negated bool
+	}{
+		{`\pL`, false},
+		{`\PL`, true},
+		{`\p{L}`, false},
+		{`\P{L}`, true},
+		{`\p{^L}`, true},
+		{`\P{^L}`, false},
+		{`\p{sc=Greek}`, false},
+		{`\P{sc=Greek}`, true},
+		{`\p{^sc=Greek}`, true},
+	}
+
+	p := NewParser(nil)
+	for _, test := range tests {
+		re, err := p.Parse(test.pattern)
+		if err != nil {
+			t.Fatalf("parse(%q): %v", test.pattern, err)
+		}
+		if re.Expr.Negated != test.negated {
+			t.Errorf("parse(%q): have negated=%v, want %v", test.pattern, re.Expr.Negated, test.negated)
+		}
+	}
+}
+
+func TestParserDupNames(t *testing.T) {
+	const pattern = `(?P<x>a)|(?P<y>b)|(?P<x>c)`
+
+	_, err := NewParser(nil).Parse(pattern)
+	perr, ok := err.(ParseError)
+	if !ok || perr.Code != ErrDuplicateGroupName || perr.Text != "x" || perr.Pos.Begin != 22 {
+		t.Errorf("parse(%q): unexpected error %#v", pattern, err)
+	}
+	if _, err := NewParser(&ParserOptions{DupNames: true}).Parse(pattern); err != nil {
+		t.Errorf("parse(%q) with DupNames: %v", pattern, err)
+	}
+	if _, err := NewParser(&ParserOptions{Dialect: DialectDotNet}).Parse(`(?<x>a)|(?<x>c)`); err != nil {
+		t.Errorf("parse in .NET: %v", err)
+	}
+
+	p := NewParser(nil)
+	if _, err := p.ParsePCRE(`/(?P<x>a)|(?P<x>c)/J`); err != nil {
+		t.Errorf("parsePCRE with J modifier: %v", err)
+	}
+	if _, err := p.ParsePCRE(`/(?P<x>a)|(?P<x>c)/`); err == nil {
+		t.Errorf("parsePCRE without J modifier: expected an error")
+	}
+}
+
+func TestParserStrictRepeat(t *testing.T) {
+	tests := []struct {
+		pattern string
+		want    string
+	}{
+		{`a{2}b{2,}c{2,5}`, ``},
+		{`\{a}\x{41}\p{Greek}`, ``},
+		{`{"key": 1}`, ``},
+		{`a{b`, ``},
+		{`[{a}]`, ``},
+		{`.{a}`, `1-4 malformed repeat {a}: expected {n}, {n,} or {n,m}`},
+		{`a{,5}`, `1-5 malformed repeat {,5}: expected {n}, {n,} or {n,m}`},
+		{`x(a{1, 2})`, `3-9 malformed repeat {1, 2}: expected {n}, {n,} or {n,m}`},
+		{`a{}`, `1-3 malformed repeat {}: expected {n}, {n,} or {n,m}`},
+	}
+
+	p := NewParser(&ParserOptions{StrictRepeat: true})
+	for _, test := range tests {
+		_, err := p.Parse(test.pattern)
+		have := ""
+		if err != nil {
+			perr := err.(ParseError)
+			if perr.Code != ErrMalformedRepeat {
+				t.Errorf("parse(%q): unexpected error code %s", test.pattern, perr.Code)
+			}
+			have = fmt.Sprintf("%d-%d %s", perr.Pos.Begin, perr.Pos.End, perr.Message)
+		}
+		if have != test.want {
+			t.Errorf("parse(%q):\nhave: %s\nwant: %s", test.pattern, have, test.want)
+		}
+		if _, err := NewParser(nil).Parse(test.pattern); err != nil {
+			t.Errorf("parse(%q) without StrictRepeat: %v", test.pattern, err)
+		}
+	}
+}
@@ -2,6 +2,7 @@ package syntax
 
 import (
 	"fmt"
+	"reflect"
 	"testing"
 )
 
@@ -15,6 +16,12 @@ func TestParserErrorsPCRE(t *testing.T) {
 		{` aa `, `whitespace is not a valid delimiter`},
 		{`/abc`, `can't find '/' ending delimiter`},
 		{`#abc`, `can't find '#' ending delimiter`},
+		{`/abc\/`, `can't find '/' ending delimiter`},
+		{`{a{b}`, `can't find '}' ending delimiter`},
+		{`/clipFrom/([0-9]+)`, `unknown modifier '('`},
+		{`/a/b/`, `unknown modifier 'b'`},
+		{`/a/iI`, `unknown modifier 'I'`},
+		{"/a/i\t", `unknown modifier '\t'`},
 	}
 
 	p := NewParser(nil)
@@ -44,7 +51,13 @@ func TestParsePCRE(t *testing.T) {
 		{`#hello#`, "hello", "##", ""},
 		{`{pcre pattern}smi`, "pcre pattern", "{}", "smi"},
 		{`<an[o]ther (example)!>ms`, "an[o]ther (example)!", "<>", "ms"},
-		{`/clipFrom/([0-9]+)`, "clipFrom", "//", "([0-9]+)"},
+		{`/a\/b/i`, `a\/b`, "//", "i"},
+		{`/a\\/i`, `a\\`, "//", "i"},
+		{`#a\#b#`, `a\#b`, "##", ""},
+		{`{a{2}c}m`, "a{2}c", "{}", "m"},
+		{`{a\}b}`, `a\}b`, "{}", ""},
+		{`(a(b)(c))`, "a(b)(c)", "()", ""},
+		{`[[a-z]\]]x`, `[a-z]\]`, "[]", "x"},
 	}
 
 	p := NewParser(nil)
@@ -68,3 +81,106 @@ func TestParsePCRE(t *testing.T) {
 		}
 	}
 }
+
+func TestParsePCREFreeSpacing(t *testing.T) {
+	tests := []struct {
+		source string
+		want   string
+	}{
+		{`/a b/`, `a b`},
+		{`/a b/x`, `{a /* */ b}`},
+		{`/a b # c/xi`, `{a /* */ b /* # c*/}`},
+		{`~a(?-x) b~x`, `{a (flags ?-x)  b}`},
+	}
+
+	p := NewParser(nil)
+	for _, test := range tests {
+		pcre, err := p.ParsePCRE(test.source)
+		if err != nil {
+			t.Fatalf("parse(%q): %v", test.source, err)
+		}
+		have := formatSyntax(&Regexp{Pattern: pcre.Pattern, Expr: pcre.Expr})
+		if have != test.want {
+			t.Errorf("parse(%q):\nhave: %s\nwant: %s", test.source, have, test.want)
+		}
+	}
+
+	// The x modifier doesn't affect the subsequent Parse calls.
+	re, err := p.Parse(`a b`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if have := formatSyntax(re); have != `a b` {
+		t.Errorf("parse after ParsePCRE: have %s, want %s", have, `a b`)
+	}
+}
+
+func TestParsePCREFlags(t *testing.T) {
+	tests := []struct {
+		source string
+		want   string
+	}{
+		{`/abc/`, ``},
+		{`/abc/i`, `i`},
+		{`/a b/xsm`, `xsm`},
+		{`/a/ADuiX`, `i`},
+		{`/(?<x>a)(?<x>b)/nUJ`, `nUJ`},
+		{"/a/i x\n", `ix`},
+	}
+
+	p := NewParser(nil)
+	for _, test := range tests {
+		pcre, err := p.ParsePCRE(test.source)
+		if err != nil {
+			t.Fatalf("parse(%q): %v", test.source, err)
+		}
+		if pcre.Flags != test.want {
+			t.Errorf("parse(%q): flags mismatch:\nhave: %q\nwant: %q",
+				test.source, pcre.Flags, test.want)
+		}
+	}
+}
+
+func TestParsePCREModifiers(t *testing.T) {
+	tests := []struct {
+		source string
+		want   RegexpPCRE
+	}{
+		{`/a/`, RegexpPCRE{}},
+		{`/a/i`, RegexpPCRE{CaseInsensitive: true}},
+		{`/a/msxADUXJun`, RegexpPCRE{
+			Multiline:     true,
+			DotAll:        true,
+			Extended:      true,
+			Anchored:      true,
+			DollarEndOnly: true,
+			Ungreedy:      true,
+			Extra:         true,
+			DupNames:      true,
+			UTF:           true,
+			NoAutoCapture: true,
+		}},
+		{`/a/Si`, RegexpPCRE{CaseInsensitive: true}},
+		{"/a/i s\n", RegexpPCRE{CaseInsensitive: true, DotAll: true}},
+		{"/a/\r\n", RegexpPCRE{}},
+	}
+
+	p := NewParser(nil)
+	for _, test := range tests {
+		pcre, err := p.ParsePCRE(test.source)
+		if err != nil {
+			t.Fatalf("parse(%q): %v", test.source, err)
+		}
+		// Only compare the modifier fields.
+		have := *pcre
+		have.Pattern = ""
+		have.Expr = Expr{}
+		have.Source = ""
+		have.Modifiers = ""
+		have.Delim = [2]byte{}
+		have.Flags = ""
+		if !reflect.DeepEqual(have, test.want) {
+			t.Errorf("parse(%q):\nhave: %+v\nwant: %+v", test.source, have, test.want)
+		}
+	}
+}
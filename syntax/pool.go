@@ -0,0 +1,55 @@
+package syntax
+
+import (
+	"sync"
+)
+
+// ParserPool is a concurrency-safe way to parse the patterns.
+//
+// Parser reuses its memory between Parse calls, so it can't be shared
+// between goroutines. ParserPool keeps a set of parsers with the same
+// options and returns the results that are not tied to any of them.
+//
+// The results are cloned, so ParserPool is slower than the Parser
+// owned by a single goroutine.
+type ParserPool struct {
+	pool sync.Pool
+}
+
+// NewParserPool returns a pool of the parsers created with opts.
+func NewParserPool(opts *ParserOptions) *ParserPool {
+	var poolOpts *ParserOptions
+	if opts != nil {
+		copied := *opts
+		poolOpts = &copied
+	}
+	return &ParserPool{
+		pool: sync.Pool{
+			New: func() interface{} { return NewParser(poolOpts) },
+		},
+	}
+}
+
+// Parse is like Parser.Parse, but it's safe for concurrent use
+// and the result remains valid after the subsequent Parse calls.
+func (pool *ParserPool) Parse(pattern string) (*Regexp, error) {
+	p := pool.pool.Get().(*Parser)
+	defer pool.pool.Put(p)
+	re, err := p.Parse(pattern)
+	if re != nil {
+		re = re.Clone()
+	}
+	return re, err
+}
+
+// ParsePCRE is like Parser.ParsePCRE, but it's safe for concurrent use
+// and the result remains valid after the subsequent Parse calls.
+func (pool *ParserPool) ParsePCRE(pattern string) (*RegexpPCRE, error) {
+	p := pool.pool.Get().(*Parser)
+	defer pool.pool.Put(p)
+	re, err := p.ParsePCRE(pattern)
+	if re != nil {
+		re = re.Clone()
+	}
+	return re, err
+}
@@ -0,0 +1,68 @@
+package syntax
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+)
+
+func TestParserPool(t *testing.T) {
+	pool := NewParserPool(&ParserOptions{Dialect: DialectRE2})
+
+	patterns := make([]string, 50)
+	for i := range patterns {
+		patterns[i] = fmt.Sprintf(`(?P<g%d>a{%d}|[b-z]+)\d*x`, i, i)
+	}
+
+	results := make([]*Regexp, len(patterns))
+	var wg sync.WaitGroup
+	for i := range patterns {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			re, err := pool.Parse(patterns[i])
+			if err != nil {
+				t.Errorf("parse(%q): %v", patterns[i], err)
+				return
+			}
+			results[i] = re
+		}(i)
+	}
+	wg.Wait()
+
+	p := NewParser(&ParserOptions{Dialect: DialectRE2})
+	for i, re := range results {
+		if re == nil {
+			continue
+		}
+		want, err := p.Parse(patterns[i])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !EqualExpr(re.Expr, want.Expr) || re.Pattern != patterns[i] {
+			t.Errorf("parse(%q): results differ", patterns[i])
+		}
+	}
+
+	if _, err := pool.Parse(`(?<=a)`); err == nil {
+		t.Errorf("expected a dialect error")
+	}
+}
+
+func TestParserPoolPCRE(t *testing.T) {
+	pool := NewParserPool(nil)
+	re1, err := pool.ParsePCRE(`/a+b/i`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	re2, err := pool.ParsePCRE(`#x|y#`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if have := Print(&Regexp{Expr: re1.Expr}); have != `a+b` {
+		t.Errorf("re1: have %q, want %q", have, `a+b`)
+	}
+	if have := Print(&Regexp{Expr: re2.Expr}); have != `x|y` {
+		t.Errorf("re2: have %q, want %q", have, `x|y`)
+	}
+}
@@ -1,10 +1,46 @@
 package syntax
 
+import (
+	"strconv"
+	"strings"
+)
+
+// Position describes a pattern span; End is exclusive.
+// See Offset for the pattern length limitations.
 type Position struct {
-	Begin uint16
-	End   uint16
+	Begin Offset `json:"begin"`
+	End   Offset `json:"end"`
 }
 
 func combinePos(begin, end Position) Position {
 	return Position{Begin: begin.Begin, End: end.End}
 }
+
+// LineCol is a 1-based line and column pair.
+// Like in go/token, Column is a byte count.
+type LineCol struct {
+	Line   int
+	Column int
+}
+
+func (lc LineCol) String() string {
+	return strconv.Itoa(lc.Line) + ":" + strconv.Itoa(lc.Column)
+}
+
+// OffsetLineCol converts the pattern byte offset into a line and column pair.
+// Lines are separated by '\n' chars.
+func OffsetLineCol(pattern string, offset int) LineCol {
+	if offset > len(pattern) {
+		offset = len(pattern)
+	}
+	prefix := pattern[:offset]
+	line := strings.Count(prefix, "\n") + 1
+	column := offset - strings.LastIndexByte(prefix, '\n')
+	return LineCol{Line: line, Column: column}
+}
+
+// LineCols returns pos begin and end locations inside the pattern.
+// The end location points to the char that follows the pos span.
+func (pos Position) LineCols(pattern string) (begin, end LineCol) {
+	return OffsetLineCol(pattern, int(pos.Begin)), OffsetLineCol(pattern, int(pos.End))
+}
@@ -0,0 +1,12 @@
+//go:build !regexlarge
+// +build !regexlarge
+
+package syntax
+
+// Offset is a pattern byte offset type.
+//
+// By default, it's uint16 to keep the AST compact, so patterns are limited
+// to 65535 bytes. Use regexlarge build tag to make it uint32.
+type Offset = uint16
+
+const maxPatternLen = 1<<16 - 1
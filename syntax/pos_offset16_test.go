@@ -0,0 +1,36 @@
+//go:build !regexlarge
+// +build !regexlarge
+
+package syntax
+
+import (
+	"strings"
+	"testing"
+	"unsafe"
+)
+
+func TestPatternTooLong(t *testing.T) {
+	p := NewParser(nil)
+
+	pattern := strings.Repeat("a", maxPatternLen)
+	if _, err := p.Parse(pattern); err != nil {
+		t.Fatalf("unexpected error for %d bytes pattern: %v", len(pattern), err)
+	}
+
+	pattern += "b"
+	_, err := p.Parse(pattern)
+	want := "pattern is too long: 65536 bytes, max is 65535"
+	if err == nil || err.Error() != want {
+		t.Errorf("expected %q error, got %v", want, err)
+	}
+}
+
+func TestExprSize(t *testing.T) {
+	if unsafe.Sizeof(uintptr(0)) != 8 {
+		t.Skip("the size is only checked on 64-bit platforms")
+	}
+	// Op, Form, Negated, a reserved byte and Pos are packed into 8 bytes.
+	if size := unsafe.Sizeof(Expr{}); size != 48 {
+		t.Errorf("Expr size is %d bytes, want 48", size)
+	}
+}
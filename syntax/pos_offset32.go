@@ -0,0 +1,12 @@
+//go:build regexlarge
+// +build regexlarge
+
+package syntax
+
+// Offset is a pattern byte offset type.
+//
+// With regexlarge build tag, it's uint32, so patterns
+// can be up to 4294967295 bytes long.
+type Offset = uint32
+
+const maxPatternLen = 1<<32 - 1
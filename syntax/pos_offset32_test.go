@@ -0,0 +1,35 @@
+//go:build regexlarge
+// +build regexlarge
+
+package syntax
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLongPattern(t *testing.T) {
+	pattern := strings.Repeat("a", 70000) + "(b+)"
+	re, err := NewParser(nil).Parse(pattern)
+	if err != nil {
+		t.Fatal(err)
+	}
+	capture := re.Expr.LastArg()
+	if capture.Op != OpCapture || capture.Begin() != 70000 || capture.End() != 70004 {
+		t.Errorf("unexpected capture: %s %d:%d", capture.Op, capture.Begin(), capture.End())
+	}
+}
+
+func TestLongPatternNesting(t *testing.T) {
+	patterns := []string{
+		"a" + strings.Repeat("*", 8e6),
+		strings.Repeat("|", 8e6) + "a",
+	}
+	p := NewParser(&ParserOptions{MaxDepth: 100})
+	for _, pattern := range patterns {
+		_, err := p.Parse(pattern)
+		if err == nil || err.(ParseError).Code != ErrNestingTooDeep {
+			t.Errorf("parse(%.20q): expected ErrNestingTooDeep, have %v", pattern, err)
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package syntax
+
+import (
+	"testing"
+)
+
+func TestOffsetLineCol(t *testing.T) {
+	const pattern = "ab\nc\n\nd"
+	tests := []struct {
+		offset int
+		want   string
+	}{
+		{0, "1:1"},
+		{1, "1:2"},
+		{2, "1:3"},
+		{3, "2:1"},
+		{4, "2:2"},
+		{5, "3:1"},
+		{6, "4:1"},
+		{7, "4:2"},
+		{100, "4:2"},
+	}
+	for _, test := range tests {
+		have := OffsetLineCol(pattern, test.offset).String()
+		if have != test.want {
+			t.Errorf("offset %d: have %s, want %s", test.offset, have, test.want)
+		}
+	}
+}
+
+func TestPositionLineCols(t *testing.T) {
+	pattern := "(?x)\n  foo  # first line\n  [a-z]+ # second line\n"
+	re, err := NewParser(nil).Parse(pattern)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var have []string
+	Walk(re, func(e *Expr) bool {
+		if e.Op == OpCharClass || e.Op == OpLiteral {
+			begin, end := e.Pos.LineCols(pattern)
+			have = append(have, e.Value+" "+begin.String()+"-"+end.String())
+		}
+		return true
+	})
+	want := []string{
+		"foo 2:3-2:6",
+		"[a-z] 3:3-3:8",
+	}
+	if len(have) != len(want) {
+		t.Fatalf("have %q, want %q", have, want)
+	}
+	for i := range want {
+		if have[i] != want[i] {
+			t.Errorf("have %q, want %q", have[i], want[i])
+		}
+	}
+}
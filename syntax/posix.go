@@ -0,0 +1,132 @@
+package syntax
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// scanBRE is a POSIX basic regular expressions version of scan.
+//
+// In BRE, parens and braces are literal chars unless they're escaped:
+// `\(re\)` is OpCapture and `re\{m,n\}` is OpRepeat.
+// GNU extensions `\|`, `\+` and `\?` are supported as well.
+func (l *lexer) scanBRE() {
+	for l.pos < len(l.input) {
+		ch := l.input[l.pos]
+		if ch >= utf8.RuneSelf {
+			_, size := utf8.DecodeRuneInString(l.input[l.pos:])
+			l.pushTok(tokChar, size)
+			l.maybeInsertConcat()
+			continue
+		}
+		switch ch {
+		case '\\':
+			l.scanBREEscape()
+		case '.':
+			l.pushTok(tokDot, 1)
+		case '*':
+			if l.breIsGroupStart() || l.tokens[len(l.tokens)-1].kind == tokCaret {
+				l.pushTok(tokChar, 1)
+			} else {
+				l.pushTok(tokStar, 1)
+			}
+		case '^':
+			if l.breIsGroupStart() {
+				l.pushTok(tokCaret, 1)
+			} else {
+				l.pushTok(tokChar, 1)
+			}
+		case '$':
+			rest := l.input[l.pos+1:]
+			if rest == "" || strings.HasPrefix(rest, `\)`) || strings.HasPrefix(rest, `\|`) {
+				l.pushTok(tokDollar, 1)
+			} else {
+				l.pushTok(tokChar, 1)
+			}
+		case '[':
+			if l.byteAt(l.pos+1) == '^' {
+				l.pushTok(tokLbracketCaret, 2)
+			} else {
+				l.pushTok(tokLbracket, 1)
+			}
+			l.scanCharClass()
+		default:
+			l.pushTok(tokChar, 1)
+		}
+		l.maybeInsertConcat()
+	}
+}
+
+func (l *lexer) scanBREEscape() {
+	if l.pos+1 >= len(l.input) {
+		throw(newPos(l.pos, l.pos+1), ErrTrailingBackslash, `unexpected end of pattern: trailing '\'`)
+	}
+	ch := l.input[l.pos+1]
+	if ch >= utf8.RuneSelf {
+		_, size := utf8.DecodeRuneInString(l.input[l.pos+1:])
+		l.pushTok(tokEscapeChar, len(`\`)+size)
+		return
+	}
+	switch ch {
+	case '(':
+		l.pushTok(tokLparen, len(`\(`))
+	case ')':
+		l.pushTok(tokRparen, len(`\)`))
+	case '|':
+		l.pushTok(tokPipe, len(`\|`))
+	case '+':
+		l.pushTok(tokPlus, len(`\+`))
+	case '?':
+		l.pushTok(tokQuestion, len(`\?`))
+	case '{':
+		j := l.stringIndex(l.pos+len(`\{`), `\}`)
+		if j < 0 {
+			throw(newPos(l.pos, l.pos+len(`\{`)), ErrUnterminatedRepeat, `can't find closing '\}'`)
+		}
+		l.pushTok(tokRepeat, len(`\{\}`)+j)
+	case '.', '*', '[', ']', '^', '$', '\\':
+		l.pushTok(tokEscapeMeta, 2)
+	default:
+		l.pushTok(tokEscapeChar, 2)
+	}
+}
+
+// breIsGroupStart reports whether the current position is
+// the beginning of the pattern or of a group (or an alternation branch).
+func (l *lexer) breIsGroupStart() bool {
+	if len(l.tokens) == 0 {
+		return true
+	}
+	switch l.tokens[len(l.tokens)-1].kind {
+	case tokLparen, tokPipe:
+		return true
+	default:
+		return false
+	}
+}
+
+// checkEscapeERE rejects escapes that are not a part of POSIX ERE.
+// Only escaped meta chars and C-style control char escapes are permitted.
+func (l *lexer) checkEscapeERE() {
+	ch := l.input[l.pos+1]
+	if ch < utf8.RuneSelf && reMetachar[ch] {
+		return
+	}
+	switch ch {
+	case '{', '}', '/', '"', 'n', 't', 'r', 'f', 'v', 'a':
+		return
+	}
+	_, size := utf8.DecodeRuneInString(l.input[l.pos+1:])
+	throw(newPos(l.pos, l.pos+1+size), ErrUnsupported, "'"+l.input[l.pos:l.pos+1+size]+"' escape is not supported in POSIX ERE")
+}
+
+// checkQuantifierERE rejects non-greedy and possessive quantifiers.
+func (l *lexer) checkQuantifierERE(kind tokenKind) {
+	if (kind != tokQuestion && kind != tokPlus) || len(l.tokens) == 0 {
+		return
+	}
+	switch l.tokens[len(l.tokens)-1].kind {
+	case tokStar, tokPlus, tokQuestion, tokRepeat:
+		throw(newPos(l.pos, l.pos+1), ErrUnsupported, "'"+kind.String()+"' after a quantifier is not supported in POSIX ERE")
+	}
+}
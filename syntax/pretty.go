@@ -0,0 +1,281 @@
+package syntax
+
+import (
+	"strconv"
+	"strings"
+)
+
+// PrettyPrint renders re as an indented free-spacing `(?x)` pattern.
+//
+// Every alternation branch is printed on its own line and groups
+// that contain alternations are split into several lines,
+// with their contents indented. Capturing groups and lookarounds
+// are annotated with `#` comments.
+//
+// The result is intended for documentation purposes: it matches the
+// same strings as the original pattern, but only regexp engines that
+// support the x flag (like PCRE) can use it directly.
+func PrettyPrint(re *Regexp) string {
+	pp := prettyPrinter{comments: re.Comments, quantifierComments: re.QuantifierComments}
+	pp.out.WriteString("(?x)\n")
+	if bodyDisablesFreeSpacing(&re.Expr) {
+		comments := pp.comments[re.Expr.Pos]
+		for i := range comments {
+			pp.line.WriteString(pp.inline(&comments[i]))
+		}
+		// The whole body is printed as is, including the quantifier comments.
+		p := printer{freeSpacing: true, comments: pp.comments, quantifierComments: pp.quantifierComments}
+		p.printNode(&re.Expr)
+		pp.line.WriteString(p.b.String())
+		pp.flush()
+	} else {
+		pp.block(&re.Expr, "")
+	}
+	return pp.out.String()
+}
+
+type prettyPrinter struct {
+	out strings.Builder
+
+	// line is a pending output line, it's written by flush.
+	line    strings.Builder
+	comment string
+
+	// lineIndent is used for the pending line;
+	// restIndent is used for the lines that follow it.
+	lineIndent string
+	restIndent string
+
+	numCaptures int
+
+	comments map[Position][]Expr
+
+	// quantifierComments are printed as separate comment lines
+	// before the quantified item, see seqItem.
+	quantifierComments map[Position][]Expr
+}
+
+func (pp *prettyPrinter) flush() {
+	if pp.line.Len() == 0 && pp.comment == "" {
+		return
+	}
+	pp.out.WriteString(pp.lineIndent)
+	pp.out.WriteString(pp.line.String())
+	if pp.comment != "" {
+		if pp.line.Len() != 0 {
+			pp.out.WriteString("  ")
+		}
+		pp.out.WriteString("# " + pp.comment)
+	}
+	pp.out.WriteByte('\n')
+	pp.line.Reset()
+	pp.comment = ""
+	pp.lineIndent = pp.restIndent
+}
+
+func (pp *prettyPrinter) block(e *Expr, indent string) {
+	if e.Op != OpAlt {
+		pp.seq(e, indent, indent)
+		return
+	}
+	for i := range e.Args {
+		first := indent + "  "
+		if i != 0 {
+			first = indent + "| "
+		}
+		size := pp.out.Len()
+		pp.seq(&e.Args[i], first, indent+"  ")
+		if pp.out.Len() == size {
+			// Empty branch still needs a line to keep the '|'.
+			pp.out.WriteString(strings.TrimRight(first, " ") + "\n")
+		}
+	}
+}
+
+func (pp *prettyPrinter) seq(e *Expr, first, rest string) {
+	pp.lineIndent = first
+	pp.restIndent = rest
+
+	items := []Expr{*e}
+	if e.Op == OpConcat {
+		items = e.Args
+	}
+	for i := range items {
+		item := &items[i]
+		comments := pp.comments[item.Pos]
+		for j := range comments {
+			pp.seqItem(&comments[j], rest)
+		}
+		pp.seqItem(item, rest)
+	}
+	pp.flush()
+}
+
+func (pp *prettyPrinter) seqItem(item *Expr, rest string) {
+	for e := item; e.IsQuantifier(); e = &e.Args[0] {
+		comments := pp.quantifierComments[e.Pos]
+		for i := range comments {
+			pp.seqItem(&comments[i], rest)
+		}
+	}
+	switch {
+	case item.Op == OpComment && item.Form == FormCommentFreeSpacing:
+		text := strings.TrimSpace(item.Value)
+		if text == "" {
+			return
+		}
+		if pp.comment != "" {
+			pp.flush()
+		}
+		pp.comment = strings.TrimSpace(strings.TrimPrefix(text, "#"))
+		pp.flush()
+	case pp.isMultiline(item):
+		pp.flush()
+		pp.group(item, rest)
+	default:
+		pp.line.WriteString(pp.inline(item))
+	}
+}
+
+// group prints a multiline group e, possibly wrapped into quantifiers.
+func (pp *prettyPrinter) group(e *Expr, indent string) {
+	suffix := ""
+	for e.IsQuantifier() {
+		switch e.Op {
+		case OpStar:
+			suffix = "*" + suffix
+		case OpPlus, OpPossessive:
+			suffix = "+" + suffix
+		case OpQuestion, OpNonGreedy:
+			suffix = "?" + suffix
+		case OpRepeat:
+			suffix = e.Args[1].Value + suffix
+		}
+		e = &e.Args[0]
+	}
+
+	switch e.Op {
+	case OpCapture:
+		pp.numCaptures++
+		pp.comment = "group " + strconv.Itoa(pp.numCaptures)
+	case OpNamedCapture:
+		pp.numCaptures++
+		pp.comment = "group " + strconv.Itoa(pp.numCaptures) + ": " + e.Args[1].Value
+	default:
+		pp.comment = groupComments[e.Op]
+	}
+	pp.line.WriteString(groupOpenText(e))
+	pp.flush()
+
+	pp.block(&e.Args[0], indent+"  ")
+
+	pp.lineIndent = indent
+	pp.restIndent = indent
+	pp.line.WriteString(")" + suffix)
+	pp.flush()
+}
+
+func (pp *prettyPrinter) inline(e *Expr) string {
+	WalkExpr(e, func(e *Expr) bool {
+		if e.Op == OpCapture || e.Op == OpNamedCapture {
+			pp.numCaptures++
+		}
+		return true
+	})
+	// The e attached comments are printed by the caller.
+	p := printer{freeSpacing: true, comments: pp.comments}
+	p.printNode(e)
+	return p.b.String()
+}
+
+// isMultiline reports whether e is a group (possibly quantified)
+// that should be printed using several lines.
+func (pp *prettyPrinter) isMultiline(e *Expr) bool {
+	for e.IsQuantifier() {
+		e = &e.Args[0]
+	}
+	switch e.Op {
+	case OpCapture, OpNamedCapture, OpGroup, OpAtomicGroup, OpAbsentGroup,
+		OpPositiveLookahead, OpNegativeLookahead, OpPositiveLookbehind, OpNegativeLookbehind:
+	case OpGroupWithFlags:
+		if disablesFreeSpacing(e.Args[1].Value, e.Form == FormFlagsReset) {
+			return false
+		}
+	default:
+		return false
+	}
+	if bodyDisablesFreeSpacing(&e.Args[0]) {
+		return false
+	}
+	multiline := false
+	WalkExpr(&e.Args[0], func(e *Expr) bool {
+		if e.Op == OpAlt || (e.Op == OpComment && strings.Contains(e.Value, "#")) {
+			multiline = true
+		}
+		// The quantifier comments are only printed by seqItem.
+		for _, c := range pp.quantifierComments[e.Pos] {
+			if strings.Contains(c.Value, "#") {
+				multiline = true
+			}
+		}
+		return !multiline
+	})
+	return multiline
+}
+
+var groupComments = map[Operation]string{
+	OpAtomicGroup:        "atomic group",
+	OpAbsentGroup:        "absent operator",
+	OpPositiveLookahead:  "lookahead",
+	OpNegativeLookahead:  "negative lookahead",
+	OpPositiveLookbehind: "lookbehind",
+	OpNegativeLookbehind: "negative lookbehind",
+}
+
+func groupOpenText(e *Expr) string {
+	switch e.Op {
+	case OpNamedCapture:
+		switch e.Form {
+		case FormNamedCaptureAngle:
+			return "(?<" + e.Args[1].Value + ">"
+		case FormNamedCaptureQuote:
+			return "(?'" + e.Args[1].Value + "'"
+		default:
+			return "(?P<" + e.Args[1].Value + ">"
+		}
+	case OpGroupWithFlags:
+		if e.Form == FormFlagsReset {
+			return "(?^" + e.Args[1].Value + ":"
+		}
+		return "(?" + e.Args[1].Value + ":"
+	default:
+		return groupPrefix[e.Op]
+	}
+}
+
+// bodyDisablesFreeSpacing reports whether a group body e contains
+// a `(?-x)` flag group that turns the x flag off for the rest of the group.
+func bodyDisablesFreeSpacing(e *Expr) bool {
+	branches := []Expr{*e}
+	if e.Op == OpAlt {
+		branches = e.Args
+	}
+	for _, branch := range branches {
+		items := []Expr{branch}
+		if branch.Op == OpConcat {
+			items = branch.Args
+		}
+		for _, item := range items {
+			if item.Op == OpFlagOnlyGroup && disablesFreeSpacing(item.Args[0].Value, item.Form == FormFlagsReset) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// disablesFreeSpacing reports whether flags turn the x flag off.
+func disablesFreeSpacing(flags string, reset bool) bool {
+	// `(?^)` resets all flags to their defaults (unset).
+	return !flagEnabled(flags, 'x', !reset)
+}
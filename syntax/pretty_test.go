@@ -0,0 +1,126 @@
+package syntax
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPrettyPrint(t *testing.T) {
+	tests := []struct {
+		pattern string
+		want    []string
+	}{
+		{`abc`, []string{
+			`abc`,
+		}},
+
+		{`a|b c|`, []string{
+			`  a`,
+			`| b\ c`,
+			`|`,
+		}},
+
+		{`^(?P<year>\d{4})-(\d\d|x)+$`, []string{
+			`^(?P<year>\d{4})-`,
+			`(  # group 2`,
+			`    \d\d`,
+			`  | x`,
+			`)+`,
+			`$`,
+		}},
+
+		{`x(?:a|(?=b|c))*?y`, []string{
+			`x`,
+			`(?:`,
+			`    a`,
+			`  | (?=  # lookahead`,
+			`        b`,
+			`      | c`,
+			`    )`,
+			`)*?`,
+			`y`,
+		}},
+
+		{`a#[# ](?i:x|y)`, []string{
+			`a\#[# ]`,
+			`(?i:`,
+			`    x`,
+			`  | y`,
+			`)`,
+		}},
+
+		{`(?<a>x  # first
+|y)`, []string{
+			`(?<a>  # group 1: a`,
+			`    x  # first`,
+			`  | y`,
+			`)`,
+		}},
+
+		{`(?-x)a|(b|c)`, []string{
+			`(?-x)a|(b|c)`,
+		}},
+
+		{`a # one
++ b(?:c # two
+*)`, []string{
+			`# one`,
+			`a+b`,
+			`(?:`,
+			`  # two`,
+			`  c*`,
+			`)`,
+		}},
+	}
+
+	p := NewParser(&ParserOptions{})
+	px := NewParser(&ParserOptions{FreeSpacing: true})
+	for _, test := range tests {
+		parser := p
+		if strings.Contains(test.pattern, "\n") {
+			parser = px
+		}
+		re, err := parser.Parse(test.pattern)
+		if err != nil {
+			t.Fatalf("parse(%q): %v", test.pattern, err)
+		}
+		have := PrettyPrint(re)
+		want := "(?x)\n" + strings.Join(test.want, "\n") + "\n"
+		if have != want {
+			t.Errorf("pretty print(%q):\nhave:\n%s\nwant:\n%s", test.pattern, have, want)
+			continue
+		}
+		if _, err := p.Parse(have); err != nil {
+			t.Errorf("parse pretty printed %q: %v", test.pattern, err)
+		}
+	}
+}
+
+func TestPrettyPrintAttachComments(t *testing.T) {
+	// Attached comments are printed the same way as the unattached ones.
+	patterns := []string{
+		"# digits\n\\d+ # tail",
+		"(?#x)a|(?#y)(b|c)",
+		"(?#x)a",
+	}
+
+	p := NewParser(&ParserOptions{FreeSpacing: true})
+	pa := NewParser(&ParserOptions{FreeSpacing: true, AttachComments: true})
+	for _, pattern := range patterns {
+		re, err := p.Parse(pattern)
+		if err != nil {
+			t.Fatalf("parse(%q): %v", pattern, err)
+		}
+		want := PrettyPrint(re)
+		re, err = pa.Parse(pattern)
+		if err != nil {
+			t.Fatalf("parse(%q): %v", pattern, err)
+		}
+		if len(re.Comments) == 0 {
+			t.Fatalf("parse(%q): no comments attached", pattern)
+		}
+		if have := PrettyPrint(re); have != want {
+			t.Errorf("pretty print(%q):\nhave:\n%s\nwant:\n%s", pattern, have, want)
+		}
+	}
+}
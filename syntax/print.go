@@ -0,0 +1,286 @@
+package syntax
+
+import (
+	"strings"
+)
+
+// Print reconstructs a pattern from the re AST.
+//
+// Unlike re.Pattern or Expr.Value, the result reflects the current
+// tree structure, so it can be used to print modified or programmatically
+// built ASTs. Only leaf values (like OpChar or OpString) are used as is;
+// parent expressions are printed from their Op, Form and Args.
+//
+// Groups are always printed using the default (Perl-like) syntax.
+// If an arg can't be printed as is without changing the meaning,
+// like OpAlt inside OpConcat, it's wrapped into a `(?:re)` group.
+//
+// For an unmodified AST, Print returns the original pattern byte for byte.
+// This includes the free-spacing whitespace and `#` comments (OpComment),
+// even the ones between an expression and its quantifier, like in `a #c\n*`
+// (see Regexp.QuantifierComments), so the AST can be used as a concrete
+// syntax tree by the refactoring tools.
+// The exceptions are DialectPOSIXBasic and DialectVim: their groups and
+// quantifiers, like `\(re\)` and `re\+`, are printed in the default syntax.
+// The trees produced in the recover mode have their missing
+// closing brackets printed as well, so `(a` becomes `(a)`.
+func Print(re *Regexp) string {
+	p := printer{comments: re.Comments, quantifierComments: re.QuantifierComments}
+	p.printExpr(&re.Expr)
+	return p.b.String()
+}
+
+type printer struct {
+	b strings.Builder
+
+	// freeSpacing makes the printer escape whitespace and '#' chars,
+	// so the result can be used in the `(?x)` mode.
+	freeSpacing bool
+
+	// comments are the attached comments, see Regexp.Comments.
+	comments map[Position][]Expr
+
+	// quantifierComments are printed right before the quantifiers,
+	// see Regexp.QuantifierComments.
+	quantifierComments map[Position][]Expr
+}
+
+func (p *printer) printExpr(e *Expr) {
+	comments := p.comments[e.Pos]
+	for i := range comments {
+		p.printExpr(&comments[i])
+	}
+	p.printNode(e)
+}
+
+// printNode is like printExpr, but e attached comments are not printed.
+func (p *printer) printNode(e *Expr) {
+	b := &p.b
+	switch e.Op {
+	case OpQuote:
+		if e.Form == FormQuoteStrayEnd {
+			b.WriteString(`\E`)
+			break
+		}
+		b.WriteString(`\Q`)
+		b.WriteString(e.Args[0].Value)
+		if e.Form != FormQuoteUnclosed {
+			b.WriteString(`\E`)
+		}
+
+	case OpEscapeChar, OpEscapeMeta:
+		if e.Form == FormEscapeNoPrefix {
+			b.WriteString(e.Args[0].Value)
+			break
+		}
+		b.WriteString(`\` + e.Args[0].Value)
+
+	case OpEscapeClass:
+		// The letter case is defined by the negation: `\d` or `\D`.
+		if e.Negated {
+			b.WriteString(`\` + strings.ToUpper(e.Args[0].Value))
+		} else {
+			b.WriteString(`\` + strings.ToLower(e.Args[0].Value))
+		}
+
+	case OpEscapeOctal:
+		if e.Form == FormEscapeOctalFull {
+			b.WriteString(`\o{` + e.Args[0].Value + `}`)
+			break
+		}
+		b.WriteString(`\` + e.Args[0].Value)
+
+	case OpEscapeHex:
+		switch e.Form {
+		case FormEscapeHexFull:
+			b.WriteString(`\x{` + e.Args[0].Value + `}`)
+		case FormEscapeUnicode:
+			b.WriteString(`\u` + e.Args[0].Value)
+		case FormEscapeUnicodeFull:
+			b.WriteString(`\u{` + e.Args[0].Value + `}`)
+		default:
+			b.WriteString(`\x` + e.Args[0].Value)
+		}
+
+	case OpEscapeUni:
+		prefix := `\p`
+		if e.Form == FormEscapeUniFull || e.Form == FormEscapeUniProperty {
+			// `\p{^L}` is already negated by its name.
+			name := unicodeClassName(e)
+			if e.Negated != strings.HasPrefix(name, "^") {
+				prefix = `\P`
+			}
+			b.WriteString(prefix + "{" + name + "}")
+			break
+		}
+		if e.Negated {
+			prefix = `\P`
+		}
+		b.WriteString(prefix + e.Args[0].Value)
+
+	case OpSubroutineCall:
+		if e.Form == FormSubroutineCallQuote {
+			b.WriteString(`\g'` + e.Args[0].Value + `'`)
+		} else {
+			b.WriteString(`\g<` + e.Args[0].Value + `>`)
+		}
+
+	case OpLiteral:
+		for i := range e.Args {
+			p.printExpr(&e.Args[i])
+		}
+
+	case OpCharClass, OpNegCharClass:
+		if e.Op == OpCharClass {
+			b.WriteByte('[')
+		} else {
+			b.WriteString("[^")
+		}
+		// Char classes are not affected by the x flag.
+		freeSpacing := p.freeSpacing
+		p.freeSpacing = false
+		for i := range e.Args {
+			p.printExpr(&e.Args[i])
+		}
+		p.freeSpacing = freeSpacing
+		b.WriteByte(']')
+
+	case OpChar:
+		if p.freeSpacing && len(e.Value) == 1 && (isSpace(e.Value[0]) || e.Value[0] == '#') {
+			b.WriteByte('\\')
+		}
+		b.WriteString(e.Value)
+
+	case OpCharRange:
+		p.printExpr(&e.Args[0])
+		b.WriteByte('-')
+		p.printExpr(&e.Args[1])
+
+	case OpConcat:
+		for i := range e.Args {
+			p.printArg(e, &e.Args[i])
+		}
+
+	case OpAlt:
+		for i := range e.Args {
+			if i != 0 {
+				b.WriteByte('|')
+			}
+			p.printExpr(&e.Args[i])
+		}
+
+	case OpStar, OpPlus, OpQuestion:
+		p.printArg(e, &e.Args[0])
+		p.printQuantifierComments(e)
+		switch e.Op {
+		case OpStar:
+			b.WriteByte('*')
+		case OpPlus:
+			b.WriteByte('+')
+		case OpQuestion:
+			b.WriteByte('?')
+		}
+
+	case OpNonGreedy, OpPossessive:
+		p.printArg(e, &e.Args[0])
+		p.printQuantifierComments(e)
+		if e.Op == OpNonGreedy {
+			b.WriteByte('?')
+		} else {
+			b.WriteByte('+')
+		}
+
+	case OpRepeat:
+		p.printArg(e, &e.Args[0])
+		p.printQuantifierComments(e)
+		b.WriteString(e.Args[1].Value)
+
+	case OpNamedCapture:
+		switch e.Form {
+		case FormNamedCaptureAngle:
+			b.WriteString("(?<" + e.Args[1].Value + ">")
+		case FormNamedCaptureQuote:
+			b.WriteString("(?'" + e.Args[1].Value + "'")
+		default:
+			b.WriteString("(?P<" + e.Args[1].Value + ">")
+		}
+		p.printExpr(&e.Args[0])
+		b.WriteByte(')')
+
+	case OpFlagOnlyGroup:
+		b.WriteString("(?")
+		if e.Form == FormFlagsReset {
+			b.WriteByte('^')
+		}
+		b.WriteString(e.Args[0].Value)
+		b.WriteByte(')')
+
+	case OpGroupWithFlags:
+		b.WriteString("(?")
+		if e.Form == FormFlagsReset {
+			b.WriteByte('^')
+		}
+		b.WriteString(e.Args[1].Value + ":")
+		p.printExpr(&e.Args[0])
+		b.WriteByte(')')
+
+	case OpCapture, OpGroup, OpAtomicGroup, OpPositiveLookahead, OpNegativeLookahead,
+		OpPositiveLookbehind, OpNegativeLookbehind, OpAbsentGroup:
+		b.WriteString(groupPrefix[e.Op])
+		p.printExpr(&e.Args[0])
+		b.WriteByte(')')
+
+	default:
+		// OpString, OpDot, OpCaret, OpDollar, OpPosixClass, OpComment, OpBad.
+		b.WriteString(e.Value)
+	}
+}
+
+// printQuantifierComments prints the comments that precede the quantifier e.
+func (p *printer) printQuantifierComments(e *Expr) {
+	comments := p.quantifierComments[e.Pos]
+	for i := range comments {
+		p.printExpr(&comments[i])
+	}
+}
+
+var groupPrefix = map[Operation]string{
+	OpCapture:            "(",
+	OpGroup:              "(?:",
+	OpAtomicGroup:        "(?>",
+	OpPositiveLookahead:  "(?=",
+	OpNegativeLookahead:  "(?!",
+	OpPositiveLookbehind: "(?<=",
+	OpNegativeLookbehind: "(?<!",
+	OpAbsentGroup:        "(?~",
+}
+
+// printArg prints an arg of parent, wrapping it into `(?:re)` group if needed.
+func (p *printer) printArg(parent, arg *Expr) {
+	if !needsGroup(parent, arg) {
+		p.printExpr(arg)
+		return
+	}
+	p.b.WriteString("(?:")
+	p.printExpr(arg)
+	p.b.WriteByte(')')
+}
+
+// needsGroup reports whether arg should be enclosed into a group to
+// be printed as a part of parent without changing the meaning.
+func needsGroup(parent, arg *Expr) bool {
+	switch parent.Op {
+	case OpConcat:
+		return arg.Op == OpAlt
+	case OpStar, OpPlus, OpQuestion, OpRepeat:
+		switch arg.Op {
+		case OpConcat:
+			return len(arg.Args) != 1 || needsGroup(parent, &arg.Args[0])
+		case OpAlt:
+			return true
+		case OpLiteral:
+			return len(arg.Args) != 1
+		}
+	}
+	return false
+}
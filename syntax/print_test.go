@@ -0,0 +1,202 @@
+package syntax
+
+import (
+	"testing"
+)
+
+func TestPrint(t *testing.T) {
+	patterns := []string{
+		``,
+		`a**`,
+		`x*?|(?i:y)+`,
+		`\Qab\E*\E`,
+		`[^\d\\\]a-z[:alpha:]]`,
+		`\PL\P{L}\p{^Greek}\x41\o{17}\g'1'`,
+		`\p{sc=Greek}\P{^General_Category=Lu}`,
+		`(?P<a>x)(?<b>y)(?'c'z)(?^i)`,
+		`(?=a)(?!b)(?<=c)(?<!d)(?>e)(?#f)`,
+	}
+	p := NewParser(nil)
+	for _, pattern := range patterns {
+		re, err := p.Parse(pattern)
+		if err != nil {
+			t.Fatalf("parse(%q): %v", pattern, err)
+		}
+		if have := Print(re); have != pattern {
+			t.Errorf("print(%q): have %q", pattern, have)
+		}
+	}
+}
+
+func TestPrintRoundTrip(t *testing.T) {
+	tests := []struct {
+		opts     ParserOptions
+		patterns []string
+	}{
+		{
+			opts: ParserOptions{},
+			patterns: []string{
+				"(?x) a b  # comment\n\tc",
+				"(?x: a | b )c d",
+				"(?x)[ # ]\\ \\#x{ 2 }",
+				"(?x)a  +b",
+				"(?x)a #c\n*",
+				"x(?#y)z",
+				`\PL\P{L}[\PL\P{Greek}]`,
+			},
+		},
+		{
+			opts: ParserOptions{FreeSpacing: true},
+			patterns: []string{
+				"a b  # comment\nc",
+				" a | b # trailing",
+				"a # c\n(?#d) b",
+				"(?-x) a b",
+				"a # 1\n # 2\n {2} ? b",
+				"( a | b ) + # c\n ?",
+			},
+		},
+		{
+			opts: ParserOptions{FreeSpacing: true, AttachComments: true},
+			patterns: []string{
+				"a b  # comment\nc",
+				"# leading\n( a | b )",
+				"# leading\na # c\n * b",
+			},
+		},
+		{
+			opts:     ParserOptions{Recover: true},
+			patterns: []string{`x|*`, `a)b)`, `\p{`},
+		},
+		{
+			opts:     ParserOptions{Dialect: DialectPCRE},
+			patterns: []string{`\K(?R)(?1)`, `\h\v\R\X`, `\g{-1}\g1\k{x}`, `a{2}+[[:^alpha:]]`},
+		},
+		{
+			opts:     ParserOptions{Dialect: DialectECMAScript},
+			patterns: []string{`\u0041\u{1F600}\cA`, `(?<x>a)\k<x>`},
+		},
+		{
+			opts:     ParserOptions{Dialect: DialectDotNet},
+			patterns: []string{`(?'x'a)\k'x'`},
+		},
+		{
+			opts:     ParserOptions{Dialect: DialectOnig},
+			patterns: []string{`(?~abc)\o{17}`},
+		},
+		{
+			opts:     ParserOptions{Dialect: DialectVim},
+			patterns: []string{`\v<foo>`, `\v%Va\<`, `^*a`, `\<foo\>\%V`},
+		},
+		{
+			opts:     ParserOptions{Dialect: DialectPOSIXExtended},
+			patterns: []string{`a(b)+{2,3}|c`},
+		},
+	}
+
+	for _, test := range tests {
+		p := NewParser(&test.opts)
+		for _, pattern := range test.patterns {
+			re, err := p.Parse(pattern)
+			if err != nil && !test.opts.Recover {
+				t.Fatalf("parse(%q): %v", pattern, err)
+			}
+			if have := Print(re); have != pattern {
+				t.Errorf("print(%q) %s: have %q", pattern, test.opts.Dialect, have)
+			}
+			if have := Print(re.Clone()); have != pattern {
+				t.Errorf("print(%q) %s clone: have %q", pattern, test.opts.Dialect, have)
+			}
+		}
+	}
+}
+
+func TestPrintModified(t *testing.T) {
+	tests := []struct {
+		pattern string
+		modify  func(e *Expr)
+		want    string
+	}{
+		{
+			pattern: `x*`,
+			modify: func(e *Expr) {
+				if e.Op == OpStar {
+					e.Args[0] = Expr{Op: OpLiteral, Args: []Expr{
+						{Op: OpChar, Value: "a"},
+						{Op: OpChar, Value: "b"},
+					}}
+				}
+			},
+			want: `(?:ab)*`,
+		},
+		{
+			pattern: `x{2}y`,
+			modify: func(e *Expr) {
+				if e.Op == OpChar && e.Value == "x" {
+					*e = Expr{Op: OpAlt, Args: []Expr{
+						{Op: OpChar, Value: "a"},
+						{Op: OpEscapeChar, Args: []Expr{{Op: OpString, Value: "d"}}},
+					}}
+				}
+			},
+			want: `(?:a|\d){2}y`,
+		},
+		{
+			pattern: `ab|c`,
+			modify: func(e *Expr) {
+				if e.Op == OpChar && e.Value == "c" {
+					*e = Expr{Op: OpConcat, Args: []Expr{
+						{Op: OpCaret, Value: "^"},
+						{Op: OpAlt, Args: []Expr{
+							{Op: OpChar, Value: "d"},
+							{Op: OpConcat},
+						}},
+					}}
+				}
+			},
+			want: `ab|^(?:d|)`,
+		},
+		{
+			pattern: `(?P<x>a)[b-c]`,
+			modify: func(e *Expr) {
+				switch e.Op {
+				case OpNamedCapture:
+					e.Args[1].Value = "name"
+				case OpCharRange:
+					e.Args[1].Value = "z"
+				}
+			},
+			want: `(?P<name>a)[b-z]`,
+		},
+		{
+			pattern: `\p{L}\p{^Greek}`,
+			modify: func(e *Expr) {
+				if e.Op == OpEscapeUni {
+					e.Negated = !e.Negated
+				}
+			},
+			want: `\P{L}\P{^Greek}`,
+		},
+		{
+			pattern: `\d[\W]`,
+			modify: func(e *Expr) {
+				if e.Op == OpEscapeClass {
+					e.Negated = !e.Negated
+				}
+			},
+			want: `\D[\w]`,
+		},
+	}
+
+	p := NewParser(nil)
+	for _, test := range tests {
+		re, err := p.Parse(test.pattern)
+		if err != nil {
+			t.Fatalf("parse(%q): %v", test.pattern, err)
+		}
+		WalkPost(re, test.modify)
+		if have := Print(re); have != test.want {
+			t.Errorf("print(%q):\nhave: %s\nwant: %s", test.pattern, have, test.want)
+		}
+	}
+}
@@ -0,0 +1,223 @@
+package syntax
+
+import (
+	"strings"
+)
+
+// RE2Feature identifies a construct that is not supported by RE2
+// and the Go regexp package.
+type RE2Feature byte
+
+//go:generate stringer -type=RE2Feature -trimprefix=RE2
+const (
+	// RE2Lookahead: `(?=re)` and `(?!re)`.
+	RE2Lookahead RE2Feature = iota
+
+	// RE2Lookbehind: `(?<=re)` and `(?<!re)`.
+	RE2Lookbehind
+
+	// RE2Backreference: `\1`, `\k<name>`, `\g{1}` and `(?P=name)`.
+	RE2Backreference
+
+	// RE2AtomicGroup: `(?>re)`.
+	RE2AtomicGroup
+
+	// RE2Possessive: `a*+`, `a++`, `a?+` and `a{n,m}+`.
+	RE2Possessive
+
+	// RE2Recursion: `(?R)`, `(?1)` and `\g<name>` subroutine calls.
+	RE2Recursion
+
+	// RE2Comment: `(?#text)`.
+	RE2Comment
+
+	// RE2Flag: the flags other than `imsU`, like `(?x)`, and `(?^)` resets.
+	RE2Flag
+
+	// RE2NamedGroup: `(?'name're)`.
+	RE2NamedGroup
+
+	// RE2AbsentGroup: `(?~re)`.
+	RE2AbsentGroup
+
+	// RE2Escape: the escapes like `\G`, `\h`, `\Z`, `\cA`, `\x1`, `\o{17}` and `\uFFFF`.
+	RE2Escape
+
+	// RE2UnicodeClass: the classes like `\p{Letter}` or `\p{sc=Greek}`.
+	RE2UnicodeClass
+
+	// RE2RepeatTooLarge: the repeat bounds above 1000, like `a{1001}`.
+	RE2RepeatTooLarge
+)
+
+// RE2Issue describes a pattern part that is not supported by RE2.
+type RE2Issue struct {
+	// Pos is a span of the unsupported pattern part.
+	Pos Position
+
+	// Text is the pattern part that is described by Pos.
+	Text string
+
+	Feature RE2Feature
+
+	Message string
+}
+
+// CheckRE2 reports the re constructs that are not supported
+// by RE2 and the Go regexp package, in the order of their appearance.
+// It's intended for the patterns parsed with the other dialects,
+// so the users can be told why their pattern is rejected by Go.
+//
+// If re is RE2-compatible, nil is returned.
+func CheckRE2(re *Regexp) []RE2Issue {
+	c := re2Checker{pattern: re.Pattern}
+	groups := re.CaptureGroups()
+	if len(groups) != 0 {
+		c.maxIndex = groups[len(groups)-1].Index
+	}
+	c.walk(&re.Expr, false)
+	return c.issues
+}
+
+type re2Checker struct {
+	pattern  string
+	maxIndex int
+	issues   []RE2Issue
+}
+
+func (c *re2Checker) walk(e *Expr, inClass bool) {
+	switch e.Op {
+	case OpCharClass, OpNegCharClass:
+		inClass = true
+
+	case OpPositiveLookahead, OpNegativeLookahead:
+		c.report(e, RE2Lookahead, "lookahead assertions are not supported")
+	case OpPositiveLookbehind, OpNegativeLookbehind:
+		c.report(e, RE2Lookbehind, "lookbehind assertions are not supported")
+	case OpAtomicGroup:
+		c.report(e, RE2AtomicGroup, "atomic groups are not supported")
+	case OpPossessive:
+		c.report(e, RE2Possessive, "possessive quantifiers are not supported")
+	case OpSubroutineCall:
+		c.report(e, RE2Recursion, "subroutine calls are not supported")
+	case OpAbsentGroup:
+		c.report(e, RE2AbsentGroup, "absent operators are not supported")
+
+	case OpComment:
+		if e.Form == FormDefault {
+			c.report(e, RE2Comment, "(?#...) comments are not supported")
+		}
+
+	case OpNamedCapture:
+		if e.Form == FormNamedCaptureQuote {
+			c.report(e, RE2NamedGroup, "(?'name') named groups are not supported")
+		}
+
+	case OpFlagOnlyGroup, OpGroupWithFlags:
+		c.checkFlags(e)
+
+	case OpEscapeChar:
+		c.checkEscapeChar(e)
+
+	case OpEscapeOctal:
+		switch v := e.Args[0].Value; {
+		case e.Form == FormEscapeOctalFull:
+			c.report(e, RE2Escape, `\o{...} escapes are not supported`)
+		case inClass:
+			if len(v) == 1 && v != "0" {
+				c.report(e, RE2Escape, "escape "+c.text(e.Pos)+" is not supported")
+			}
+		default:
+			if _, ok := backrefIndex(e, c.maxIndex); ok {
+				c.report(e, RE2Backreference, "backreferences are not supported")
+			}
+		}
+
+	case OpEscapeHex:
+		switch {
+		case e.Form == FormEscapeUnicode || e.Form == FormEscapeUnicodeFull:
+			c.report(e, RE2Escape, `\uFFFF escapes are not supported`)
+		case e.Form == FormDefault && len(e.Args[0].Value) != 2:
+			c.report(e, RE2Escape, "escape "+c.text(e.Pos)+" is not supported")
+		}
+
+	case OpEscapeUni:
+		name := strings.TrimPrefix(unicodeClassName(e), "^")
+		if !isUnicodeClassName(name, true) {
+			c.report(e, RE2UnicodeClass, "Unicode class "+name+" is not supported")
+		}
+
+	case OpRepeat:
+		if min, max := e.RepeatBounds(); min > re2MaxRepeat || max > re2MaxRepeat {
+			c.report(&e.Args[1], RE2RepeatTooLarge, "repeat "+e.Args[1].Value+" exceeds the limit of 1000")
+		}
+	}
+
+	for i := range e.Args {
+		c.walk(&e.Args[i], inClass)
+	}
+}
+
+func (c *re2Checker) checkEscapeChar(e *Expr) {
+	v := e.Args[0].Value
+	if v == "" || !isLetter(v[0]) || strings.Contains(dialects[DialectRE2].escapeLetters, v[:1]) {
+		return
+	}
+	switch v[0] {
+	case 'k', 'g':
+		// `\k<name>` and `\g{1}` are parsed as escapes followed by the literal chars.
+		c.report(e, RE2Backreference, "backreferences are not supported")
+	default:
+		c.report(e, RE2Escape, "escape "+c.text(e.Pos)+" is not supported")
+	}
+}
+
+func (c *re2Checker) checkFlags(e *Expr) {
+	flags := e.Args[0].Value
+	if e.Op == OpGroupWithFlags {
+		flags = e.Args[1].Value
+	}
+	switch {
+	case e.Form == FormFlagsReset:
+		c.report(e, RE2Flag, "(?^) flag resets are not supported")
+	case strings.HasPrefix(flags, "P="):
+		c.report(e, RE2Backreference, "backreferences are not supported")
+	case isRecursionCall(flags):
+		c.report(e, RE2Recursion, "recursive patterns are not supported")
+	default:
+		for i := 0; i < len(flags); i++ {
+			if !strings.ContainsRune("imsU-", rune(flags[i])) {
+				c.report(e, RE2Flag, "flag "+flags[i:i+1]+" is not supported")
+				break
+			}
+		}
+	}
+}
+
+// isRecursionCall reports whether the group flags are actually
+// a recursive call: `(?R)`, `(?1)`, `(?-1)`, `(?+1)` or `(?&name)`.
+func isRecursionCall(flags string) bool {
+	switch {
+	case flags == "R":
+		return true
+	case flags == "":
+		return false
+	case flags[0] == '-' || flags[0] == '+':
+		return len(flags) > 1 && isDigit(flags[1])
+	default:
+		return isDigit(flags[0]) || flags[0] == '&'
+	}
+}
+
+func (c *re2Checker) report(e *Expr, feature RE2Feature, message string) {
+	c.issues = append(c.issues, RE2Issue{
+		Pos:     e.Pos,
+		Text:    c.text(e.Pos),
+		Feature: feature,
+		Message: message + " in RE2",
+	})
+}
+
+func (c *re2Checker) text(pos Position) string {
+	return patternText(c.pattern, pos)
+}
@@ -0,0 +1,88 @@
+package syntax
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"testing"
+)
+
+func TestCheckRE2(t *testing.T) {
+	tests := []struct {
+		dialect Dialect
+		pattern string
+		want    []string
+	}{
+		{DialectPCRE, `abc`, nil},
+		{DialectPCRE, `(?P<x>a+?)(?<y>b)\Qa.b\E[\d\pL\p{Greek}]{2,1000}(?i:x)(?-s)\x41\x{263a}\0\z`, nil},
+
+		{DialectPCRE, `a(?=b)(?!c)`, []string{`Lookahead 1-6 (?=b)`, `Lookahead 6-11 (?!c)`}},
+		{DialectPCRE, `(?<=a)b(?<!c)`, []string{`Lookbehind 0-6 (?<=a)`, `Lookbehind 7-13 (?<!c)`}},
+		{DialectPCRE, `(a)\1`, []string{`Backreference 3-5 \1`}},
+		{DialectPCRE, `(?<x>a)\k<x>`, []string{`Backreference 7-9 \k`}},
+		{DialectPCRE, `(?P<x>a)(?P=x)`, []string{`Backreference 8-14 (?P=x)`}},
+		{DialectPCRE, `(a)\g{1}`, []string{`Backreference 3-5 \g`}},
+		{DialectPCRE, `x(?>a+)`, []string{`AtomicGroup 1-7 (?>a+)`}},
+		{DialectPCRE, `a++b*+c{2}+`, []string{`Possessive 0-3 a++`, `Possessive 3-6 b*+`, `Possessive 6-11 c{2}+`}},
+		{DialectPCRE, `(a)(?R)(?1)(?-1)\g<1>`, []string{
+			`Recursion 3-7 (?R)`,
+			`Recursion 7-11 (?1)`,
+			`Recursion 11-16 (?-1)`,
+			`Recursion 16-21 \g<1>`,
+		}},
+		{DialectPCRE, `a(?#comment)`, []string{`Comment 1-12 (?#comment)`}},
+		{DialectPCRE2, `(?x)a (?^i)(?J:b)`, []string{
+			`Flag 0-4 (?x)`,
+			`Flag 6-11 (?^i)`,
+			`Flag 11-17 (?J:b)`,
+		}},
+		{DialectPCRE, `(?'x'a)`, []string{`NamedGroup 0-7 (?'x'a)`}},
+		{DialectOnig, `(?~abc)`, []string{`AbsentGroup 0-7 (?~abc)`}},
+		{DialectPCRE, `\G\h[\R]\x1\o{17}[\1]`, []string{
+			`Escape 0-2 \G`,
+			`Escape 2-4 \h`,
+			`Escape 5-7 \R`,
+			`Escape 8-11 \x1`,
+			`Escape 11-17 \o{17}`,
+			`Escape 18-20 \1`,
+		}},
+		{DialectECMAScript, `\u0041\cA`, []string{`Escape 0-6 \u0041`, `Escape 6-9 \cA`}},
+		{DialectPCRE, `\p{Letter}\P{sc=Greek}\p{greek}`, []string{
+			`UnicodeClass 0-10 \p{Letter}`,
+			`UnicodeClass 10-22 \P{sc=Greek}`,
+			`UnicodeClass 22-31 \p{greek}`,
+		}},
+		{DialectPCRE, `a{1001}b{2,5000}`, []string{`RepeatTooLarge 1-7 {1001}`, `RepeatTooLarge 8-16 {2,5000}`}},
+	}
+
+	for _, test := range tests {
+		re, err := NewParser(&ParserOptions{Dialect: test.dialect}).Parse(test.pattern)
+		if err != nil {
+			t.Fatalf("parse(%q): %v", test.pattern, err)
+		}
+		issues := CheckRE2(re)
+		var have []string
+		for _, issue := range issues {
+			have = append(have, fmt.Sprintf("%s %d-%d %s", issue.Feature, issue.Pos.Begin, issue.Pos.End, issue.Text))
+			if issue.Message == "" {
+				t.Errorf("check(%q): empty message for %s", test.pattern, issue.Text)
+			}
+		}
+		if !reflect.DeepEqual(have, test.want) {
+			t.Errorf("check(%q):\nhave: %q\nwant: %q", test.pattern, have, test.want)
+		}
+
+		// The patterns without issues should be accepted by Go.
+		if _, err := regexp.Compile(test.pattern); (err == nil) != (len(issues) == 0) {
+			t.Errorf("check(%q): %d issues, but Go compile error is %v", test.pattern, len(issues), err)
+		}
+	}
+
+	re, err := NewParser(nil).Parse(`(?=a)`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if issues := CheckRE2(re); len(issues) != 1 || issues[0].Message != "lookahead assertions are not supported in RE2" {
+		t.Errorf("unexpected issues: %+v", issues)
+	}
+}
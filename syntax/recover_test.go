@@ -0,0 +1,103 @@
+package syntax
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestParserRecover(t *testing.T) {
+	tests := []struct {
+		pattern string
+		want    string
+		errors  []string
+	}{
+		{`abc`, `abc`, nil},
+		{`a\`, `{a (bad \)}`, []string{`1:2 unexpected end of pattern: trailing '\'`}},
+		{`(a`, `(capture a)`, []string{`2:2 expected ')', found 'None'`}},
+		{`a)b`, `{a (bad )) b}`, []string{`1:2 unexpected token: )`}},
+		{`))`, `{(bad )) (bad ))}`, []string{`0:1 unexpected token: )`, `1:2 unexpected token: )`}},
+		{`*a`, `{(bad *) a}`, []string{`0:1 unexpected token: *`}},
+		{`a|*`, `(or a (bad *))`, []string{`2:3 unexpected token: *`}},
+		{`[a-z`, `[a-z]`, []string{`0:1 unterminated '['`}},
+		{`x\x{12`, `{x (bad \x) {12}`, []string{`1:3 can't find closing '}'`}},
+		{`a)(b`, `{a (bad )) (capture b)}`, []string{
+			`1:2 unexpected token: )`,
+			`4:4 expected ')', found 'None'`,
+		}},
+		{`((`, `(capture (capture (bad )))`, []string{
+			`2:2 unexpected token: None`,
+			`2:2 expected ')', found 'None'`,
+			`2:2 expected ')', found 'None'`,
+		}},
+		{`\x{1(a`, `{(bad \x) {1 (capture a)}`, []string{
+			`0:2 can't find closing '}'`,
+			`6:6 expected ')', found 'None'`,
+		}},
+	}
+
+	p := NewParser(&ParserOptions{Recover: true})
+	for _, test := range tests {
+		re, err := p.Parse(test.pattern)
+		if re == nil {
+			t.Fatalf("parse(%q): nil result", test.pattern)
+		}
+		if have := formatSyntax(re); have != test.want {
+			t.Errorf("parse(%q) syntax:\nhave: %s\nwant: %s", test.pattern, have, test.want)
+		}
+		var errors []string
+		if err != nil {
+			for _, e := range err.(ErrorList) {
+				errors = append(errors, fmt.Sprintf("%d:%d %s", e.Pos.Begin, e.Pos.End, e.Message))
+			}
+		}
+		have := strings.Join(errors, "\n")
+		want := strings.Join(test.errors, "\n")
+		if have != want {
+			t.Errorf("parse(%q) errors:\nhave:\n%s\nwant:\n%s", test.pattern, have, want)
+		}
+	}
+}
+
+func TestParserRecoverFirstError(t *testing.T) {
+	// The first recover mode error is identical to the normal mode error.
+	patterns := []string{
+		`a\`,
+		`(a`,
+		`*a`,
+		`[a-z`,
+		`x\x{12`,
+		`(?<=a+)\pL`,
+	}
+	opts := ParserOptions{Dialect: DialectPython}
+	p := NewParser(&opts)
+	opts.Recover = true
+	recoverParser := NewParser(&opts)
+	for _, pattern := range patterns {
+		_, err := p.Parse(pattern)
+		if err == nil {
+			t.Fatalf("parse(%q): expected an error", pattern)
+		}
+		_, errList := recoverParser.Parse(pattern)
+		if errList == nil {
+			t.Fatalf("parse(%q): expected an error in recover mode", pattern)
+		}
+		have := errList.(ErrorList)[0]
+		if have != err.(ParseError) {
+			t.Errorf("parse(%q): errors mismatch:\nhave: %v\nwant: %v", pattern, have, err)
+		}
+	}
+}
+
+func TestParserRecoverSemantic(t *testing.T) {
+	p := NewParser(&ParserOptions{Recover: true, Dialect: DialectPython})
+	re, err := p.Parse(`(?<=a+)\pL(?<x>y)`)
+	if have, want := formatSyntax(re), `{(?<= (+ a)) \pL (capture y x)}`; have != want {
+		t.Errorf("syntax mismatch:\nhave: %s\nwant: %s", have, want)
+	}
+	have := err.Error()
+	want := `\p{...} classes are not supported in Python (and 2 more errors)`
+	if have != want {
+		t.Errorf("error mismatch:\nhave: %s\nwant: %s", have, want)
+	}
+}
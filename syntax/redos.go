@@ -0,0 +1,301 @@
+package syntax
+
+import (
+	"unicode"
+)
+
+// Blowup is an estimated backtracking matching time class.
+type Blowup byte
+
+//go:generate stringer -type=Blowup -trimprefix=Blowup
+const (
+	// BlowupLinear means that no super-linear backtracking was detected.
+	BlowupLinear Blowup = iota
+
+	// BlowupPolynomial means that the matching time can grow polynomially
+	// with the input length: `.*.*=`.
+	BlowupPolynomial
+
+	// BlowupExponential means that the matching time can grow exponentially
+	// with the input length: `(a+)+b`.
+	BlowupExponential
+)
+
+// BacktrackingRisk describes a pattern part that can cause
+// catastrophic backtracking (ReDoS) in the backtracking regexp engines.
+type BacktrackingRisk struct {
+	// Pos is a span of the pattern part that causes the backtracking.
+	Pos Position
+
+	// Text is the pattern part that is described by Pos.
+	Text string
+
+	Blowup Blowup
+
+	Message string
+}
+
+// CheckBacktracking reports the re parts that can make the backtracking
+// regexp engines (PCRE, Java, .NET, etc.) run in super-linear time.
+//
+// The following patterns are detected:
+//
+//   - nested unbounded quantifiers: `(a+)+`, `(\w+\s?)*` (exponential)
+//   - repeated alternations with overlapping alternatives: `(a|a)*`, `(\d|\w)+` (exponential)
+//   - adjacent unbounded quantifiers over the same chars: `\d+\d+`, `.*=.*` (polynomial)
+//
+// This is a heuristic analysis: it doesn't take the case folding into account
+// and may report the patterns that can't actually be exploited.
+// Possessive quantifiers and atomic groups are not reported.
+// Linear-time engines, like RE2, are not affected by any of these.
+func CheckBacktracking(re *Regexp) []BacktrackingRisk {
+	c := backtrackingChecker{pattern: re.Pattern}
+	c.walk(&re.Expr)
+	return c.risks
+}
+
+type backtrackingChecker struct {
+	pattern string
+	risks   []BacktrackingRisk
+}
+
+func (c *backtrackingChecker) walk(e *Expr) {
+	switch e.Op {
+	case OpPossessive:
+		// Only the quantifier body is checked.
+		c.walk(&e.Args[0].Args[0])
+		return
+	case OpConcat:
+		c.checkAdjacent(e)
+	case OpStar, OpPlus, OpRepeat:
+		if isUnboundedQuantifier(e) {
+			c.checkRepeated(e)
+		}
+	}
+	for i := range e.Args {
+		c.walk(&e.Args[i])
+	}
+}
+
+// checkRepeated checks the body of the unbounded quantifier e.
+func (c *backtrackingChecker) checkRepeated(e *Expr) {
+	body := unwrapGroups(&e.Args[0])
+	branches := []*Expr{body}
+	if body.Op == OpAlt {
+		branches = branches[:0]
+		for i := range body.Args {
+			branches = append(branches, unwrapGroups(&body.Args[i]))
+		}
+		if x, y := overlappingAlternatives(branches); x != nil {
+			c.report(e.Pos, BlowupExponential, "alternatives "+c.text(x.Pos)+" and "+c.text(y.Pos)+
+				" can match the same input")
+			return
+		}
+	}
+
+	for _, branch := range branches {
+		items := flattenConcat(branch, nil)
+		for i, item := range items {
+			if !isUnboundedQuantifier(item) {
+				continue
+			}
+			othersNullable := true
+			for j, other := range items {
+				if j != i && !isNullable(*other) {
+					othersNullable = false
+					break
+				}
+			}
+			if othersNullable {
+				c.report(e.Pos, BlowupExponential, "nested unbounded quantifier "+c.text(item.Pos))
+				return
+			}
+		}
+	}
+}
+
+// checkAdjacent checks whether concatenation e contains unbounded quantifiers
+// that can match the same chars without anything in between.
+func (c *backtrackingChecker) checkAdjacent(e *Expr) {
+	for i := range e.Args {
+		x := &e.Args[i]
+		if !isUnboundedQuantifier(x) {
+			continue
+		}
+		xChars := exprChars(x.Args[0], false)
+		for j := i + 1; j < len(e.Args); j++ {
+			y := &e.Args[j]
+			if isUnboundedQuantifier(y) && rangesOverlap(xChars, exprChars(y.Args[0], false)) {
+				pos := Position{Begin: x.Begin(), End: y.End()}
+				c.report(pos, BlowupPolynomial, "quantifiers "+c.text(x.Pos)+" and "+c.text(y.Pos)+
+					" can match the same input")
+				break
+			}
+			if !isNullable(*y) {
+				break
+			}
+		}
+	}
+}
+
+func (c *backtrackingChecker) report(pos Position, blowup Blowup, message string) {
+	c.risks = append(c.risks, BacktrackingRisk{
+		Pos:     pos,
+		Text:    c.text(pos),
+		Blowup:  blowup,
+		Message: message,
+	})
+}
+
+func (c *backtrackingChecker) text(pos Position) string {
+	return patternText(c.pattern, pos)
+}
+
+// overlappingAlternatives returns a pair of alternatives that
+// can match the same input, as far as we can tell.
+//
+// x and y are considered to be overlapping if they start with the same char
+// and x chars and widths are the subsets of the y chars and widths.
+func overlappingAlternatives(branches []*Expr) (x, y *Expr) {
+	for i, x := range branches {
+		for _, y := range branches[i+1:] {
+			if !rangesOverlap(exprChars(*x, true), exprChars(*y, true)) {
+				continue
+			}
+			if alternativeCovers(y, x) || alternativeCovers(x, y) {
+				return x, y
+			}
+		}
+	}
+	return nil, nil
+}
+
+// alternativeCovers reports whether x chars and widths are the subsets of y.
+func alternativeCovers(x, y *Expr) bool {
+	if len(subtractRanges(exprChars(*x, false), exprChars(*y, false))) != 0 {
+		return false
+	}
+	xmin, xmax := exprWidth(*x)
+	ymin, ymax := exprWidth(*y)
+	if xmin < ymin {
+		return false
+	}
+	return ymax == -1 || (xmax != -1 && xmax <= ymax)
+}
+
+func isUnboundedQuantifier(e *Expr) bool {
+	switch e.Op {
+	case OpStar, OpPlus:
+		return true
+	case OpRepeat:
+		_, max := e.RepeatBounds()
+		return max == -1
+	case OpNonGreedy:
+		return isUnboundedQuantifier(&e.Args[0])
+	default:
+		// Possessive quantifiers never backtrack.
+		return false
+	}
+}
+
+func isNullable(e Expr) bool {
+	min, _ := exprWidth(e)
+	return min == 0
+}
+
+// unwrapGroups returns the expression enclosed into the (possibly nested) groups.
+// Atomic groups are not unwrapped as they prevent the backtracking.
+func unwrapGroups(e *Expr) *Expr {
+	for {
+		switch e.Op {
+		case OpCapture, OpNamedCapture, OpGroup, OpGroupWithFlags:
+			e = &e.Args[0]
+		default:
+			return e
+		}
+	}
+}
+
+// flattenConcat appends the e concatenation items to the list;
+// the concatenations inside the groups are flattened as well.
+func flattenConcat(e *Expr, list []*Expr) []*Expr {
+	e = unwrapGroups(e)
+	if e.Op != OpConcat {
+		return append(list, e)
+	}
+	for i := range e.Args {
+		list = flattenConcat(&e.Args[i], list)
+	}
+	return list
+}
+
+// exprChars returns a superset of the chars that can be matched by e.
+// If first is true, only the chars that can start the match are returned.
+func exprChars(e Expr, first bool) []rune {
+	switch e.Op {
+	case OpCaret, OpDollar, OpComment, OpFlagOnlyGroup,
+		OpPositiveLookahead, OpNegativeLookahead, OpPositiveLookbehind, OpNegativeLookbehind:
+		return nil
+
+	case OpDot:
+		return []rune{0, unicode.MaxRune}
+
+	case OpConcat, OpLiteral:
+		var ranges []rune
+		for _, a := range e.Args {
+			ranges = append(ranges, exprChars(a, first)...)
+			if first && !isNullable(a) {
+				break
+			}
+		}
+		return cleanRanges(ranges)
+
+	case OpAlt:
+		var ranges []rune
+		for _, a := range e.Args {
+			ranges = append(ranges, exprChars(a, first)...)
+		}
+		return cleanRanges(ranges)
+
+	case OpStar, OpPlus, OpQuestion, OpRepeat, OpNonGreedy, OpPossessive,
+		OpCapture, OpNamedCapture, OpGroup, OpGroupWithFlags, OpAtomicGroup:
+		return exprChars(e.Args[0], first)
+
+	case OpQuote:
+		var ranges []rune
+		for _, r := range e.Args[0].Value {
+			ranges = append(ranges, r, r)
+			if first {
+				break
+			}
+		}
+		return cleanRanges(ranges)
+
+	case OpEscapeChar:
+		if min, max := escapeCharWidth(e.Args[0].Value); min == 0 && max == 0 {
+			return nil
+		}
+	}
+
+	ranges, err := ClassRanges(&e)
+	if err != nil {
+		return []rune{0, unicode.MaxRune}
+	}
+	return ranges
+}
+
+// rangesOverlap reports whether clean ranges x and y have common chars.
+func rangesOverlap(x, y []rune) bool {
+	i, j := 0, 0
+	for i < len(x) && j < len(y) {
+		switch {
+		case x[i+1] < y[j]:
+			i += 2
+		case y[j+1] < x[i]:
+			j += 2
+		default:
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,53 @@
+package syntax
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCheckBacktracking(t *testing.T) {
+	tests := []struct {
+		pattern string
+		want    []string
+	}{
+		{`abc`, nil},
+		{`a+b+`, nil},
+		{`(a+b)+`, nil},
+		{`(a|b)*`, nil},
+		{`(a|ab)*`, nil},
+		{`\d+\.\d+`, nil},
+		{`(?:a++)+`, nil},
+		{`(?:a+)++`, nil},
+		{`(?:a{1,5})+`, nil},
+
+		{`(a+)+b`, []string{`Exponential (a+)+: nested unbounded quantifier a+`}},
+		{`(?:a*)*`, []string{`Exponential (?:a*)*: nested unbounded quantifier a*`}},
+		{`x(\w+\s?)*y`, []string{`Exponential (\w+\s?)*: nested unbounded quantifier \w+`}},
+		{`((?:a+?)){2,}`, []string{`Exponential ((?:a+?)){2,}: nested unbounded quantifier a+?`}},
+		{`(a|b+)+`, []string{`Exponential (a|b+)+: nested unbounded quantifier b+`}},
+		{`(a|a)*`, []string{`Exponential (a|a)*: alternatives a and a can match the same input`}},
+		{`(\d|\w)+`, []string{`Exponential (\d|\w)+: alternatives \d and \w can match the same input`}},
+		{`(?:x|.*|y)+`, []string{`Exponential (?:x|.*|y)+: alternatives x and .* can match the same input`}},
+
+		{`\d+\d+`, []string{`Polynomial \d+\d+: quantifiers \d+ and \d+ can match the same input`}},
+		{`.*=?.*`, []string{`Polynomial .*=?.*: quantifiers .* and .* can match the same input`}},
+		{`a*b*`, nil},
+		{`a*b+a*`, nil},
+		{`\w+x\w+`, nil},
+	}
+
+	p := NewParser(nil)
+	for _, test := range tests {
+		re, err := p.Parse(test.pattern)
+		if err != nil {
+			t.Fatalf("parse(%q): %v", test.pattern, err)
+		}
+		var have []string
+		for _, r := range CheckBacktracking(re) {
+			have = append(have, r.Blowup.String()+" "+r.Text+": "+r.Message)
+		}
+		if !reflect.DeepEqual(have, test.want) {
+			t.Errorf("check(%q):\nhave: %q\nwant: %q", test.pattern, have, test.want)
+		}
+	}
+}
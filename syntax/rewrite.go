@@ -0,0 +1,80 @@
+package syntax
+
+import (
+	"sort"
+	"strings"
+)
+
+// Rewrite calls fn for every re expression in post-order
+// and parses the resulting pattern.
+//
+// To replace an expression, fn assigns its new textual representation
+// to the expression Value, like `\d` for `[0-9]`. Args are rewritten before
+// their parent, so the parent Value already includes the args replacements
+// when fn is called for it. Other expression fields are ignored.
+//
+// fn may also update args Values instead, like a capture name.
+//
+// The returned Regexp has consistent Args, Pos and Value fields.
+// re Values are updated in place, so re should not be used after this call.
+func (p *Parser) Rewrite(re *Regexp, fn func(e *Expr)) (*Regexp, error) {
+	root := &re.Expr
+	rewriteExpr(re.Pattern, root, fn)
+	pattern := re.Pattern[:root.Begin()] + root.Value + re.Pattern[root.End():]
+	return p.Parse(pattern)
+}
+
+func rewriteExpr(pattern string, e *Expr, fn func(*Expr)) {
+	if len(e.Args) == 0 {
+		fn(e)
+		return
+	}
+
+	// Args are not always ordered by their positions.
+	// For example, `(?P<name>x)` name is the last arg.
+	order := make([]int, len(e.Args))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		return e.Args[order[i]].Begin() < e.Args[order[j]].Begin()
+	})
+
+	// Record the text between args before they're rewritten.
+	// In free-spacing mode, a quantifier can span over the comments
+	// that follow its operand; such overlapping args are skipped.
+	glue := make([]string, 0, len(e.Args)+1)
+	pos := e.Begin()
+	visible := order[:0]
+	for _, i := range order {
+		if e.Args[i].Begin() < pos {
+			continue
+		}
+		visible = append(visible, i)
+		glue = append(glue, pattern[pos:e.Args[i].Begin()])
+		pos = e.Args[i].End()
+	}
+	glue = append(glue, pattern[pos:e.End()])
+
+	build := func() string {
+		var b strings.Builder
+		for k, i := range visible {
+			b.WriteString(glue[k])
+			b.WriteString(e.Args[i].Value)
+		}
+		b.WriteString(glue[len(glue)-1])
+		return b.String()
+	}
+
+	for i := range e.Args {
+		rewriteExpr(pattern, &e.Args[i], fn)
+	}
+	e.Value = build()
+
+	// fn is permitted to modify e args instead of e itself.
+	value := e.Value
+	fn(e)
+	if e.Value == value {
+		e.Value = build()
+	}
+}
@@ -0,0 +1,97 @@
+package syntax
+
+import (
+	"testing"
+)
+
+func TestRewrite(t *testing.T) {
+	isDigitRange := func(e *Expr) bool {
+		return e.Op == OpCharClass && len(e.Args) == 1 &&
+			e.Args[0].Op == OpCharRange &&
+			e.Args[0].Args[0].Value == "0" &&
+			e.Args[0].Args[1].Value == "9"
+	}
+
+	type rewriteTest struct {
+		pattern string
+		want    string
+		syntax  string
+	}
+	runTests := func(opts *ParserOptions, fn func(e *Expr), tests []rewriteTest) {
+		t.Helper()
+		p := NewParser(opts)
+		for _, test := range tests {
+			re, err := p.Parse(test.pattern)
+			if err != nil {
+				t.Fatalf("parse(%q): %v", test.pattern, err)
+			}
+			re, err = p.Rewrite(re, fn)
+			if err != nil {
+				t.Fatalf("rewrite(%q): %v", test.pattern, err)
+			}
+			if re.Pattern != test.want {
+				t.Errorf("rewrite(%q):\nhave: %s\nwant: %s", test.pattern, re.Pattern, test.want)
+			}
+			if have := formatSyntax(re); have != test.syntax {
+				t.Errorf("rewrite(%q) syntax:\nhave: %s\nwant: %s", test.pattern, have, test.syntax)
+			}
+		}
+	}
+
+	runTests(nil, func(e *Expr) {
+		if isDigitRange(e) {
+			e.Value = `\d`
+		}
+	}, []rewriteTest{
+		{`[0-9]`, `\d`, `\d`},
+		{`x[0-9]+|[0-9]`, `x\d+|\d`, `(or {x (+ \d)} \d)`},
+		{`(?P<num>[0-9]{1,3})`, `(?P<num>\d{1,3})`, `(capture (repeat \d {1,3}) num)`},
+		{`(?i:[0-9])`, `(?i:\d)`, `(group \d ?i)`},
+		{`[a-z]`, `[a-z]`, `[a-z]`},
+	})
+
+	// Rewrite named capture names.
+	runTests(nil, func(e *Expr) {
+		if e.Op == OpNamedCapture {
+			e.Args[1].Value = "_" + e.Args[1].Value
+		}
+	}, []rewriteTest{
+		{`(?P<x>a)(?<y>b)`, `(?P<_x>a)(?<_y>b)`, `{(capture a _x) (capture b _y)}`},
+	})
+
+	// Chars replacement inside literals.
+	runTests(nil, func(e *Expr) {
+		if e.Op == OpChar && e.Value == "." {
+			e.Value = `\.`
+		}
+	}, []rewriteTest{
+		{`a.b`, `a.b`, `{a . b}`},
+		{`[.]x.`, `[\.]x.`, `{[\.] x .}`},
+	})
+
+	// Comments removal.
+	runTests(&ParserOptions{FreeSpacing: true}, func(e *Expr) {
+		if e.Op == OpComment {
+			e.Value = ""
+		}
+	}, []rewriteTest{
+		{"a # c\n b", "ab", "ab"},
+		{"a # c\n +", "a # c\n +", "(+ a)"},
+	})
+}
+
+func TestRewriteError(t *testing.T) {
+	p := NewParser(nil)
+	re, err := p.Parse(`a(b)`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = p.Rewrite(re, func(e *Expr) {
+		if e.Op == OpCapture {
+			e.Value = "(b"
+		}
+	})
+	if err == nil || err.Error() != "expected ')', found 'None'" {
+		t.Errorf("expected an error, got %v", err)
+	}
+}
@@ -0,0 +1,138 @@
+package syntax
+
+import (
+	"strings"
+)
+
+// Simplify returns a copy of re with trivial constructs folded.
+//
+// The following transformations are applied:
+//
+//   - single-element alternations and concatenations are replaced by their element
+//   - nested concatenations and alternations are flattened: `a(?:bc)` => `abc`
+//   - redundant `(?:re)` groups are collapsed: `(?:a)*` => `a*`
+//   - single repetitions are removed: `a{1,1}` => `a`, `a{1}?` => `a`
+//   - adjacent chars and literals are merged into a single literal
+//
+// Unlike Minify, the result is an AST, so it can be compared with
+// the original tree using EqualExpr or printed with Print.
+// Expressions positions refer to re.Pattern, so the simplified
+// parts can be reported in terms of the original source.
+// re is not modified.
+func Simplify(re *Regexp) *Regexp {
+	e := re.Expr.Clone()
+	simplifyExpr(&e)
+	if e.Op == OpGroup && canUnwrapGroup(nil, &e.Args[0]) {
+		e = e.Args[0]
+	}
+	return &Regexp{Pattern: re.Pattern, Expr: e, Flags: re.Flags}
+}
+
+func simplifyExpr(e *Expr) {
+	for i := range e.Args {
+		simplifyExpr(&e.Args[i])
+	}
+
+	switch e.Op {
+	case OpConcat:
+		// Unwrapped groups can add more args than they remove,
+		// so the args can't be filtered in place.
+		args := make([]Expr, 0, len(e.Args))
+		for _, a := range e.Args {
+			if a.Op == OpGroup && canUnwrapGroup(e, &a.Args[0]) {
+				a = a.Args[0]
+			}
+			if a.Op == OpConcat {
+				args = appendLiterals(args, a.Args...)
+				continue
+			}
+			args = appendLiterals(args, a)
+		}
+		e.Args = args
+		if len(e.Args) == 1 {
+			*e = e.Args[0]
+		}
+
+	case OpAlt:
+		args := make([]Expr, 0, len(e.Args))
+		for _, a := range e.Args {
+			if a.Op == OpGroup && canUnwrapGroup(e, &a.Args[0]) {
+				a = a.Args[0]
+			}
+			if a.Op == OpAlt {
+				args = append(args, a.Args...)
+				continue
+			}
+			args = append(args, a)
+		}
+		e.Args = args
+		if len(e.Args) == 1 {
+			*e = e.Args[0]
+		}
+
+	case OpRepeat:
+		s := e.Args[1].Value
+		if s != "" && s[0] == '{' {
+			if min, max := repeatBounds(s); min == 1 && max == 1 {
+				*e = e.Args[0]
+			}
+		}
+
+	case OpNonGreedy, OpPossessive:
+		if !e.Args[0].IsQuantifier() {
+			// `a{1}?` was turned into `a`.
+			*e = e.Args[0]
+		}
+
+	default:
+		for i := range e.Args {
+			a := &e.Args[i]
+			if a.Op == OpGroup && canUnwrapGroup(e, &a.Args[0]) {
+				*a = a.Args[0]
+			}
+		}
+	}
+}
+
+// appendLiterals appends args to the concatenation list,
+// merging the consecutive chars and literals.
+func appendLiterals(list []Expr, args ...Expr) []Expr {
+	for _, a := range args {
+		if a.Op != OpChar && a.Op != OpLiteral {
+			list = append(list, a)
+			continue
+		}
+		if len(list) == 0 {
+			list = append(list, a)
+			continue
+		}
+		last := &list[len(list)-1]
+		if last.Op != OpChar && last.Op != OpLiteral {
+			list = append(list, a)
+			continue
+		}
+		*last = mergeLiterals(*last, a)
+	}
+	return list
+}
+
+func mergeLiterals(x, y Expr) Expr {
+	var args []Expr
+	for _, e := range []Expr{x, y} {
+		if e.Op == OpChar {
+			args = append(args, e)
+		} else {
+			args = append(args, e.Args...)
+		}
+	}
+	var value strings.Builder
+	for _, a := range args {
+		value.WriteString(a.Value)
+	}
+	return Expr{
+		Op:    OpLiteral,
+		Pos:   Position{Begin: x.Begin(), End: y.End()},
+		Args:  args,
+		Value: value.String(),
+	}
+}
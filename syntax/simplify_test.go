@@ -0,0 +1,69 @@
+package syntax
+
+import (
+	"testing"
+)
+
+func TestSimplify(t *testing.T) {
+	tests := []struct {
+		pattern string
+		want    string
+	}{
+		{``, ``},
+		{`abc`, `abc`},
+		{`(?:a)`, `a`},
+		{`(?:a|b)`, `a|b`},
+		{`(?:a)*`, `a*`},
+		{`(?:ab)*`, `(?:ab)*`},
+		{`x(?:ab)y`, `xaby`},
+		{`(?:a*b)c`, `a*bc`},
+		{`x(?:a|b)y`, `x(?:a|b)y`},
+		{`x(?:)y`, `xy`},
+		{`(?:a|b)|c`, `a|b|c`},
+		{`((?:a|b))`, `(a|b)`},
+		{`(?:(?i)a)b`, `(?:(?i)a)b`},
+		{`(?:\1)0`, `(?:\1)0`},
+		{`a{1,1}`, `a`},
+		{`a{1}b`, `ab`},
+		{`a{1}?`, `a`},
+		{`a{0,1}`, `a{0,1}`},
+		{`x(?:a{1})b+`, `xab+`},
+	}
+
+	p := NewParser(nil)
+	for _, test := range tests {
+		re, err := p.Parse(test.pattern)
+		if err != nil {
+			t.Fatalf("parse(%q): %v", test.pattern, err)
+		}
+		simplified := Simplify(re)
+		if have := Print(simplified); have != test.want {
+			t.Errorf("simplify(%q):\nhave: %s\nwant: %s", test.pattern, have, test.want)
+		}
+		want, err := NewParser(nil).Parse(test.want)
+		if err != nil {
+			t.Fatalf("parse(%q): %v", test.want, err)
+		}
+		if !EqualExpr(simplified.Expr, want.Expr) {
+			t.Errorf("simplify(%q): tree is not equal to the %q tree", test.pattern, test.want)
+		}
+		if simplified.Pattern != test.pattern {
+			t.Errorf("simplify(%q): pattern was changed to %q", test.pattern, simplified.Pattern)
+		}
+	}
+}
+
+func TestSimplifyLiteralPos(t *testing.T) {
+	re, err := NewParser(nil).Parse(`x(?:ab)y*`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	e := Simplify(re).Expr
+	lit := e.Args[0]
+	if lit.Op != OpLiteral || lit.Value != "xab" {
+		t.Fatalf("expected xab literal, got %s %q", lit.Op, lit.Value)
+	}
+	if lit.Begin() != 0 || lit.End() != 6 {
+		t.Errorf("literal pos mismatch: have %d:%d, want 0:6", lit.Begin(), lit.End())
+	}
+}
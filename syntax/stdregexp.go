@@ -0,0 +1,541 @@
+package syntax
+
+import (
+	stdsyntax "regexp/syntax"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// ToStdRegexp converts re AST into the regexp/syntax package representation.
+//
+// flags are interpreted in the same way as by regexp/syntax.Parse;
+// for example, the multi-line mode is enabled unless OneLine is set.
+// Inline flag groups like `(?i)` update them for the rest of the enclosing group.
+// re.Flags are applied on top of flags, like an inline flag group.
+//
+// The result is not simplified; use its Simplify method before compiling it.
+// Constructs that are not supported by regexp/syntax, like lookarounds,
+// are reported as ErrUnsupported errors inside ErrorList.
+func ToStdRegexp(re *Regexp, flags stdsyntax.Flags) (*stdsyntax.Regexp, error) {
+	c := stdConverter{}
+	c.init(re)
+	if re.Flags != "" {
+		// The x flag was already handled by the parser.
+		flags = c.applyFlags(&re.Expr, removeFlag(re.Flags, 'x'), flags)
+	}
+	result := c.convert(&re.Expr, &flags)
+	if len(c.errors) != 0 {
+		return nil, c.errors
+	}
+	return result, nil
+}
+
+type stdConverter struct {
+	translator
+
+	// capIndex is the last assigned capture group index.
+	capIndex int
+
+	// asciiFold limits the case folding to ASCII letters.
+	asciiFold bool
+}
+
+// convert returns e regexp/syntax equivalent.
+// flags are updated by the flag-only groups, like `(?i)`.
+func (c *stdConverter) convert(e *Expr, flags *stdsyntax.Flags) *stdsyntax.Regexp {
+	switch e.Op {
+	case OpConcat:
+		return c.convertList(stdsyntax.OpConcat, e.Args, flags)
+	case OpAlt:
+		return c.convertList(stdsyntax.OpAlternate, e.Args, flags)
+
+	case OpLiteral:
+		var runes []rune
+		for _, a := range e.Args {
+			runes = append(runes, c.literalRune(&a))
+		}
+		return c.newLiteral(runes, *flags)
+	case OpChar:
+		return c.newLiteral([]rune{c.literalRune(e)}, *flags)
+	case OpQuote:
+		if e.Form == FormQuoteStrayEnd || e.Args[0].Value == "" {
+			return &stdsyntax.Regexp{Op: stdsyntax.OpEmptyMatch, Flags: *flags}
+		}
+		return c.newLiteral([]rune(e.Args[0].Value), *flags)
+	case OpEscapeHex, OpEscapeOctal:
+		return c.newLiteral([]rune{c.literalRune(e)}, *flags)
+	case OpEscapeMeta:
+		return c.newLiteral([]rune{c.literalRune(e)}, *flags)
+
+	case OpEscapeChar:
+		return c.convertEscape(e, *flags)
+	case OpEscapeUni, OpEscapeClass:
+		return c.newCharClass(c.classRanges(e, *flags), *flags)
+	case OpCharClass, OpNegCharClass:
+		return c.newCharClass(c.classRanges(e, *flags), *flags)
+
+	case OpDot:
+		if *flags&stdsyntax.DotNL != 0 {
+			return &stdsyntax.Regexp{Op: stdsyntax.OpAnyChar, Flags: *flags}
+		}
+		return &stdsyntax.Regexp{Op: stdsyntax.OpAnyCharNotNL, Flags: *flags}
+	case OpCaret:
+		if *flags&stdsyntax.OneLine == 0 {
+			return &stdsyntax.Regexp{Op: stdsyntax.OpBeginLine, Flags: *flags}
+		}
+		return &stdsyntax.Regexp{Op: stdsyntax.OpBeginText, Flags: *flags}
+	case OpDollar:
+		if *flags&stdsyntax.OneLine == 0 {
+			return &stdsyntax.Regexp{Op: stdsyntax.OpEndLine, Flags: *flags}
+		}
+		return &stdsyntax.Regexp{Op: stdsyntax.OpEndText, Flags: *flags | stdsyntax.WasDollar}
+
+	case OpStar, OpPlus, OpQuestion, OpRepeat:
+		return c.convertRepeat(e, *flags, false)
+	case OpNonGreedy:
+		if !e.Args[0].IsQuantifier() || e.Args[0].Op == OpNonGreedy || e.Args[0].Op == OpPossessive {
+			break
+		}
+		return c.convertRepeat(&e.Args[0], *flags, true)
+
+	case OpCapture, OpNamedCapture:
+		c.capIndex++
+		result := &stdsyntax.Regexp{Op: stdsyntax.OpCapture, Flags: *flags, Cap: c.capIndex}
+		if e.Op == OpNamedCapture {
+			result.Name = e.Args[1].Value
+		}
+		groupFlags := *flags
+		result.Sub = []*stdsyntax.Regexp{c.convert(&e.Args[0], &groupFlags)}
+		return result
+	case OpGroup:
+		groupFlags := *flags
+		return c.convert(&e.Args[0], &groupFlags)
+	case OpGroupWithFlags:
+		groupFlags := c.applyFlags(e, e.Args[1].Value, *flags)
+		return c.convert(&e.Args[0], &groupFlags)
+	case OpFlagOnlyGroup:
+		*flags = c.applyFlags(e, e.Args[0].Value, *flags)
+		return &stdsyntax.Regexp{Op: stdsyntax.OpEmptyMatch, Flags: *flags}
+
+	case OpComment:
+		return &stdsyntax.Regexp{Op: stdsyntax.OpEmptyMatch, Flags: *flags}
+	}
+
+	c.fail(e, e.Op.String()+" is not supported by regexp/syntax")
+	return &stdsyntax.Regexp{Op: stdsyntax.OpNoMatch}
+}
+
+func (c *stdConverter) convertList(op stdsyntax.Op, args []Expr, flags *stdsyntax.Flags) *stdsyntax.Regexp {
+	subs := make([]*stdsyntax.Regexp, 0, len(args))
+	for i := range args {
+		sub := c.convert(&args[i], flags)
+		if op == stdsyntax.OpConcat && sub.Op == stdsyntax.OpEmptyMatch {
+			continue
+		}
+		subs = append(subs, sub)
+	}
+	switch len(subs) {
+	case 0:
+		return &stdsyntax.Regexp{Op: stdsyntax.OpEmptyMatch, Flags: *flags}
+	case 1:
+		if op == stdsyntax.OpConcat {
+			return subs[0]
+		}
+	}
+	return &stdsyntax.Regexp{Op: op, Flags: *flags, Sub: subs}
+}
+
+func (c *stdConverter) convertRepeat(e *Expr, flags stdsyntax.Flags, nonGreedy bool) *stdsyntax.Regexp {
+	result := &stdsyntax.Regexp{Flags: flags}
+	switch e.Op {
+	case OpStar:
+		result.Op = stdsyntax.OpStar
+	case OpPlus:
+		result.Op = stdsyntax.OpPlus
+	case OpQuestion:
+		result.Op = stdsyntax.OpQuest
+	case OpRepeat:
+		result.Op = stdsyntax.OpRepeat
+		result.Min, result.Max = e.RepeatBounds()
+		if result.Min > re2MaxRepeat || result.Max > re2MaxRepeat || (result.Max != -1 && result.Max < result.Min) {
+			c.fail(e, "invalid repeat count")
+		}
+	}
+	if nonGreedy {
+		// NonGreedy flag inverts the meaning of the `?` suffix.
+		result.Flags ^= stdsyntax.NonGreedy
+	}
+	subFlags := flags
+	result.Sub = []*stdsyntax.Regexp{c.convert(&e.Args[0], &subFlags)}
+	return result
+}
+
+// applyFlags returns the flags updated by the imsU flags string.
+func (c *stdConverter) applyFlags(e *Expr, s string, flags stdsyntax.Flags) stdsyntax.Flags {
+	if e.Form == FormFlagsReset {
+		c.fail(e, "(?^) flag resets are not supported by regexp/syntax")
+		return flags
+	}
+	enable := true
+	for _, ch := range s {
+		set := enable
+		var flag stdsyntax.Flags
+		switch ch {
+		case '-':
+			enable = false
+			continue
+		case 'i':
+			flag = stdsyntax.FoldCase
+		case 's':
+			flag = stdsyntax.DotNL
+		case 'U':
+			flag = stdsyntax.NonGreedy
+		case 'm':
+			// `(?m)` clears the OneLine flag.
+			flag = stdsyntax.OneLine
+			set = !enable
+		default:
+			c.fail(e, "(?"+string(ch)+") flag is not supported by regexp/syntax")
+			continue
+		}
+		if set {
+			flags |= flag
+		} else {
+			flags &^= flag
+		}
+	}
+	return flags
+}
+
+func (c *stdConverter) newLiteral(runes []rune, flags stdsyntax.Flags) *stdsyntax.Regexp {
+	return &stdsyntax.Regexp{
+		Op:    stdsyntax.OpLiteral,
+		Flags: flags & stdsyntax.FoldCase,
+		Rune:  runes,
+	}
+}
+
+func (c *stdConverter) newCharClass(ranges []rune, flags stdsyntax.Flags) *stdsyntax.Regexp {
+	return &stdsyntax.Regexp{Op: stdsyntax.OpCharClass, Flags: flags, Rune: ranges}
+}
+
+func (c *stdConverter) convertEscape(e *Expr, flags stdsyntax.Flags) *stdsyntax.Regexp {
+	switch e.Args[0].Value {
+	case "b":
+		return &stdsyntax.Regexp{Op: stdsyntax.OpWordBoundary, Flags: flags}
+	case "B":
+		return &stdsyntax.Regexp{Op: stdsyntax.OpNoWordBoundary, Flags: flags}
+	case "A":
+		return &stdsyntax.Regexp{Op: stdsyntax.OpBeginText, Flags: flags}
+	case "z":
+		return &stdsyntax.Regexp{Op: stdsyntax.OpEndText, Flags: flags}
+	}
+	return c.newLiteral([]rune{c.literalRune(e)}, flags)
+}
+
+// literalRune returns a char that is matched by e.
+func (c *stdConverter) literalRune(e *Expr) rune {
+	switch e.Op {
+	case OpChar:
+		r, _ := utf8.DecodeRuneInString(e.Value)
+		return r
+	case OpQuote:
+		r, _ := utf8.DecodeRuneInString(e.Args[0].Value)
+		return r
+	case OpEscapeMeta:
+		r, _ := utf8.DecodeRuneInString(e.Args[0].Value)
+		return r
+	case OpEscapeHex:
+		code, err := strconv.ParseUint(e.Args[0].Value, 16, 32)
+		if err != nil || code > unicode.MaxRune {
+			c.fail(e, "invalid escape code")
+		}
+		return rune(code)
+	case OpEscapeOctal:
+		v := e.Args[0].Value
+		if e.Form == FormDefault && len(v) == 1 && v != "0" {
+			c.fail(e, "backreferences are not supported by regexp/syntax")
+			return 0
+		}
+		code, err := strconv.ParseUint(v, 8, 32)
+		if err != nil || code > unicode.MaxRune {
+			c.fail(e, "invalid escape code")
+		}
+		return rune(code)
+	case OpEscapeChar:
+		v := e.Args[0].Value
+		switch v {
+		case "a":
+			return '\a'
+		case "f":
+			return '\f'
+		case "t":
+			return '\t'
+		case "n":
+			return '\n'
+		case "r":
+			return '\r'
+		case "v":
+			return '\v'
+		}
+		if r, size := utf8.DecodeRuneInString(v); size == len(v) && !isAlphanumeric(v[0]) {
+			return r
+		}
+		c.fail(e, `\`+v+" escape is not supported by regexp/syntax")
+		return 0
+	}
+	c.fail(e, e.Op.String()+" can't be used as a char")
+	return 0
+}
+
+// classRanges returns sorted rune ranges matched by the class-like e.
+func (c *stdConverter) classRanges(e *Expr, flags stdsyntax.Flags) []rune {
+	var ranges []rune
+	negated := false
+	fold := flags&stdsyntax.FoldCase != 0
+	switch e.Op {
+	case OpCharClass, OpNegCharClass:
+		negated = e.Op == OpNegCharClass
+		for i := range e.Args {
+			ranges = c.appendClassElem(ranges, &e.Args[i], fold)
+		}
+	default:
+		ranges = c.appendClassElem(ranges, e, fold)
+	}
+
+	ranges = cleanRanges(ranges)
+	if negated {
+		if flags&stdsyntax.ClassNL == 0 {
+			// Negated classes don't match '\n' unless ClassNL is set.
+			ranges = cleanRanges(append(ranges, '\n', '\n'))
+		}
+		ranges = negateRanges(ranges)
+	}
+	return ranges
+}
+
+// appendClassElem appends the ranges matched by the class element e.
+// If fold is set, the element is matched case-insensitively.
+func (c *stdConverter) appendClassElem(ranges []rune, e *Expr, fold bool) []rune {
+	switch e.Op {
+	case OpCharRange:
+		lo := c.literalRune(&e.Args[0])
+		hi := c.literalRune(&e.Args[1])
+		if lo > hi {
+			c.fail(e, "invalid char range")
+		}
+		return c.appendRanges(ranges, []rune{lo, hi}, false, fold)
+
+	case OpQuote:
+		var class []rune
+		for _, r := range e.Args[0].Value {
+			class = append(class, r, r)
+		}
+		return c.appendRanges(ranges, class, false, fold)
+
+	case OpPosixClass:
+		name := posixClassName(e)
+		negated := strings.HasPrefix(name, "^")
+		class, ok := posixClassRanges[strings.TrimPrefix(name, "^")]
+		if !ok {
+			c.fail(e, "unknown POSIX class "+e.Value)
+			return ranges
+		}
+		return c.appendRanges(ranges, class, negated, fold)
+
+	case OpEscapeClass:
+		class, ok := perlClassRanges[strings.ToLower(e.Args[0].Value)]
+		if !ok {
+			c.fail(e, "unknown class escape "+e.Value)
+			return ranges
+		}
+		return c.appendRanges(ranges, class, e.Negated, fold)
+
+	case OpEscapeUni:
+		return c.appendUnicodeClass(ranges, e, fold)
+	}
+
+	r := c.literalRune(e)
+	return c.appendRanges(ranges, []rune{r, r}, false, fold)
+}
+
+func (c *stdConverter) appendUnicodeClass(ranges []rune, e *Expr, fold bool) []rune {
+	name := strings.TrimPrefix(unicodeClassName(e), "^")
+	negated := e.Negated
+	if name == "Any" {
+		return c.appendRanges(ranges, []rune{0, unicode.MaxRune}, negated, fold)
+	}
+	var table *unicode.RangeTable
+	if e.Form == FormEscapeUniProperty {
+		table = unicodePropertyTable(strings.TrimPrefix(e.Args[0].Value, "^"), e.Args[1].Value)
+	} else {
+		table = unicode.Categories[name]
+		if table == nil {
+			table = unicode.Scripts[name]
+		}
+	}
+	if table == nil {
+		c.fail(e, "unknown Unicode class "+name)
+		return ranges
+	}
+	var class []rune
+	for _, r := range table.R16 {
+		class = appendStrideRange(class, rune(r.Lo), rune(r.Hi), rune(r.Stride))
+	}
+	for _, r := range table.R32 {
+		class = appendStrideRange(class, rune(r.Lo), rune(r.Hi), rune(r.Stride))
+	}
+	return c.appendRanges(ranges, class, negated, fold)
+}
+
+// unicodeClassName returns the class name of the OpEscapeUni e,
+// including the '^' negation prefix: `\pL` => `L`, `\p{^Greek}` => `^Greek`.
+// Property forms are joined back: `\p{sc=Greek}` => `sc=Greek`.
+func unicodeClassName(e *Expr) string {
+	switch e.Form {
+	case FormEscapeUniFull:
+		return e.Args[0].Value
+	case FormEscapeUniProperty:
+		return e.Args[0].Value + "=" + e.Args[1].Value
+	default:
+		// `\pL` and `\PL` forms.
+		v := e.Args[0].Value
+		return v[len(v)-1:]
+	}
+}
+
+// unicodePropertyTable returns a range table for the `name=value`
+// Unicode property or nil if it's not supported.
+// Only the script and general category properties are recognized.
+func unicodePropertyTable(name, value string) *unicode.RangeTable {
+	switch name {
+	case "Script", "sc":
+		return unicode.Scripts[value]
+	case "General_Category", "gc":
+		if short, ok := unicodeCategoryNames[value]; ok {
+			value = short
+		}
+		return unicode.Categories[value]
+	default:
+		return nil
+	}
+}
+
+var perlClassRanges = map[string][]rune{
+	"d": {'0', '9'},
+	"s": {'\t', '\n', '\f', '\r', ' ', ' '},
+	"w": {'0', '9', 'A', 'Z', '_', '_', 'a', 'z'},
+}
+
+// posixClassName returns the OpPosixClass e name: `alpha` for `[:alpha:]`.
+// The negated class names start with '^': `^alpha` for `[:^alpha:]`.
+func posixClassName(e *Expr) string {
+	return strings.TrimSuffix(strings.TrimPrefix(e.Value, "[:"), ":]")
+}
+
+var posixClassRanges = map[string][]rune{
+	"alnum":  {'0', '9', 'A', 'Z', 'a', 'z'},
+	"alpha":  {'A', 'Z', 'a', 'z'},
+	"ascii":  {0, 0x7F},
+	"blank":  {'\t', '\t', ' ', ' '},
+	"cntrl":  {0, 0x1F, 0x7F, 0x7F},
+	"digit":  {'0', '9'},
+	"graph":  {'!', '~'},
+	"lower":  {'a', 'z'},
+	"print":  {' ', '~'},
+	"punct":  {'!', '/', ':', '@', '[', '`', '{', '~'},
+	"space":  {'\t', '\r', ' ', ' '},
+	"upper":  {'A', 'Z'},
+	"word":   {'0', '9', 'A', 'Z', '_', '_', 'a', 'z'},
+	"xdigit": {'0', '9', 'A', 'F', 'a', 'f'},
+}
+
+func appendStrideRange(ranges []rune, lo, hi, stride rune) []rune {
+	if stride == 1 {
+		return append(ranges, lo, hi)
+	}
+	for r := lo; r <= hi; r += stride {
+		ranges = append(ranges, r, r)
+	}
+	return ranges
+}
+
+// appendRanges appends class ranges (or their complement, if negated) to ranges.
+// If fold is set, the case-folding equivalents of the class chars are added
+// before the negation, like regexp/syntax does: `(?i)\W` doesn't match `k`,
+// because `K` (Kelvin sign) is a word char after the folding.
+func (c *stdConverter) appendRanges(ranges, class []rune, negated, fold bool) []rune {
+	switch {
+	case fold && c.asciiFold:
+		class = appendASCIIFoldedRanges(append([]rune(nil), class...))
+	case fold:
+		class = appendFoldedRanges(append([]rune(nil), class...))
+	}
+	if negated {
+		class = negateRanges(cleanRanges(append([]rune(nil), class...)))
+	}
+	return append(ranges, class...)
+}
+
+// appendFoldedRanges adds case-folding equivalents of the ranges chars.
+func appendFoldedRanges(ranges []rune) []rune {
+	n := len(ranges)
+	for i := 0; i < n; i += 2 {
+		lo, hi := ranges[i], ranges[i+1]
+		for r := lo; r <= hi; r++ {
+			for f := unicode.SimpleFold(r); f != r; f = unicode.SimpleFold(f) {
+				ranges = append(ranges, f, f)
+			}
+		}
+	}
+	return ranges
+}
+
+// cleanRanges sorts ranges and merges the overlapping and adjacent ones.
+func cleanRanges(ranges []rune) []rune {
+	pairs := make([][2]rune, 0, len(ranges)/2)
+	for i := 0; i < len(ranges); i += 2 {
+		pairs = append(pairs, [2]rune{ranges[i], ranges[i+1]})
+	}
+	sort.Slice(pairs, func(i, j int) bool {
+		return pairs[i][0] < pairs[j][0]
+	})
+	result := ranges[:0]
+	for _, p := range pairs {
+		n := len(result)
+		if n != 0 && p[0] <= result[n-1]+1 {
+			if p[1] > result[n-1] {
+				result[n-1] = p[1]
+			}
+			continue
+		}
+		result = append(result, p[0], p[1])
+	}
+	return result
+}
+
+// subtractRanges returns the clean ranges of x chars that are not included into y.
+func subtractRanges(x, y []rune) []rune {
+	// x \ y is a complement of (^x | y).
+	complement := negateRanges(cleanRanges(append([]rune(nil), x...)))
+	return negateRanges(cleanRanges(append(complement, y...)))
+}
+
+// negateRanges returns a complement of the clean ranges.
+func negateRanges(ranges []rune) []rune {
+	var result []rune
+	next := rune(0)
+	for i := 0; i < len(ranges); i += 2 {
+		if ranges[i] > next {
+			result = append(result, next, ranges[i]-1)
+		}
+		next = ranges[i+1] + 1
+	}
+	if next <= unicode.MaxRune {
+		result = append(result, next, unicode.MaxRune)
+	}
+	return result
+}
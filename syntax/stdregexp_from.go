@@ -0,0 +1,196 @@
+package syntax
+
+import (
+	stdsyntax "regexp/syntax"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// FromStdRegexp builds this package AST from the regexp/syntax tree.
+//
+// The tree is printed into a pattern that is then parsed with
+// the default parser options, so the resulting Regexp has
+// a synthetic Pattern and the positions that refer to it.
+// Case-insensitive literals are wrapped into `(?i:re)` groups and
+// line anchors are wrapped into `(?m:re)` groups.
+func FromStdRegexp(re *stdsyntax.Regexp) (*Regexp, error) {
+	e, err := fromStdExpr(re)
+	if err != nil {
+		return nil, err
+	}
+	pattern := Print(&Regexp{Expr: e})
+	return NewParser(nil).Parse(pattern)
+}
+
+func fromStdExpr(re *stdsyntax.Regexp) (Expr, error) {
+	switch re.Op {
+	case stdsyntax.OpNoMatch:
+		return parseTemplate(`[^\x00-\x{10FFFF}]`), nil
+	case stdsyntax.OpEmptyMatch:
+		return Expr{Op: OpConcat}, nil
+
+	case stdsyntax.OpLiteral:
+		lit := Expr{Op: OpLiteral}
+		for _, r := range re.Rune {
+			lit.Args = append(lit.Args, stdLiteralChar(r, false))
+		}
+		if len(lit.Args) == 1 {
+			lit = lit.Args[0]
+		}
+		if re.Flags&stdsyntax.FoldCase != 0 {
+			return newFlagsGroup("i", lit), nil
+		}
+		return lit, nil
+
+	case stdsyntax.OpCharClass:
+		return stdCharClass(re.Rune), nil
+
+	case stdsyntax.OpAnyCharNotNL:
+		return Expr{Op: OpDot, Value: "."}, nil
+	case stdsyntax.OpAnyChar:
+		return newFlagsGroup("s", Expr{Op: OpDot, Value: "."}), nil
+	case stdsyntax.OpBeginLine:
+		return newFlagsGroup("m", Expr{Op: OpCaret, Value: "^"}), nil
+	case stdsyntax.OpEndLine:
+		return newFlagsGroup("m", Expr{Op: OpDollar, Value: "$"}), nil
+	case stdsyntax.OpBeginText:
+		return Expr{Op: OpCaret, Value: "^"}, nil
+	case stdsyntax.OpEndText:
+		if re.Flags&stdsyntax.WasDollar != 0 {
+			return Expr{Op: OpDollar, Value: "$"}, nil
+		}
+		return parseTemplate(`\z`), nil
+	case stdsyntax.OpWordBoundary:
+		return parseTemplate(`\b`), nil
+	case stdsyntax.OpNoWordBoundary:
+		return parseTemplate(`\B`), nil
+
+	case stdsyntax.OpCapture:
+		sub, err := fromStdExpr(re.Sub[0])
+		if err != nil {
+			return Expr{}, err
+		}
+		if re.Name != "" {
+			return Expr{Op: OpNamedCapture, Args: []Expr{sub, {Op: OpString, Value: re.Name}}}, nil
+		}
+		return Expr{Op: OpCapture, Args: []Expr{sub}}, nil
+
+	case stdsyntax.OpStar, stdsyntax.OpPlus, stdsyntax.OpQuest, stdsyntax.OpRepeat:
+		sub, err := fromStdExpr(re.Sub[0])
+		if err != nil {
+			return Expr{}, err
+		}
+		if sub.IsQuantifier() {
+			// `a**` is not a valid RE2 syntax.
+			sub = Expr{Op: OpGroup, Args: []Expr{sub}}
+		}
+		var e Expr
+		switch re.Op {
+		case stdsyntax.OpStar:
+			e = Expr{Op: OpStar, Args: []Expr{sub}}
+		case stdsyntax.OpPlus:
+			e = Expr{Op: OpPlus, Args: []Expr{sub}}
+		case stdsyntax.OpQuest:
+			e = Expr{Op: OpQuestion, Args: []Expr{sub}}
+		case stdsyntax.OpRepeat:
+			e = Expr{Op: OpRepeat, Args: []Expr{sub, {Op: OpString, Value: stdRepeatCount(re.Min, re.Max)}}}
+		}
+		if re.Flags&stdsyntax.NonGreedy != 0 {
+			e = Expr{Op: OpNonGreedy, Args: []Expr{e}}
+		}
+		return e, nil
+
+	case stdsyntax.OpConcat, stdsyntax.OpAlternate:
+		e := Expr{Op: OpConcat}
+		if re.Op == stdsyntax.OpAlternate {
+			e.Op = OpAlt
+		}
+		for _, sub := range re.Sub {
+			arg, err := fromStdExpr(sub)
+			if err != nil {
+				return Expr{}, err
+			}
+			e.Args = append(e.Args, arg)
+		}
+		return e, nil
+	}
+
+	return Expr{}, ParseError{
+		Code:    ErrUnsupported,
+		Message: "unexpected regexp/syntax op " + re.Op.String(),
+	}
+}
+
+func newFlagsGroup(flags string, e Expr) Expr {
+	return Expr{
+		Op:   OpGroupWithFlags,
+		Args: []Expr{e, {Op: OpString, Value: flags}},
+	}
+}
+
+func stdRepeatCount(min, max int) string {
+	switch {
+	case max == -1:
+		return "{" + strconv.Itoa(min) + ",}"
+	case min == max:
+		return "{" + strconv.Itoa(min) + "}"
+	default:
+		return "{" + strconv.Itoa(min) + "," + strconv.Itoa(max) + "}"
+	}
+}
+
+// stdCharClass converts sorted rune ranges into a char class.
+// Classes that include both 0 and unicode.MaxRune are printed as negated.
+func stdCharClass(ranges []rune) Expr {
+	if len(ranges) == 0 {
+		return parseTemplate(`[^\x00-\x{10FFFF}]`)
+	}
+	class := Expr{Op: OpCharClass}
+	if ranges[0] == 0 && ranges[len(ranges)-1] == unicode.MaxRune {
+		class.Op = OpNegCharClass
+		ranges = negateRanges(ranges)
+	}
+	class.Args = appendRangeExprs(class.Args, ranges)
+	if len(class.Args) == 0 {
+		// Negated full range: matches any char.
+		return parseTemplate(`[\x00-\x{10FFFF}]`)
+	}
+	return class
+}
+
+// appendRangeExprs appends char class elements that match the ranges to args.
+func appendRangeExprs(args []Expr, ranges []rune) []Expr {
+	for i := 0; i < len(ranges); i += 2 {
+		lo, hi := ranges[i], ranges[i+1]
+		switch {
+		case lo == hi:
+			args = append(args, stdLiteralChar(lo, true))
+		case lo+1 == hi:
+			args = append(args, stdLiteralChar(lo, true), stdLiteralChar(hi, true))
+		default:
+			args = append(args, Expr{
+				Op:   OpCharRange,
+				Args: []Expr{stdLiteralChar(lo, true), stdLiteralChar(hi, true)},
+			})
+		}
+	}
+	return args
+}
+
+// stdLiteralChar returns an expression that matches r.
+// Meta chars and non-printable chars are escaped.
+func stdLiteralChar(r rune, inClass bool) Expr {
+	if !unicode.IsPrint(r) || r == ' ' && inClass {
+		return Expr{
+			Op:   OpEscapeHex,
+			Form: FormEscapeHexFull,
+			Args: []Expr{{Op: OpString, Value: strings.ToUpper(strconv.FormatInt(int64(r), 16))}},
+		}
+	}
+	s := string(r)
+	if r < 0x80 && (reMetachar[r] || charClassMetachar[r] || r == '{' || r == '}') {
+		return Expr{Op: OpEscapeMeta, Args: []Expr{{Op: OpString, Value: s}}}
+	}
+	return Expr{Op: OpChar, Value: s}
+}
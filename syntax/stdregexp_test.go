@@ -0,0 +1,211 @@
+package syntax
+
+import (
+	"reflect"
+	"regexp"
+	stdsyntax "regexp/syntax"
+	"testing"
+)
+
+func TestToStdRegexp(t *testing.T) {
+	patterns := []string{
+		``,
+		`abc`,
+		`a|bc|`,
+		`^a.b$`,
+		`(?m)^a$`,
+		`(?s)a.b`,
+		`(?i)straße`,
+		`(?i:Ab)c`,
+		`x*y+?z??a{2}b{2,}?c{1,3}`,
+		`(?U)a+b+?`,
+		`(a)(?P<name>b)(?:c)`,
+		`[a-c\d]+[^x-z]`,
+		`[^\n]`,
+		`(?i)[a-c]`,
+		`\d\D\s\S\w\W\b\B\A\z`,
+		`[[:alpha:][:^digit:]]`,
+		`\pL\PL\p{Greek}\P{Greek}\p{^Greek}`,
+		`\x41\x{1F600}\101\0\t\n\.`,
+		`\Qa.b\E`,
+		`(?i)a(?-i)b`,
+	}
+	inputs := []string{
+		"", "a", "abc", "a\nb", "axb", "STRASSE", "straße", "ABc", "abC",
+		"xyyzaabbbccc", "aaabbb", "abc", "cz", "\n", "B", "7 x_", "Ωλ",
+		"A😀A\x00\t\n.", "a.b", "ab", "Ab", "aB",
+	}
+
+	for _, pattern := range patterns {
+		re, err := NewParser(nil).Parse(pattern)
+		if err != nil {
+			t.Fatalf("parse(%q): %v", pattern, err)
+		}
+		converted, err := ToStdRegexp(re, stdsyntax.Perl)
+		if err != nil {
+			t.Errorf("convert(%q): %v", pattern, err)
+			continue
+		}
+		have, err := regexp.Compile(converted.String())
+		if err != nil {
+			t.Errorf("convert(%q): compile %q: %v", pattern, converted, err)
+			continue
+		}
+		want := regexp.MustCompile(pattern)
+		for _, input := range inputs {
+			h := have.FindAllStringSubmatchIndex(input, -1)
+			w := want.FindAllStringSubmatchIndex(input, -1)
+			if !reflect.DeepEqual(h, w) {
+				t.Errorf("convert(%q) match %q:\nhave: %v\nwant: %v", pattern, input, h, w)
+			}
+		}
+		if have.NumSubexp() != want.NumSubexp() {
+			t.Errorf("convert(%q): captures mismatch", pattern)
+		}
+	}
+}
+
+func TestToStdRegexpFoldedClasses(t *testing.T) {
+	patterns := []string{
+		`(?i)\W`,
+		`(?i)\P{Lu}`,
+		`(?i)[\Wk]`,
+		`(?i)[^\W]`,
+		`(?i)[[:^alpha:]]`,
+		`(?i)[^a-z\PL]`,
+		`(?i)\p{^Greek}`,
+	}
+
+	for _, pattern := range patterns {
+		re, err := NewParser(nil).Parse(pattern)
+		if err != nil {
+			t.Fatalf("parse(%q): %v", pattern, err)
+		}
+		have, err := ToStdRegexp(re, stdsyntax.Perl)
+		if err != nil {
+			t.Errorf("convert(%q): %v", pattern, err)
+			continue
+		}
+		want, err := stdsyntax.Parse(pattern, stdsyntax.Perl)
+		if err != nil {
+			t.Fatalf("std parse(%q): %v", pattern, err)
+		}
+		if have.Op != want.Op || !reflect.DeepEqual(have.Rune, want.Rune) {
+			t.Errorf("convert(%q):\nhave: %s %v\nwant: %s %v", pattern, have.Op, have.Rune, want.Op, want.Rune)
+		}
+	}
+}
+
+func TestToStdRegexpFlags(t *testing.T) {
+	re, err := NewParser(nil).ParseFlags(`a b(?-i)c`, "ix")
+	if err != nil {
+		t.Fatal(err)
+	}
+	converted, err := ToStdRegexp(re, stdsyntax.Perl)
+	if err != nil {
+		t.Fatal(err)
+	}
+	have := regexp.MustCompile(converted.String())
+	want := regexp.MustCompile(`(?i)ab(?-i)c`)
+	for _, input := range []string{"abc", "ABc", "AbC", "a bc"} {
+		if have.MatchString(input) != want.MatchString(input) {
+			t.Errorf("match %q: have %v, want %v", input, have.MatchString(input), want.MatchString(input))
+		}
+	}
+}
+
+func TestToStdRegexpComments(t *testing.T) {
+	re, err := NewParser(&ParserOptions{FreeSpacing: true}).Parse("a (?#comment) b # c")
+	if err != nil {
+		t.Fatal(err)
+	}
+	converted, err := ToStdRegexp(re, stdsyntax.Perl)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if have := converted.String(); have != "ab" {
+		t.Errorf("convert: have %q, want %q", have, "ab")
+	}
+}
+
+func TestToStdRegexpErrors(t *testing.T) {
+	tests := []struct {
+		pattern string
+		want    string
+	}{
+		{`a(?=b)`, `PositiveLookahead is not supported by regexp/syntax`},
+		{`a++`, `Possessive is not supported by regexp/syntax`},
+		{`(a)\1`, `backreferences are not supported by regexp/syntax`},
+		{`a{1001}`, `invalid repeat count`},
+		{`\p{Zz}`, `unknown Unicode class Zz`},
+		{`\p{Block=Greek}`, `unknown Unicode class Block=Greek`},
+	}
+
+	for _, test := range tests {
+		re, err := NewParser(nil).Parse(test.pattern)
+		if err != nil {
+			t.Fatalf("parse(%q): %v", test.pattern, err)
+		}
+		_, err = ToStdRegexp(re, stdsyntax.Perl)
+		if err == nil || err.Error() != test.want {
+			t.Errorf("convert(%q): have %v, want %s", test.pattern, err, test.want)
+		}
+	}
+}
+
+func TestFromStdRegexp(t *testing.T) {
+	patterns := []string{
+		``,
+		`abc`,
+		`a|bc|`,
+		`^a.b$`,
+		`(?m)^a$`,
+		`(?s)a.b`,
+		`(?i)straße`,
+		`x*y+?z??a{2}b{2,}?c{1,3}`,
+		`(a)(?P<name>b)(?:c)`,
+		`[a-c\d]+[^x-z]`,
+		`[^\n]`,
+		`[\^\]\-\\ ]`,
+		`\d\D\s\S\w\W\b\B\A\z`,
+		`\x{1F600}\x00\t\n\.\{\}a{2}`,
+		`(?:a+)*`,
+		`[^\x00-\x{10FFFF}]`,
+		`[\x00-\x{10FFFF}]`,
+	}
+	inputs := []string{
+		"", "a", "abc", "a\nb", "axb", "STRASSE", "straße", "ABc",
+		"xyyzaabbbccc", "aaabbb", "cz", "\n", "^]- \\", "7 x_",
+		"😀\x00\t\n.{}aa", "aaa",
+	}
+
+	for _, pattern := range patterns {
+		stdre, err := stdsyntax.Parse(pattern, stdsyntax.Perl)
+		if err != nil {
+			t.Fatalf("parse(%q): %v", pattern, err)
+		}
+		re, err := FromStdRegexp(stdre)
+		if err != nil {
+			t.Errorf("convert(%q): %v", pattern, err)
+			continue
+		}
+		if re.Pattern != Print(re) {
+			t.Errorf("convert(%q): pattern %q doesn't match the printed tree %q",
+				pattern, re.Pattern, Print(re))
+		}
+		have, err := regexp.Compile(re.Pattern)
+		if err != nil {
+			t.Errorf("convert(%q): compile %q: %v", pattern, re.Pattern, err)
+			continue
+		}
+		want := regexp.MustCompile(pattern)
+		for _, input := range inputs {
+			h := have.FindAllStringSubmatchIndex(input, -1)
+			w := want.FindAllStringSubmatchIndex(input, -1)
+			if !reflect.DeepEqual(h, w) {
+				t.Errorf("convert(%q) => %q match %q:\nhave: %v\nwant: %v",
+					pattern, re.Pattern, input, h, w)
+			}
+		}
+	}
+}
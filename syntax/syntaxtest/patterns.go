@@ -0,0 +1,187 @@
+// Package syntaxtest provides helpers for testing the regexp parsers.
+package syntaxtest
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+
+	"github.com/quasilyte/regex/syntax"
+)
+
+// PatternGenerator produces random patterns that are accepted by
+// the syntax package parser for the selected dialect.
+//
+// The patterns are intended for the differential fuzzing of the
+// regexp engines and parsers, like regexp/syntax for DialectRE2.
+type PatternGenerator struct {
+	rand    *rand.Rand
+	dialect syntax.Dialect
+	parser  *syntax.Parser
+
+	atoms       []construct
+	groups      []construct
+	quantifiers []string
+
+	// MaxDepth limits the groups nesting; defaults to 3.
+	MaxDepth int
+
+	numNames int
+}
+
+// construct is a pattern building block.
+// Groups text contains %s for the enclosed pattern.
+type construct struct {
+	text         string
+	quantifiable bool
+}
+
+// allAtoms is a list of the atoms that are checked against the dialect.
+var allAtoms = []construct{
+	{`a`, true},
+	{`b`, true},
+	{`xyz`, false},
+	{`0`, true},
+	{`.`, true},
+	{`\.`, true},
+	{`\*`, true},
+	{`\(`, true},
+	{`\x41`, true},
+	{`\x{263a}`, true},
+	{`A`, true},
+	{`\t`, true},
+	{`\n`, true},
+	{`\d`, true},
+	{`\D`, true},
+	{`\w`, true},
+	{`\W`, true},
+	{`\s`, true},
+	{`\S`, true},
+	{`\pL`, true},
+	{`\p{Greek}`, true},
+	{`\PN`, true},
+	{`[abc]`, true},
+	{`[^a-z]`, true},
+	{`[a-z0-9_]`, true},
+	{`[\d\s.]`, true},
+	{`[[:alpha:]]`, true},
+	{`[^[:digit:]x]`, true},
+	{`\Qa.b\E`, false},
+	{`^`, false},
+	{`$`, false},
+	{`\b`, false},
+	{`\B`, false},
+	{`\A`, false},
+	{`\z`, false},
+	{`(?i)`, false},
+	{`(?s-i)`, false},
+	{`(?#comment)`, false},
+}
+
+// allGroups is a list of the group templates that are checked against the dialect.
+var allGroups = []construct{
+	{`(%s)`, true},
+	{`(?:%s)`, true},
+	{`(?P<NAME>%s)`, true},
+	{`(?<NAME>%s)`, true},
+	{`(?'NAME'%s)`, true},
+	{`(?i:%s)`, true},
+	{`(?s-i:%s)`, true},
+	{`(?>%s)`, true},
+	{`(?=%s)`, false},
+	{`(?!%s)`, false},
+	{`(?<=%s)`, false},
+	{`(?<!%s)`, false},
+}
+
+// allQuantifiers is a list of the quantifiers that are checked against the dialect.
+var allQuantifiers = []string{
+	`*`, `+`, `?`, `{2}`, `{1,3}`, `{2,}`,
+	`*?`, `+?`, `??`, `{1,3}?`,
+	`*+`, `++`, `?+`,
+}
+
+// NewPatternGenerator creates a generator for the dialect patterns.
+// If r is nil, a source with a fixed seed is used.
+func NewPatternGenerator(dialect syntax.Dialect, r *rand.Rand) *PatternGenerator {
+	if r == nil {
+		r = rand.New(rand.NewSource(1))
+	}
+	g := &PatternGenerator{
+		rand:     r,
+		dialect:  dialect,
+		parser:   syntax.NewParser(&syntax.ParserOptions{Dialect: dialect}),
+		MaxDepth: 3,
+	}
+	for _, atom := range allAtoms {
+		if g.accepts(atom.text) {
+			g.atoms = append(g.atoms, atom)
+		}
+	}
+	for _, group := range allGroups {
+		if g.accepts(strings.ReplaceAll(fmt.Sprintf(group.text, "a"), "NAME", "x")) {
+			g.groups = append(g.groups, group)
+		}
+	}
+	for _, q := range allQuantifiers {
+		if g.accepts("a" + q) {
+			g.quantifiers = append(g.quantifiers, q)
+		}
+	}
+	return g
+}
+
+// Generate returns a random pattern that is accepted by the dialect parser.
+func (g *PatternGenerator) Generate() string {
+	// Some combinations are not permitted by the dialect,
+	// like the variable-width lookbehinds in PCRE.
+	for attempt := 0; attempt < 100; attempt++ {
+		g.numNames = 0
+		pattern := g.alternation(0)
+		if g.accepts(pattern) {
+			return pattern
+		}
+	}
+	return g.atoms[0].text
+}
+
+func (g *PatternGenerator) accepts(pattern string) bool {
+	_, err := g.parser.Parse(pattern)
+	return err == nil
+}
+
+func (g *PatternGenerator) alternation(depth int) string {
+	branches := []string{g.concat(depth)}
+	for g.rand.Intn(4) == 0 {
+		branches = append(branches, g.concat(depth))
+	}
+	return strings.Join(branches, "|")
+}
+
+func (g *PatternGenerator) concat(depth int) string {
+	var b strings.Builder
+	n := 1 + g.rand.Intn(4)
+	for i := 0; i < n; i++ {
+		b.WriteString(g.term(depth))
+	}
+	return b.String()
+}
+
+func (g *PatternGenerator) term(depth int) string {
+	var c construct
+	if depth < g.MaxDepth && len(g.groups) != 0 && g.rand.Intn(4) == 0 {
+		group := g.groups[g.rand.Intn(len(g.groups))]
+		text := fmt.Sprintf(group.text, g.alternation(depth+1))
+		if strings.Contains(group.text, "NAME") {
+			g.numNames++
+			text = strings.Replace(text, "NAME", fmt.Sprintf("g%d", g.numNames), 1)
+		}
+		c = construct{text: text, quantifiable: group.quantifiable}
+	} else {
+		c = g.atoms[g.rand.Intn(len(g.atoms))]
+	}
+	if c.quantifiable && len(g.quantifiers) != 0 && g.rand.Intn(3) == 0 {
+		return c.text + g.quantifiers[g.rand.Intn(len(g.quantifiers))]
+	}
+	return c.text
+}
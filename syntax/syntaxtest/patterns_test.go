@@ -0,0 +1,50 @@
+package syntaxtest
+
+import (
+	"math/rand"
+	stdsyntax "regexp/syntax"
+	"testing"
+
+	"github.com/quasilyte/regex/syntax"
+)
+
+func TestPatternGenerator(t *testing.T) {
+	dialects := []syntax.Dialect{
+		syntax.DialectDefault,
+		syntax.DialectRE2,
+		syntax.DialectPCRE,
+		syntax.DialectPCRE2,
+		syntax.DialectECMAScript,
+		syntax.DialectPython,
+		syntax.DialectJava,
+		syntax.DialectDotNet,
+		syntax.DialectOnig,
+	}
+
+	for _, dialect := range dialects {
+		g := NewPatternGenerator(dialect, rand.New(rand.NewSource(1)))
+		p := syntax.NewParser(&syntax.ParserOptions{Dialect: dialect})
+		for i := 0; i < 200; i++ {
+			pattern := g.Generate()
+			re, err := p.Parse(pattern)
+			if err != nil {
+				t.Errorf("%s: parse(%q): %v", dialect, pattern, err)
+				continue
+			}
+			if have := syntax.Print(re); have != pattern {
+				t.Errorf("%s: print(%q): have %q", dialect, pattern, have)
+			}
+		}
+	}
+}
+
+func TestPatternGeneratorStdlib(t *testing.T) {
+	// Every pattern that is accepted as RE2 should be accepted by regexp/syntax as well.
+	g := NewPatternGenerator(syntax.DialectRE2, rand.New(rand.NewSource(1)))
+	for i := 0; i < 500; i++ {
+		pattern := g.Generate()
+		if _, err := stdsyntax.Parse(pattern, stdsyntax.Perl); err != nil {
+			t.Errorf("std parse(%q): %v", pattern, err)
+		}
+	}
+}
@@ -0,0 +1,165 @@
+package syntax
+
+// TokenKind is a coarse-grained lexical class of a pattern token.
+//
+// Token kinds are intended for syntax highlighting and similar tools
+// that don't need the parsed AST.
+type TokenKind byte
+
+//go:generate stringer -type=TokenKind -trimprefix=Token -output=token_string.go
+const (
+	TokenNone TokenKind = iota
+
+	// TokenChar is a single literal char, like `a`.
+	TokenChar
+
+	// TokenEscape is an escape sequence, like `\d`, `\x41`, `\u00E9`, `\p{L}` or `\g<name>`.
+	TokenEscape
+
+	// TokenPosixClass is a named class inside brackets, like `[:alpha:]`.
+	TokenPosixClass
+
+	// TokenQuantifier is `*`, `+`, `?` or a `{n,m}` repetition.
+	TokenQuantifier
+
+	// TokenDot is a `.` metachar.
+	TokenDot
+
+	// TokenAnchor is `^` or `$`.
+	TokenAnchor
+
+	// TokenAlternation is a `|` metachar.
+	TokenAlternation
+
+	// TokenClassOpen is `[` or `[^`.
+	TokenClassOpen
+
+	// TokenClassClose is `]`.
+	TokenClassClose
+
+	// TokenRange is a `-` char range separator inside brackets.
+	TokenRange
+
+	// TokenGroupOpen is any group start, like `(`, `(?:`, `(?i:`, `(?P<name>` or `(?=`.
+	TokenGroupOpen
+
+	// TokenGroupClose is `)`.
+	TokenGroupClose
+
+	// TokenLookaroundPostfix is a Vim lookaround postfix, like `\@=`.
+	TokenLookaroundPostfix
+
+	// TokenQuote is a `\Q...\E` quoted literal.
+	TokenQuote
+
+	// TokenComment is a `(?#...)` comment or a free-spacing mode `#` comment.
+	TokenComment
+
+	// TokenBad is an invalid pattern part.
+	// Only produced when ParserOptions.Recover is set.
+	TokenBad
+)
+
+// Token is a lexical pattern element.
+type Token struct {
+	Kind TokenKind
+	Pos  Position
+}
+
+// Tokenize splits pattern into tokens using the default parser options.
+//
+// See Parser.Tokenize for details.
+func Tokenize(pattern string) ([]Token, error) {
+	return NewParser(nil).Tokenize(pattern)
+}
+
+// Tokenize splits pattern into tokens without building an AST.
+//
+// Dialect and FreeSpacing options are respected. Only lexical errors
+// are reported, so a nil error doesn't mean that the pattern is valid:
+// `a)` is tokenized successfully, but Parse rejects it.
+//
+// When ParserOptions.Recover is set, invalid pattern parts are
+// returned as TokenBad tokens and all errors are collected into ErrorList.
+func (p *Parser) Tokenize(pattern string) (tokens []Token, err error) {
+	defer p.catchError(&err)
+
+	if err := p.checkPatternLen(pattern); err != nil {
+		return nil, err
+	}
+
+	p.out.Pattern = pattern
+	p.lexer.Init(pattern)
+	tokens = make([]Token, 0, len(p.lexer.tokens))
+	for _, tok := range p.lexer.tokens {
+		// The quantifier trivia is reported in the source order.
+		for _, trivia := range p.lexer.quantifierTrivia[tok.pos.Begin] {
+			tokens = append(tokens, Token{Kind: TokenComment, Pos: trivia.pos})
+		}
+		kind := tokenKinds[tok.kind]
+		if kind == TokenNone {
+			continue
+		}
+		tokens = append(tokens, Token{Kind: kind, Pos: tok.pos})
+	}
+
+	if len(p.lexer.errors) != 0 {
+		errors := make(ErrorList, len(p.lexer.errors))
+		for i, e := range p.lexer.errors {
+			e.Text = p.errorText(e.Pos)
+			errors[i] = e
+		}
+		return tokens, errors
+	}
+	return tokens, nil
+}
+
+var tokenKinds = [...]TokenKind{
+	tokChar:                      TokenChar,
+	tokPosixClass:                TokenPosixClass,
+	tokRepeat:                    TokenQuantifier,
+	tokEscapeChar:                TokenEscape,
+	tokEscapeMeta:                TokenEscape,
+	tokEscapeOctal:               TokenEscape,
+	tokEscapeOctalFull:           TokenEscape,
+	tokEscapeUni:                 TokenEscape,
+	tokEscapeUniFull:             TokenEscape,
+	tokEscapeHex:                 TokenEscape,
+	tokEscapeHexFull:             TokenEscape,
+	tokEscapeUnicode:             TokenEscape,
+	tokEscapeUnicodeFull:         TokenEscape,
+	tokSubroutineCall:            TokenEscape,
+	tokSubroutineCallQuote:       TokenEscape,
+	tokComment:                   TokenComment,
+	tokBad:                       TokenBad,
+	tokQ:                         TokenQuote,
+	tokMinus:                     TokenRange,
+	tokLbracket:                  TokenClassOpen,
+	tokLbracketCaret:             TokenClassOpen,
+	tokRbracket:                  TokenClassClose,
+	tokDollar:                    TokenAnchor,
+	tokCaret:                     TokenAnchor,
+	tokQuestion:                  TokenQuantifier,
+	tokDot:                       TokenDot,
+	tokPlus:                      TokenQuantifier,
+	tokStar:                      TokenQuantifier,
+	tokPipe:                      TokenAlternation,
+	tokLparen:                    TokenGroupOpen,
+	tokLparenName:                TokenGroupOpen,
+	tokLparenNameAngle:           TokenGroupOpen,
+	tokLparenNameQuote:           TokenGroupOpen,
+	tokLparenFlags:               TokenGroupOpen,
+	tokLparenAtomic:              TokenGroupOpen,
+	tokLparenPositiveLookahead:   TokenGroupOpen,
+	tokLparenPositiveLookbehind:  TokenGroupOpen,
+	tokLparenNegativeLookahead:   TokenGroupOpen,
+	tokLparenNegativeLookbehind:  TokenGroupOpen,
+	tokLparenAbsent:              TokenGroupOpen,
+	tokLparenGroup:               TokenGroupOpen,
+	tokRparen:                    TokenGroupClose,
+	tokPositiveLookaheadPostfix:  TokenLookaroundPostfix,
+	tokNegativeLookaheadPostfix:  TokenLookaroundPostfix,
+	tokPositiveLookbehindPostfix: TokenLookaroundPostfix,
+	tokNegativeLookbehindPostfix: TokenLookaroundPostfix,
+	tokAtomicPostfix:             TokenLookaroundPostfix,
+}
@@ -0,0 +1,74 @@
+package syntax
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestTokenize(t *testing.T) {
+	tests := []struct {
+		pattern string
+		opts    ParserOptions
+		want    string
+	}{
+		{``, ParserOptions{}, ``},
+		{`ab`, ParserOptions{}, `Char"a" Char"b"`},
+		{`^a.b*$`, ParserOptions{}, `Anchor"^" Char"a" Dot"." Char"b" Quantifier"*" Anchor"$"`},
+		{`x{1,2}?|y+`, ParserOptions{}, `Char"x" Quantifier"{1,2}" Quantifier"?" Alternation"|" Char"y" Quantifier"+"`},
+		{`(?P<n>x)(?i:y)`, ParserOptions{}, `GroupOpen"(?P<n>" Char"x" GroupClose")" GroupOpen"(?i:" Char"y" GroupClose")"`},
+		{`[^a-z[:digit:]]`, ParserOptions{}, `ClassOpen"[^" Char"a" Range"-" Char"z" PosixClass"[:digit:]" ClassClose"]"`},
+		{`\d\x41\p{L}`, ParserOptions{}, `Escape"\d" Escape"\x41" Escape"\p{L}"`},
+		{`\Qa.b\E(?#c)`, ParserOptions{}, `Quote"\Qa.b\E" Comment"(?#c)"`},
+		{`a)`, ParserOptions{}, `Char"a" GroupClose")"`},
+
+		{`a # b`, ParserOptions{FreeSpacing: true}, `Char"a" Comment" # b"`},
+		{`a + b`, ParserOptions{FreeSpacing: true}, `Char"a" Comment" " Quantifier"+" Comment" " Char"b"`},
+		{`\(a\)\@=`, ParserOptions{Dialect: DialectVim}, `GroupOpen"\(" Char"a" GroupClose"\)" LookaroundPostfix"\@="`},
+		{`a\{1,2\}`, ParserOptions{Dialect: DialectPOSIXBasic}, `Char"a" Quantifier"\{1,2\}"`},
+		{`a\x{1`, ParserOptions{Recover: true}, `Char"a" Bad"\x" Char"{" Char"1"`},
+	}
+
+	for _, test := range tests {
+		p := NewParser(&test.opts)
+		tokens, err := p.Tokenize(test.pattern)
+		if err != nil && !test.opts.Recover {
+			t.Errorf("tokenize(%q): unexpected error: %v", test.pattern, err)
+			continue
+		}
+		parts := make([]string, len(tokens))
+		for i, tok := range tokens {
+			text := test.pattern[tok.Pos.Begin:tok.Pos.End]
+			parts[i] = fmt.Sprintf("%s%q", tok.Kind, text)
+		}
+		have := strings.Join(parts, " ")
+		want := strings.ReplaceAll(test.want, `\`, `\\`)
+		if have != want {
+			t.Errorf("tokenize(%q):\nhave: %s\nwant: %s", test.pattern, have, want)
+		}
+	}
+}
+
+func TestTokenizeError(t *testing.T) {
+	_, err := Tokenize(`ab\`)
+	perr, ok := err.(ParseError)
+	if !ok {
+		t.Fatalf("expected ParseError, got %T", err)
+	}
+	if perr.Code != ErrTrailingBackslash || perr.Text != `\` {
+		t.Errorf("unexpected error: %+v", perr)
+	}
+
+	p := NewParser(&ParserOptions{Recover: true})
+	tokens, err := p.Tokenize(`[a\x{`)
+	list, ok := err.(ErrorList)
+	if !ok || len(list) != 1 {
+		t.Fatalf("expected 1 error, got %v", err)
+	}
+	if list[0].Code != ErrUnterminatedEscape {
+		t.Errorf("unexpected error code: %s", list[0].Code)
+	}
+	if len(tokens) != 4 || tokens[2].Kind != TokenBad {
+		t.Errorf("unexpected tokens: %v", tokens)
+	}
+}
@@ -17,39 +17,52 @@ func _() {
 	_ = x[tokEscapeChar-6]
 	_ = x[tokEscapeMeta-7]
 	_ = x[tokEscapeOctal-8]
-	_ = x[tokEscapeUni-9]
-	_ = x[tokEscapeUniFull-10]
-	_ = x[tokEscapeHex-11]
-	_ = x[tokEscapeHexFull-12]
-	_ = x[tokComment-13]
-	_ = x[tokQ-14]
-	_ = x[tokMinus-15]
-	_ = x[tokLbracket-16]
-	_ = x[tokLbracketCaret-17]
-	_ = x[tokRbracket-18]
-	_ = x[tokDollar-19]
-	_ = x[tokCaret-20]
-	_ = x[tokQuestion-21]
-	_ = x[tokDot-22]
-	_ = x[tokPlus-23]
-	_ = x[tokStar-24]
-	_ = x[tokPipe-25]
-	_ = x[tokLparen-26]
-	_ = x[tokLparenName-27]
-	_ = x[tokLparenNameAngle-28]
-	_ = x[tokLparenNameQuote-29]
-	_ = x[tokLparenFlags-30]
-	_ = x[tokLparenAtomic-31]
-	_ = x[tokLparenPositiveLookahead-32]
-	_ = x[tokLparenPositiveLookbehind-33]
-	_ = x[tokLparenNegativeLookahead-34]
-	_ = x[tokLparenNegativeLookbehind-35]
-	_ = x[tokRparen-36]
+	_ = x[tokEscapeOctalFull-9]
+	_ = x[tokEscapeUni-10]
+	_ = x[tokEscapeUniFull-11]
+	_ = x[tokEscapeHex-12]
+	_ = x[tokEscapeHexFull-13]
+	_ = x[tokSubroutineCall-14]
+	_ = x[tokSubroutineCallQuote-15]
+	_ = x[tokComment-16]
+	_ = x[tokBad-17]
+	_ = x[tokEscapeUnicode-18]
+	_ = x[tokEscapeUnicodeFull-19]
+	_ = x[tokQ-20]
+	_ = x[tokMinus-21]
+	_ = x[tokLbracket-22]
+	_ = x[tokLbracketCaret-23]
+	_ = x[tokRbracket-24]
+	_ = x[tokDollar-25]
+	_ = x[tokCaret-26]
+	_ = x[tokQuestion-27]
+	_ = x[tokDot-28]
+	_ = x[tokPlus-29]
+	_ = x[tokStar-30]
+	_ = x[tokPipe-31]
+	_ = x[tokLparen-32]
+	_ = x[tokLparenName-33]
+	_ = x[tokLparenNameAngle-34]
+	_ = x[tokLparenNameQuote-35]
+	_ = x[tokLparenFlags-36]
+	_ = x[tokLparenAtomic-37]
+	_ = x[tokLparenPositiveLookahead-38]
+	_ = x[tokLparenPositiveLookbehind-39]
+	_ = x[tokLparenNegativeLookahead-40]
+	_ = x[tokLparenNegativeLookbehind-41]
+	_ = x[tokLparenAbsent-42]
+	_ = x[tokLparenGroup-43]
+	_ = x[tokRparen-44]
+	_ = x[tokPositiveLookaheadPostfix-45]
+	_ = x[tokNegativeLookaheadPostfix-46]
+	_ = x[tokPositiveLookbehindPostfix-47]
+	_ = x[tokNegativeLookbehindPostfix-48]
+	_ = x[tokAtomicPostfix-49]
 }
 
-const _tokenKind_name = "NoneCharGroupFlagsPosixClassConcatRepeatEscapeCharEscapeMetaEscapeOctalEscapeUniEscapeUniFullEscapeHexEscapeHexFullComment\\Q-[[^]$^?.+*|((?P<name>(?<name>(?'name'(?flags(?>(?=(?<=(?!(?<!)"
+const _tokenKind_name = "NoneCharGroupFlagsPosixClassConcatRepeatEscapeCharEscapeMetaEscapeOctalEscapeOctalFullEscapeUniEscapeUniFullEscapeHexEscapeHexFullSubroutineCallSubroutineCallQuoteCommentBadEscapeUnicodeEscapeUnicodeFull\\Q-[[^]$^?.+*|((?P<name>(?<name>(?'name'(?flags(?>(?=(?<=(?!(?<!(?~\\%()\\@=\\@!\\@<=\\@<!\\@>"
 
-var _tokenKind_index = [...]uint8{0, 4, 8, 18, 28, 34, 40, 50, 60, 71, 80, 93, 102, 115, 122, 124, 125, 126, 128, 129, 130, 131, 132, 133, 134, 135, 136, 137, 146, 154, 162, 169, 172, 175, 179, 182, 186, 187}
+var _tokenKind_index = [...]uint16{0, 4, 8, 18, 28, 34, 40, 50, 60, 71, 86, 95, 108, 117, 130, 144, 163, 170, 173, 186, 203, 205, 206, 207, 209, 210, 211, 212, 213, 214, 215, 216, 217, 218, 227, 235, 243, 250, 253, 256, 260, 263, 267, 270, 273, 274, 277, 280, 284, 288, 291}
 
 func (i tokenKind) String() string {
 	if i >= tokenKind(len(_tokenKind_index)-1) {
@@ -0,0 +1,432 @@
+package syntax
+
+import (
+	"strconv"
+	"strings"
+)
+
+// TranslateWarning describes a construct that was translated
+// into something that is not fully equivalent.
+type TranslateWarning struct {
+	// Pos is a span of the source pattern part that caused the warning.
+	Pos Position
+
+	// Text is the pattern part that is described by Pos.
+	Text string
+
+	Message string
+}
+
+// translator is a base for the dialect translators.
+//
+// Translators work on the re AST copy; nodes that need to be
+// changed are replaced with programmatically built nodes
+// and the result is rendered with Print.
+type translator struct {
+	pattern  string
+	warnings []TranslateWarning
+	errors   ErrorList
+
+	numCaptures int
+}
+
+func (t *translator) init(re *Regexp) {
+	t.pattern = re.Pattern
+	t.warnings = nil
+	t.errors = nil
+	t.numCaptures = len(re.CaptureGroups())
+}
+
+func (t *translator) text(pos Position) string {
+	if int(pos.End) > len(t.pattern) || pos.Begin > pos.End {
+		return ""
+	}
+	return t.pattern[pos.Begin:pos.End]
+}
+
+func (t *translator) warn(e *Expr, message string) {
+	t.warnings = append(t.warnings, TranslateWarning{
+		Pos:     e.Pos,
+		Text:    t.text(e.Pos),
+		Message: message,
+	})
+}
+
+func (t *translator) fail(e *Expr, message string) {
+	t.errors = append(t.errors, ParseError{
+		Pos:     e.Pos,
+		Code:    ErrUnsupported,
+		Text:    t.text(e.Pos),
+		Message: message,
+	})
+}
+
+// result prints the translated AST.
+// If there were any errors, pattern is empty.
+func (t *translator) result(e *Expr) (string, []TranslateWarning, error) {
+	if len(t.errors) != 0 {
+		return "", t.warnings, t.errors
+	}
+	return Print(&Regexp{Expr: *e}), t.warnings, nil
+}
+
+// parseTemplate parses a pattern that is used as a translation result.
+// s is expected to be a valid pattern.
+func parseTemplate(s string) Expr {
+	re, err := NewParser(nil).Parse(s)
+	if err != nil {
+		panic("invalid template " + s + ": " + err.Error())
+	}
+	return re.Expr.Clone()
+}
+
+// TranslatePCREToRE2 converts a PCRE pattern AST into the RE2 syntax
+// that can be used with Go regexp package.
+//
+// Some constructs are replaced with their RE2 equivalents:
+// `\h` and `\v` classes are expanded, `(?'name're)` becomes `(?P<name>re)`,
+// comments and the x flag are removed.
+// Possessive quantifiers and atomic groups are translated into their
+// backtracking counterparts with a warning, as they can match differently.
+//
+// Constructs that have no RE2 equivalent, like lookarounds or backreferences,
+// are reported as ErrUnsupported errors inside ErrorList.
+// The returned pattern is empty in that case.
+func TranslatePCREToRE2(re *Regexp) (string, []TranslateWarning, error) {
+	t := pcreToRE2{}
+	t.init(re)
+	e := re.Expr.Clone()
+	t.translate(&e, false)
+	return t.result(&e)
+}
+
+type pcreToRE2 struct {
+	translator
+}
+
+// re2MaxRepeat is the max {n,m} repetition count accepted by RE2.
+const re2MaxRepeat = 1000
+
+const (
+	pcreHorizontalSpace = `\t\x{20}\x{A0}\x{1680}\x{180E}\x{2000}-\x{200A}\x{202F}\x{205F}\x{3000}`
+	pcreVerticalSpace   = `\n\x0B\f\r\x{85}\x{2028}\x{2029}`
+)
+
+func (t *pcreToRE2) translate(e *Expr, inClass bool) {
+	switch e.Op {
+	case OpCharClass, OpNegCharClass:
+		t.translateCharClass(e)
+		return
+
+	case OpComment:
+		*e = Expr{Op: OpConcat}
+		return
+
+	case OpQuote:
+		if e.Form == FormQuoteStrayEnd {
+			*e = Expr{Op: OpConcat}
+		}
+		return
+
+	case OpEscapeChar, OpEscapeMeta:
+		t.translateEscape(e, inClass)
+		return
+
+	case OpEscapeOctal:
+		t.translateOctal(e)
+		return
+
+	case OpPossessive:
+		t.warn(e, "possessive quantifier is translated into a greedy one")
+		*e = e.Args[0]
+
+	case OpAtomicGroup:
+		t.warn(e, "atomic group is translated into a non-capturing group")
+		e.Op = OpGroup
+
+	case OpNamedCapture:
+		e.Form = FormDefault
+
+	case OpRepeat:
+		if min, max := e.RepeatBounds(); min > re2MaxRepeat || max > re2MaxRepeat {
+			t.fail(e, "repeat count is greater than "+strconv.Itoa(re2MaxRepeat))
+		}
+
+	case OpFlagOnlyGroup, OpGroupWithFlags:
+		t.translateFlags(e)
+
+	case OpPositiveLookahead, OpNegativeLookahead:
+		t.fail(e, "lookahead assertions are not supported in RE2")
+		return
+	case OpPositiveLookbehind, OpNegativeLookbehind:
+		t.fail(e, "lookbehind assertions are not supported in RE2")
+		return
+	case OpSubroutineCall:
+		t.fail(e, "subroutine calls are not supported in RE2")
+		return
+	case OpAbsentGroup:
+		t.fail(e, "absent operators are not supported in RE2")
+		return
+	}
+
+	for i := range e.Args {
+		t.translate(&e.Args[i], inClass)
+	}
+}
+
+func (t *pcreToRE2) translateFlags(e *Expr) {
+	if e.Form == FormFlagsReset {
+		t.fail(e, "(?^) flag resets are not supported in RE2")
+		return
+	}
+	flagsArg := &e.Args[0]
+	if e.Op == OpGroupWithFlags {
+		flagsArg = &e.Args[1]
+	}
+
+	var flags strings.Builder
+	for _, ch := range flagsArg.Value {
+		switch ch {
+		case 'i', 'm', 's', 'U', '-':
+			flags.WriteRune(ch)
+		case 'x':
+			// Free-spacing comments are removed anyway.
+		default:
+			t.fail(e, "(?"+string(ch)+") flag is not supported in RE2")
+		}
+	}
+	value := strings.TrimSuffix(flags.String(), "-")
+	switch {
+	case value != "":
+		flagsArg.Value = value
+	case e.Op == OpGroupWithFlags:
+		*e = Expr{Op: OpGroup, Pos: e.Pos, Args: []Expr{e.Args[0]}}
+	default:
+		*e = Expr{Op: OpConcat}
+	}
+}
+
+func (t *pcreToRE2) translateCharClass(e *Expr) {
+	args := make([]Expr, 0, len(e.Args))
+	for _, a := range e.Args {
+		if a.Op != OpEscapeChar {
+			t.translate(&a, true)
+			args = append(args, a)
+			continue
+		}
+		switch v := a.Args[0].Value; v {
+		case "h", "v":
+			args = append(args, pcreClassTemplate(v, false).Args...)
+		case "H", "V":
+			if len(e.Args) != 1 {
+				t.fail(&a, `\`+v+" inside a char class can't be expressed in RE2")
+				continue
+			}
+			// `[\H]` is `\H` and `[^\H]` is `\h`.
+			negated := e.Op == OpCharClass
+			*e = pcreClassTemplate(strings.ToLower(v), negated)
+			return
+		default:
+			t.translate(&a, true)
+			args = append(args, a)
+		}
+	}
+	e.Args = args
+}
+
+// pcreClassTemplate returns `\h` or `\v` PCRE class RE2 equivalent.
+func pcreClassTemplate(name string, negated bool) Expr {
+	set := pcreHorizontalSpace
+	if name == "v" {
+		set = pcreVerticalSpace
+	}
+	if negated {
+		return parseTemplate(`[^` + set + `]`)
+	}
+	return parseTemplate(`[` + set + `]`)
+}
+
+func (t *pcreToRE2) translateEscape(e *Expr, inClass bool) {
+	v := e.Args[0].Value
+	if len(v) != 1 || !isAlphanumeric(v[0]) {
+		if len(v) == 1 && isPunct(v[0]) {
+			return
+		}
+		// RE2 only permits escaping punctuation, so `\ ` becomes ` `.
+		*e = Expr{Op: OpChar, Value: v}
+		return
+	}
+
+	switch v[0] {
+	case 'a', 'f', 't', 'n', 'r', 'd', 'D', 's', 'S', 'w', 'W':
+		return
+	case 'b':
+		if inClass {
+			// `[\b]` is a backspace char.
+			*e = parseTemplate(`\x08`)
+		}
+		return
+	case 'B', 'A', 'z':
+		if inClass {
+			break
+		}
+		return
+	case 'h', 'H', 'V':
+		*e = pcreClassTemplate(strings.ToLower(v), v != "h")
+		return
+	case 'v':
+		// `\v` is a vertical tab char in RE2.
+		*e = pcreClassTemplate("v", false)
+		return
+	case 'R':
+		if inClass {
+			break
+		}
+		*e = parseTemplate(`(?:\r\n|[` + pcreVerticalSpace + `])`)
+		return
+	case 'N':
+		if inClass {
+			break
+		}
+		*e = parseTemplate(`[^\n]`)
+		return
+	case 'e':
+		*e = parseTemplate(`\x1B`)
+		return
+	}
+	t.fail(e, `\`+v+" escape is not supported in RE2")
+}
+
+func (t *pcreToRE2) translateOctal(e *Expr) {
+	v := e.Args[0].Value
+	if e.Form != FormEscapeOctalFull && v[0] != '0' {
+		// PCRE interprets `\1`-`\9` and `\NN` with NN <= captures count as backreferences.
+		if n, _ := strconv.Atoi(v); len(v) == 1 || n <= t.numCaptures {
+			t.fail(e, "backreferences are not supported in RE2")
+			return
+		}
+	}
+	code, err := strconv.ParseUint(v, 8, 32)
+	if err != nil {
+		t.fail(e, "invalid octal escape")
+		return
+	}
+	*e = parseTemplate(`\x{` + strings.ToUpper(strconv.FormatUint(code, 16)) + `}`)
+}
+
+func isPunct(ch byte) bool {
+	return (ch >= '!' && ch <= '/') ||
+		(ch >= ':' && ch <= '@') ||
+		(ch >= '[' && ch <= '`') ||
+		(ch >= '{' && ch <= '~')
+}
+
+// TranslateRE2ToPCRE converts an RE2 pattern AST into the PCRE syntax.
+//
+// The constructs that are interpreted differently by PCRE are rewritten:
+// `$` outside of the multi-line mode becomes `\z`, `\v` becomes `\x0B`,
+// octal escapes become hex escapes, so they're not confused with backreferences,
+// and literal `{` is escaped.
+// The result assumes that PCRE UTF mode is enabled.
+//
+// Constructs that are not a part of RE2 syntax are reported
+// as ErrUnsupported errors inside ErrorList.
+func TranslateRE2ToPCRE(re *Regexp) (string, []TranslateWarning, error) {
+	t := re2ToPCRE{}
+	t.init(re)
+	e := re.Expr.Clone()
+	multiline := false
+	t.translate(&e, &multiline)
+	return t.result(&e)
+}
+
+type re2ToPCRE struct {
+	translator
+}
+
+// translate rewrites e in place.
+// multiline tracks the m flag state, it's updated by the `(?m)` groups.
+func (t *re2ToPCRE) translate(e *Expr, multiline *bool) {
+	if f := exprFeature(e); f != featNone && DialectRE2.info().features&f == 0 {
+		t.fail(e, featureNames[f]+" are not supported in RE2")
+		return
+	}
+
+	switch e.Op {
+	case OpDollar:
+		if !*multiline {
+			*e = parseTemplate(`\z`)
+		}
+		return
+
+	case OpChar:
+		if e.Value == "{" {
+			*e = parseTemplate(`\{`)
+		}
+		return
+
+	case OpCharClass, OpNegCharClass:
+		// Only the escapes need to be translated inside a class.
+		for i := range e.Args {
+			a := &e.Args[i]
+			switch a.Op {
+			case OpEscapeChar, OpEscapeOctal:
+				t.translate(a, multiline)
+			case OpCharRange:
+				t.translate(&a.Args[0], multiline)
+				t.translate(&a.Args[1], multiline)
+			}
+		}
+		return
+
+	case OpEscapeChar:
+		if e.Args[0].Value == "v" {
+			// `\v` is a vertical whitespace class in PCRE.
+			*e = parseTemplate(`\x0B`)
+		}
+		return
+
+	case OpEscapeOctal:
+		code, err := strconv.ParseUint(e.Args[0].Value, 8, 32)
+		if err != nil {
+			t.fail(e, "invalid octal escape")
+			return
+		}
+		*e = parseTemplate(`\x{` + strings.ToUpper(strconv.FormatUint(code, 16)) + `}`)
+		return
+
+	case OpFlagOnlyGroup:
+		*multiline = flagEnabled(e.Args[0].Value, 'm', *multiline)
+		return
+
+	case OpGroupWithFlags:
+		groupMultiline := flagEnabled(e.Args[1].Value, 'm', *multiline)
+		t.translate(&e.Args[0], &groupMultiline)
+		return
+
+	case OpCapture, OpNamedCapture, OpGroup:
+		// Flags that are set inside a group don't leak outside.
+		groupMultiline := *multiline
+		t.translate(&e.Args[0], &groupMultiline)
+		return
+	}
+
+	for i := range e.Args {
+		t.translate(&e.Args[i], multiline)
+	}
+}
+
+// flagEnabled reports whether flag is set after flags are applied.
+// enabled is the flag state before that.
+func flagEnabled(flags string, flag byte, enabled bool) bool {
+	enable := true
+	for i := 0; i < len(flags); i++ {
+		switch flags[i] {
+		case '-':
+			enable = false
+		case flag:
+			enabled = enable
+		}
+	}
+	return enabled
+}
@@ -0,0 +1,361 @@
+package syntax
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// TranslateECMAScriptToRE2 converts a JavaScript regexp literal like `/ab+c/i`
+// into the RE2 syntax that can be used with Go regexp package.
+//
+// The i, m and s flags are converted into a `(?ims)` prefix; the y flag
+// is translated into a `\A` anchor with a warning; g and d flags are ignored
+// as they don't affect the pattern itself. The u flag affects how
+// `\u{...}`, `\p{...}` and unknown escapes are interpreted.
+//
+// JavaScript semantics of `.` and `\s` are preserved by expanding them
+// into the explicit char classes; `\uFFFF` escapes (including surrogate pairs)
+// become `\x{...}` escapes.
+//
+// Constructs that have no RE2 equivalent, like lookarounds or backreferences,
+// are reported as ErrUnsupported errors inside ErrorList.
+// Positions are relative to the pattern between the slashes.
+func TranslateECMAScriptToRE2(literal string) (string, []TranslateWarning, error) {
+	end := strings.LastIndexByte(literal, '/')
+	if !strings.HasPrefix(literal, "/") || end == 0 {
+		return "", nil, ParseError{
+			Pos:     newPos(0, len(literal)),
+			Code:    ErrUnsupported,
+			Text:    literal,
+			Message: "expected /pattern/flags literal",
+		}
+	}
+	pattern, flags := literal[1:end], literal[end+1:]
+
+	re, err := NewParser(&ParserOptions{Dialect: DialectECMAScript}).Parse(pattern)
+	if err != nil {
+		return "", nil, err
+	}
+
+	t := ecmascriptToRE2{}
+	t.init(re)
+	e := re.Expr.Clone()
+	root := Expr{Op: OpConcat, Pos: e.Pos}
+	var goFlags strings.Builder
+	for i := 0; i < len(flags); i++ {
+		switch ch := flags[i]; ch {
+		case 'i', 'm', 's':
+			goFlags.WriteByte(ch)
+		case 'u':
+			t.unicode = true
+		case 'y':
+			t.warn(&e, "sticky flag is translated into \\A anchor")
+			root.Args = append(root.Args, parseTemplate(`\A`))
+		case 'g', 'd':
+			// These flags don't affect the pattern.
+		default:
+			t.fail(&e, "/"+string(ch)+" flag is not supported")
+		}
+	}
+	t.multiline = strings.Contains(flags, "m")
+	t.dotAll = strings.Contains(flags, "s")
+	Walk(re, func(e *Expr) bool {
+		if e.Op == OpNamedCapture {
+			t.hasNamedCaptures = true
+		}
+		return true
+	})
+
+	t.translate(&e, false)
+
+	if goFlags.Len() != 0 {
+		flagGroup := parseTemplate("(?" + goFlags.String() + ")")
+		root.Args = append([]Expr{flagGroup}, root.Args...)
+	}
+	if len(root.Args) == 0 {
+		return t.result(&e)
+	}
+	root.Args = append(root.Args, e)
+	return t.result(&root)
+}
+
+type ecmascriptToRE2 struct {
+	translator
+
+	unicode          bool
+	multiline        bool
+	dotAll           bool
+	hasNamedCaptures bool
+}
+
+// ecmascriptSpace is a set of chars matched by ECMAScript `\s`.
+const ecmascriptSpace = `\t\n\v\f\r\x{20}\x{A0}\x{1680}\x{2000}-\x{200A}\x{2028}\x{2029}\x{202F}\x{205F}\x{3000}\x{FEFF}`
+
+func (t *ecmascriptToRE2) translate(e *Expr, inClass bool) {
+	switch e.Op {
+	case OpConcat:
+		e.Args = t.combineSurrogates(e.Args)
+
+	case OpCharClass, OpNegCharClass:
+		e.Args = t.combineSurrogates(e.Args)
+		t.translateCharClass(e)
+		return
+
+	case OpDot:
+		if !t.dotAll {
+			*e = parseTemplate(`[^\n\r\x{2028}\x{2029}]`)
+		}
+		return
+
+	case OpCaret, OpDollar:
+		if t.multiline {
+			t.warn(e, "multi-line anchors only recognize \\n line terminators in RE2")
+		}
+		return
+
+	case OpEscapeChar, OpEscapeMeta, OpEscapeClass:
+		t.translateEscape(e, inClass)
+		return
+
+	case OpEscapeOctal:
+		// `\N` is a backreference if there are at least N groups;
+		// otherwise it's a legacy octal escape.
+		if n, _ := strconv.Atoi(e.Args[0].Value); n != 0 && n <= t.numCaptures {
+			t.fail(e, "backreferences are not supported in RE2")
+			return
+		}
+		code, err := strconv.ParseUint(e.Args[0].Value, 8, 32)
+		if err != nil {
+			t.fail(e, "invalid octal escape")
+			return
+		}
+		*e = parseTemplate(`\x{` + strings.ToUpper(strconv.FormatUint(code, 16)) + `}`)
+		return
+
+	case OpEscapeHex:
+		t.translateHex(e)
+		return
+
+	case OpEscapeUni:
+		t.translateUni(e)
+		return
+
+	case OpNamedCapture:
+		e.Form = FormDefault
+
+	case OpRepeat:
+		if min, max := e.RepeatBounds(); min > re2MaxRepeat || max > re2MaxRepeat {
+			t.fail(e, "repeat count is greater than "+strconv.Itoa(re2MaxRepeat))
+		}
+
+	case OpPositiveLookahead, OpNegativeLookahead:
+		t.fail(e, "lookahead assertions are not supported in RE2")
+		return
+	case OpPositiveLookbehind, OpNegativeLookbehind:
+		t.fail(e, "lookbehind assertions are not supported in RE2")
+		return
+	}
+
+	for i := range e.Args {
+		t.translate(&e.Args[i], inClass)
+	}
+}
+
+func (t *ecmascriptToRE2) translateCharClass(e *Expr) {
+	args := make([]Expr, 0, len(e.Args))
+	for _, a := range e.Args {
+		if a.Op != OpEscapeClass || (a.Args[0].Value != "s" && a.Args[0].Value != "S") {
+			t.translate(&a, true)
+			args = append(args, a)
+			continue
+		}
+		switch {
+		case !a.Negated:
+			args = append(args, ecmascriptSpaceTemplate(false).Args...)
+		case len(e.Args) == 1:
+			// `[\S]` is `\S` and `[^\S]` is `\s`.
+			*e = ecmascriptSpaceTemplate(e.Op == OpCharClass)
+			return
+		default:
+			t.fail(&a, `\S inside a char class can't be expressed in RE2`)
+		}
+	}
+	e.Args = args
+}
+
+// ecmascriptSpaceTemplate returns `\s` or `\S` ECMAScript class RE2 equivalent.
+func ecmascriptSpaceTemplate(negated bool) Expr {
+	if negated {
+		return parseTemplate(`[^` + ecmascriptSpace + `]`)
+	}
+	return parseTemplate(`[` + ecmascriptSpace + `]`)
+}
+
+func (t *ecmascriptToRE2) translateEscape(e *Expr, inClass bool) {
+	v := e.Args[0].Value
+	if len(v) == 1 && isPunct(v[0]) {
+		return
+	}
+
+	switch v {
+	case "d", "D", "w", "W", "t", "n", "r", "f", "v":
+		return
+	case "s", "S":
+		*e = ecmascriptSpaceTemplate(v == "S")
+		return
+	case "b":
+		if inClass {
+			// `[\b]` is a backspace char.
+			*e = parseTemplate(`\x08`)
+		}
+		return
+	case "B":
+		if !inClass {
+			return
+		}
+	case "k":
+		if t.unicode || t.hasNamedCaptures {
+			t.fail(e, "backreferences are not supported in RE2")
+			return
+		}
+	}
+
+	if len(v) == 2 && v[0] == 'c' {
+		// `\cJ` is a control char: its code is a letter code modulo 32.
+		*e = parseTemplate(`\x{` + strconv.FormatUint(uint64(v[1]%32), 16) + `}`)
+		return
+	}
+	if t.unicode {
+		t.fail(e, `\`+v+" escape is not supported in the unicode mode")
+		return
+	}
+	// Identity escape: `\a` matches 'a'.
+	*e = Expr{Op: OpChar, Value: v}
+}
+
+func (t *ecmascriptToRE2) translateHex(e *Expr) {
+	v := e.Args[0].Value
+	switch e.Form {
+	case FormEscapeUnicode:
+		code, _ := strconv.ParseUint(v, 16, 32)
+		if code >= 0xD800 && code <= 0xDFFF {
+			t.fail(e, "lone surrogates can't be matched in RE2")
+			return
+		}
+		*e = hexEscapeTemplate(rune(code))
+	case FormEscapeUnicodeFull:
+		if !t.unicode {
+			// Without the u flag, `\u{3}` is `uuu`.
+			*e = parseTemplate(`u{` + v + `}`)
+			return
+		}
+		code, err := strconv.ParseUint(v, 16, 32)
+		if err != nil || code > unicode.MaxRune {
+			t.fail(e, "invalid code point")
+			return
+		}
+		*e = hexEscapeTemplate(rune(code))
+	}
+}
+
+func (t *ecmascriptToRE2) translateUni(e *Expr) {
+	if !t.unicode {
+		// Without the u flag, `\p{L}` is `p{L}`.
+		*e = parseTemplate(`\Q` + strings.TrimPrefix(t.text(e.Pos), `\`) + `\E`)
+		return
+	}
+	if e.Form == FormDefault {
+		t.fail(e, "\\p escape without braces is not supported")
+		return
+	}
+	name := unicodeClassName(e)
+	if e.Form == FormEscapeUniProperty {
+		switch e.Args[0].Value {
+		case "Script", "sc", "General_Category", "gc":
+			name = e.Args[1].Value
+		}
+	}
+	if short, ok := unicodeCategoryNames[name]; ok {
+		name = short
+	}
+	if unicode.Categories[name] == nil && unicode.Scripts[name] == nil {
+		t.fail(e, "Unicode property "+unicodeClassName(e)+" is not supported in RE2")
+		return
+	}
+	e.Form = FormEscapeUniFull
+	e.Args = e.Args[:1]
+	e.Args[0].Value = name
+}
+
+// combineSurrogates replaces `\uD83D\uDE00` surrogate pair escapes
+// with a single escape that describes the encoded code point.
+func (t *ecmascriptToRE2) combineSurrogates(args []Expr) []Expr {
+	for i := 0; i+1 < len(args); i++ {
+		hi, ok1 := unicodeEscapeCode(&args[i])
+		lo, ok2 := unicodeEscapeCode(&args[i+1])
+		if !ok1 || !ok2 || hi < 0xD800 || hi > 0xDBFF || lo < 0xDC00 || lo > 0xDFFF {
+			continue
+		}
+		r := (hi-0xD800)<<10 + (lo - 0xDC00) + 0x10000
+		pair := hexEscapeTemplate(r)
+		pair.Pos = combinePos(args[i].Pos, args[i+1].Pos)
+		args[i] = pair
+		args = append(args[:i+1], args[i+2:]...)
+	}
+	return args
+}
+
+func unicodeEscapeCode(e *Expr) (rune, bool) {
+	if e.Op != OpEscapeHex || e.Form != FormEscapeUnicode {
+		return 0, false
+	}
+	code, err := strconv.ParseUint(e.Args[0].Value, 16, 32)
+	return rune(code), err == nil
+}
+
+func hexEscapeTemplate(r rune) Expr {
+	return parseTemplate(`\x{` + strings.ToUpper(strconv.FormatInt(int64(r), 16)) + `}`)
+}
+
+// unicodeCategoryNames maps long general category names to their short forms.
+var unicodeCategoryNames = map[string]string{
+	"Letter":                "L",
+	"Cased_Letter":          "LC",
+	"Uppercase_Letter":      "Lu",
+	"Lowercase_Letter":      "Ll",
+	"Titlecase_Letter":      "Lt",
+	"Modifier_Letter":       "Lm",
+	"Other_Letter":          "Lo",
+	"Mark":                  "M",
+	"Nonspacing_Mark":       "Mn",
+	"Spacing_Mark":          "Mc",
+	"Enclosing_Mark":        "Me",
+	"Number":                "N",
+	"Decimal_Number":        "Nd",
+	"Letter_Number":         "Nl",
+	"Other_Number":          "No",
+	"Punctuation":           "P",
+	"Connector_Punctuation": "Pc",
+	"Dash_Punctuation":      "Pd",
+	"Open_Punctuation":      "Ps",
+	"Close_Punctuation":     "Pe",
+	"Initial_Punctuation":   "Pi",
+	"Final_Punctuation":     "Pf",
+	"Other_Punctuation":     "Po",
+	"Symbol":                "S",
+	"Math_Symbol":           "Sm",
+	"Currency_Symbol":       "Sc",
+	"Modifier_Symbol":       "Sk",
+	"Other_Symbol":          "So",
+	"Separator":             "Z",
+	"Space_Separator":       "Zs",
+	"Line_Separator":        "Zl",
+	"Paragraph_Separator":   "Zp",
+	"Other":                 "C",
+	"Control":               "Cc",
+	"Format":                "Cf",
+	"Surrogate":             "Cs",
+	"Private_Use":           "Co",
+	"Unassigned":            "Cn",
+}
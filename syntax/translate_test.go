@@ -0,0 +1,236 @@
+package syntax
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestTranslatePCREToRE2(t *testing.T) {
+	tests := []struct {
+		pattern  string
+		want     string
+		warnings []string
+	}{
+		{`abc`, `abc`, nil},
+		{`(?P<a>x)(?<b>y)(?'c'z)`, `(?P<a>x)(?P<b>y)(?P<c>z)`, nil},
+		{`a(?#comment)b`, `ab`, nil},
+		{`(?x) a \  b # comment`, `a b`, nil},
+		{`(?ix:a b)`, `(?i:ab)`, nil},
+		{`(?x:a)`, `(?:a)`, nil},
+		{`\Qa.b\E\E`, `\Qa.b\E`, nil},
+		{`a{2,1000}`, `a{2,1000}`, nil},
+
+		{`\h+`, `[\t\x{20}\x{A0}\x{1680}\x{180E}\x{2000}-\x{200A}\x{202F}\x{205F}\x{3000}]+`, nil},
+		{`\V`, `[^\n\x0B\f\r\x{85}\x{2028}\x{2029}]`, nil},
+		{`[\va]`, `[\n\x0B\f\r\x{85}\x{2028}\x{2029}a]`, nil},
+		{`[^\H]`, `[\t\x{20}\x{A0}\x{1680}\x{180E}\x{2000}-\x{200A}\x{202F}\x{205F}\x{3000}]`, nil},
+		{`a\Rb`, `a(?:\r\n|[\n\x0B\f\r\x{85}\x{2028}\x{2029}])b`, nil},
+		{`\N\e[\b]`, `[^\n]\x1B[\x08]`, nil},
+		{`\012\o{101}`, `\x{A}\x{41}`, nil},
+		{`(a)\12`, `(a)\x{A}`, nil},
+
+		{`a++b`, `a+b`, []string{`possessive quantifier is translated into a greedy one`}},
+		{`(?>a|ab)c`, `(?:a|ab)c`, []string{`atomic group is translated into a non-capturing group`}},
+	}
+
+	p := NewParser(&ParserOptions{Dialect: DialectPCRE})
+	for _, test := range tests {
+		re, err := p.Parse(test.pattern)
+		if err != nil {
+			t.Fatalf("parse(%q): %v", test.pattern, err)
+		}
+		have, warnings, err := TranslatePCREToRE2(re)
+		if err != nil {
+			t.Errorf("translate(%q): unexpected error: %v", test.pattern, err)
+			continue
+		}
+		if have != test.want {
+			t.Errorf("translate(%q):\nhave: %s\nwant: %s", test.pattern, have, test.want)
+		}
+		var messages []string
+		for _, w := range warnings {
+			messages = append(messages, w.Message)
+		}
+		if strings.Join(messages, "; ") != strings.Join(test.warnings, "; ") {
+			t.Errorf("translate(%q) warnings:\nhave: %q\nwant: %q", test.pattern, messages, test.warnings)
+		}
+		if _, err := regexp.Compile(have); err != nil {
+			t.Errorf("translate(%q): result is not a valid Go regexp: %v", test.pattern, err)
+		}
+	}
+}
+
+func TestTranslatePCREToRE2Errors(t *testing.T) {
+	tests := []struct {
+		pattern string
+		errors  []string
+	}{
+		{`a(?=b)`, []string{`(?=b): lookahead assertions are not supported in RE2`}},
+		{`(?<!a)b`, []string{`(?<!a): lookbehind assertions are not supported in RE2`}},
+		{`(a)\1`, []string{`\1: backreferences are not supported in RE2`}},
+		{`\Ka\G`, []string{
+			`\K: \K escape is not supported in RE2`,
+			`\G: \G escape is not supported in RE2`,
+		}},
+		{`a{1001}`, []string{`a{1001}: repeat count is greater than 1000`}},
+		{`(?J)(?<n>a)`, []string{`(?J): (?J) flag is not supported in RE2`}},
+		{`[\Ha]`, []string{`\H: \H inside a char class can't be expressed in RE2`}},
+	}
+
+	p := NewParser(&ParserOptions{Dialect: DialectPCRE})
+	for _, test := range tests {
+		re, err := p.Parse(test.pattern)
+		if err != nil {
+			t.Fatalf("parse(%q): %v", test.pattern, err)
+		}
+		have, _, err := TranslatePCREToRE2(re)
+		list, ok := err.(ErrorList)
+		if !ok {
+			t.Errorf("translate(%q): expected ErrorList, got %v", test.pattern, err)
+			continue
+		}
+		if have != "" {
+			t.Errorf("translate(%q): expected empty result, got %q", test.pattern, have)
+		}
+		var messages []string
+		for _, e := range list {
+			if e.Code != ErrUnsupported {
+				t.Errorf("translate(%q): unexpected error code %s", test.pattern, e.Code)
+			}
+			messages = append(messages, e.Text+": "+e.Message)
+		}
+		if strings.Join(messages, "\n") != strings.Join(test.errors, "\n") {
+			t.Errorf("translate(%q) errors:\nhave: %q\nwant: %q", test.pattern, messages, test.errors)
+		}
+	}
+}
+
+func TestTranslateRE2ToPCRE(t *testing.T) {
+	tests := []struct {
+		pattern string
+		want    string
+	}{
+		{`abc`, `abc`},
+		{`(?P<a>x)(?<b>y)\pL\p{^Greek}`, `(?P<a>x)(?<b>y)\pL\p{^Greek}`},
+		{`^a$`, `^a\z`},
+		{`(?m)^a$`, `(?m)^a$`},
+		{`(?m:a$)b$`, `(?m:a$)b\z`},
+		{`(?m)a$(?-m)b$`, `(?m)a$(?-m)b\z`},
+		{`((?m)a$)b$`, `((?m)a$)b\z`},
+		{`a{,2}\{`, `a\{,2}\{`},
+		{`a{1,2}`, `a{1,2}`},
+		{`\v[\v-\x0D]`, `\x0B[\x0B-\x0D]`},
+		{`\1\012[\12]`, `\x{1}\x{A}[\x{A}]`},
+		{`\Qa{\E`, `\Qa{\E`},
+	}
+
+	p := NewParser(&ParserOptions{Dialect: DialectRE2})
+	for _, test := range tests {
+		re, err := p.Parse(test.pattern)
+		if err != nil {
+			t.Fatalf("parse(%q): %v", test.pattern, err)
+		}
+		have, warnings, err := TranslateRE2ToPCRE(re)
+		if err != nil {
+			t.Errorf("translate(%q): unexpected error: %v", test.pattern, err)
+			continue
+		}
+		if len(warnings) != 0 {
+			t.Errorf("translate(%q): unexpected warnings: %v", test.pattern, warnings)
+		}
+		if have != test.want {
+			t.Errorf("translate(%q):\nhave: %s\nwant: %s", test.pattern, have, test.want)
+		}
+	}
+
+	re, err := NewParser(nil).Parse(`a(?=b)`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := TranslateRE2ToPCRE(re); err == nil || err.Error() != "lookahead assertions are not supported in RE2" {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestTranslateECMAScriptToRE2(t *testing.T) {
+	tests := []struct {
+		literal  string
+		want     string
+		warnings []string
+	}{
+		{`/abc/`, `abc`, nil},
+		{`/abc/gi`, `(?i)abc`, nil},
+		{`/a.b/s`, `(?s)a.b`, nil},
+		{`/a.b/`, `a[^\n\r\x{2028}\x{2029}]b`, nil},
+		{`/(?<year>\d{4})-\w+/`, `(?P<year>\d{4})-\w+`, nil},
+		{`/\s\S[\s,]/`, `[\t\n\v\f\r\x{20}\x{A0}\x{1680}\x{2000}-\x{200A}\x{2028}\x{2029}\x{202F}\x{205F}\x{3000}\x{FEFF}]` +
+			`[^\t\n\v\f\r\x{20}\x{A0}\x{1680}\x{2000}-\x{200A}\x{2028}\x{2029}\x{202F}\x{205F}\x{3000}\x{FEFF}]` +
+			`[\t\n\v\f\r\x{20}\x{A0}\x{1680}\x{2000}-\x{200A}\x{2028}\x{2029}\x{202F}\x{205F}\x{3000}\x{FEFF},]`, nil},
+		{`/\u00e9[\u0041-\u005A]/`, `\x{E9}[\x{41}-\x{5A}]`, nil},
+		{`/\uD83D\uDE00/`, `\x{1F600}`, nil},
+		{`/\u{1F600}/u`, `\x{1F600}`, nil},
+		{`/\u{3}/`, `u{3}`, nil},
+		{`/\cJ\0[\b]/`, `\x{a}\x{0}[\x08]`, nil},
+		{`/\a\z\//`, `az\/`, nil},
+		{`/\p{L}/`, `\Qp{L}\E`, nil},
+		{`/\p{Script=Greek}\P{Letter}/u`, `\p{Greek}\P{L}`, nil},
+		{`/(a)\2/`, `(a)\x{2}`, nil},
+		{`/a/y`, `\Aa`, []string{`sticky flag is translated into \A anchor`}},
+		{`/^a$/m`, `(?m)^a$`, []string{
+			`multi-line anchors only recognize \n line terminators in RE2`,
+			`multi-line anchors only recognize \n line terminators in RE2`,
+		}},
+	}
+
+	for _, test := range tests {
+		have, warnings, err := TranslateECMAScriptToRE2(test.literal)
+		if err != nil {
+			t.Errorf("translate(%q): unexpected error: %v", test.literal, err)
+			continue
+		}
+		if have != test.want {
+			t.Errorf("translate(%q):\nhave: %s\nwant: %s", test.literal, have, test.want)
+		}
+		var messages []string
+		for _, w := range warnings {
+			messages = append(messages, w.Message)
+		}
+		if strings.Join(messages, "; ") != strings.Join(test.warnings, "; ") {
+			t.Errorf("translate(%q) warnings:\nhave: %q\nwant: %q", test.literal, messages, test.warnings)
+		}
+		if _, err := regexp.Compile(have); err != nil {
+			t.Errorf("translate(%q): result is not a valid Go regexp: %v", test.literal, err)
+		}
+	}
+}
+
+func TestTranslateECMAScriptToRE2Errors(t *testing.T) {
+	tests := []struct {
+		literal string
+		want    string
+	}{
+		{`abc`, `expected /pattern/flags literal`},
+		{`/a(/`, `unexpected token: None`},
+		{`/a/x`, `/x flag is not supported`},
+		{`/a(?=b)/`, `lookahead assertions are not supported in RE2`},
+		{`/(?<=a)b/`, `lookbehind assertions are not supported in RE2`},
+		{`/(a)\1/`, `backreferences are not supported in RE2`},
+		{`/(?<n>a)\k<n>/`, `backreferences are not supported in RE2`},
+		{`/\uD83D/`, `lone surrogates can't be matched in RE2`},
+		{`/\p{ASCII_Hex_Digit}/u`, `Unicode property ASCII_Hex_Digit is not supported in RE2`},
+		{`/\a/u`, `\a escape is not supported in the unicode mode`},
+		{`/[\Sa]/`, `\S inside a char class can't be expressed in RE2`},
+	}
+
+	for _, test := range tests {
+		have, _, err := TranslateECMAScriptToRE2(test.literal)
+		if err == nil {
+			t.Errorf("translate(%q): expected an error, got %q", test.literal, have)
+			continue
+		}
+		if err.Error() != test.want {
+			t.Errorf("translate(%q) error:\nhave: %s\nwant: %s", test.literal, err, test.want)
+		}
+	}
+}
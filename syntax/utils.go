@@ -1,5 +1,52 @@
 package syntax
 
+import (
+	"unsafe"
+)
+
+// bytesToString returns a string that shares the memory with b.
+func bytesToString(b []byte) string {
+	return *(*string)(unsafe.Pointer(&b))
+}
+
+// patternText returns the pattern part that is described by pos.
+// If pos doesn't belong to the pattern, an empty string is returned.
+func patternText(pattern string, pos Position) string {
+	if int(pos.End) > len(pattern) || pos.Begin > pos.End {
+		return ""
+	}
+	return pattern[pos.Begin:pos.End]
+}
+
+// editDistance returns the Levenshtein distance between a and b.
+// The strings are compared byte by byte.
+func editDistance(a, b string) int {
+	row := make([]int, len(b)+1)
+	for j := range row {
+		row[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		prev := row[0]
+		row[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			next := prev + cost
+			if row[j]+1 < next {
+				next = row[j] + 1
+			}
+			if row[j-1]+1 < next {
+				next = row[j-1] + 1
+			}
+			prev = row[j]
+			row[j] = next
+		}
+	}
+	return row[len(b)]
+}
+
 func isSpace(ch byte) bool {
 	switch ch {
 	case '\r', '\n', '\t', '\f', '\v', ' ':
@@ -15,6 +62,10 @@ func isAlphanumeric(ch byte) bool {
 		(ch >= '0' && ch <= '9')
 }
 
+func isLetter(ch byte) bool {
+	return (ch >= 'a' && ch <= 'z') || (ch >= 'A' && ch <= 'Z')
+}
+
 func isDigit(ch byte) bool {
 	return ch >= '0' && ch <= '9'
 }
@@ -0,0 +1,296 @@
+package syntax
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+type ValidateOptions struct {
+	// Dialect selects the regexp flavor rules to be checked.
+	// It should match the dialect used to parse the pattern.
+	// Unknown values are treated as DialectDefault, like in ParserOptions.
+	Dialect Dialect
+
+	// Warnings enables the checks for the constructs that are valid,
+	// but are likely to be a mistake, like `a{0}`, `a{1}` or `\1(a)`.
+	Warnings bool
+}
+
+// Validate reports the semantic problems that the parser accepts on purpose,
+// so the AST can be built even for a slightly broken pattern:
+//
+//   - reversed char ranges: `[z-a]`
+//   - ranges with class endpoints: `[a-\d]`, `[\d-a]` (depending on the dialect)
+//   - escapes that are not valid in the dialect: `\y` or `\x2` in RE2
+//   - unknown Unicode classes: `\p{Foo}`, `\p{greek}` in RE2
+//   - unknown POSIX classes: `[[:foo:]]`
+//   - invalid repeat bounds: `a{5,2}`, `a{1001}` in RE2
+//   - empty lookbehinds: `(?<=)`
+//   - backreferences to non-existent groups: `(a)\2`
+//
+// The problems are returned as ErrorList.
+// If no problems are found, nil is returned.
+func Validate(re *Regexp, opts *ValidateOptions) error {
+	v := validator{pattern: re.Pattern}
+	if opts != nil {
+		v.dialect = opts.Dialect.orDefault()
+		v.warnings = opts.Warnings
+	}
+	v.info = v.dialect.info()
+	v.validate(&re.Expr)
+	if !v.info.strictEscapes {
+		v.checkBackreferences(re)
+	}
+	if len(v.errors) != 0 {
+		return v.errors
+	}
+	return nil
+}
+
+type validator struct {
+	pattern  string
+	dialect  Dialect
+	info     *dialectInfo
+	warnings bool
+	errors   ErrorList
+}
+
+func (v *validator) validate(e *Expr) {
+	switch e.Op {
+	case OpCharClass, OpNegCharClass:
+		v.checkClass(e)
+	case OpCharRange:
+		v.checkRange(e)
+	case OpEscapeChar:
+		v.checkEscapeChar(e)
+	case OpEscapeUni:
+		v.checkUnicodeClass(e)
+	case OpPosixClass:
+		v.checkPosixClass(e)
+	case OpRepeat:
+		v.checkRepeat(e)
+	case OpEscapeHex:
+		if v.info.strictEscapes && e.Form == FormDefault && len(e.Args[0].Value) != 2 {
+			v.report(e.Pos, ErrInvalidEscape, "invalid escape "+v.text(e.Pos)+": expected 2 hex digits")
+		}
+	case OpEscapeOctal:
+		if v.info.strictEscapes && e.Form == FormDefault && len(e.Args[0].Value) == 1 && e.Args[0].Value != "0" {
+			v.report(e.Pos, ErrInvalidEscape, "backreferences are not supported in "+v.dialect.String())
+		}
+	case OpPositiveLookbehind, OpNegativeLookbehind:
+		if body := e.Args[0]; body.Op == OpConcat && len(body.Args) == 0 {
+			v.report(e.Pos, ErrEmptyLookbehind, "empty lookbehind assertion")
+		}
+	}
+
+	for i := range e.Args {
+		v.validate(&e.Args[i])
+	}
+}
+
+// checkClass reports `[\d-a]` ranges that are parsed as a class
+// followed by the literal '-' char.
+func (v *validator) checkClass(e *Expr) {
+	if !v.info.strictClassRange {
+		return
+	}
+	for i := 0; i+2 < len(e.Args); i++ {
+		if isClassShorthand(&e.Args[i]) && e.Args[i+1].Op == OpChar && e.Args[i+1].Value == "-" {
+			pos := Position{Begin: e.Args[i].Begin(), End: e.Args[i+2].End()}
+			v.report(pos, ErrInvalidRange, "invalid char range "+v.text(pos)+": "+
+				v.text(e.Args[i].Pos)+" is not a char")
+		}
+	}
+}
+
+func (v *validator) checkRange(e *Expr) {
+	lo, ok := exprRune(&e.Args[0])
+	if !ok {
+		v.report(e.Pos, ErrInvalidRange, "invalid char range "+v.text(e.Pos)+": "+
+			v.text(e.Args[0].Pos)+" is not a char")
+		return
+	}
+	hi, ok := exprRune(&e.Args[1])
+	if !ok {
+		v.report(e.Pos, ErrInvalidRange, "invalid char range "+v.text(e.Pos)+": "+
+			v.text(e.Args[1].Pos)+" is not a char")
+		return
+	}
+	if lo > hi {
+		v.report(e.Pos, ErrInvalidRange, "invalid char range "+v.text(e.Pos)+": bounds are reversed")
+	}
+}
+
+func (v *validator) checkEscapeChar(e *Expr) {
+	if v.info.escapeLetters == "" {
+		return
+	}
+	s := e.Args[0].Value
+	if s != "" && isLetter(s[0]) && !strings.Contains(v.info.escapeLetters, s[:1]) {
+		v.report(e.Pos, ErrInvalidEscape, "invalid escape "+v.text(e.Pos)+" in "+v.dialect.String())
+	}
+}
+
+func (v *validator) checkUnicodeClass(e *Expr) {
+	name := strings.TrimPrefix(unicodeClassName(e), "^")
+	if isUnicodeClassName(name, v.info.strictUnicodeNames) {
+		return
+	}
+	message := "unknown Unicode class " + name
+	if suggestion := suggestUnicodeClass(name, v.info.strictUnicodeNames); suggestion != "" {
+		message += ", did you mean " + suggestion + "?"
+	}
+	v.report(e.Pos, ErrUnknownUnicodeClass, message)
+}
+
+func (v *validator) checkPosixClass(e *Expr) {
+	name := strings.TrimPrefix(posixClassName(e), "^")
+	if posixClassRanges[name] != nil {
+		return
+	}
+	message := "unknown POSIX class " + name
+	m := newNameMatcher(name)
+	for candidate := range posixClassRanges {
+		m.add(candidate, candidate)
+	}
+	if m.best != "" {
+		message += ", did you mean " + m.best + "?"
+	}
+	v.report(e.Pos, ErrUnknownPosixClass, message)
+}
+
+func (v *validator) checkRepeat(e *Expr) {
+	bounds := &e.Args[1]
+	min, max := repeatBounds(bounds.Value)
+	switch {
+	case max != -1 && min > max:
+		v.report(bounds.Pos, ErrInvalidRepeat, "invalid repeat "+bounds.Value+": min is greater than max")
+	case v.info.maxRepeat != 0 && (min > v.info.maxRepeat || max > v.info.maxRepeat):
+		v.report(bounds.Pos, ErrRepeatTooLarge, "repeat "+bounds.Value+" exceeds the "+
+			v.dialect.String()+" limit of "+strconv.Itoa(v.info.maxRepeat))
+	case v.warnings && max == 0:
+		v.report(bounds.Pos, ErrRedundantRepeat, v.text(e.Args[0].Pos)+bounds.Value+" always matches an empty string")
+	case v.warnings && min == 1 && max == 1:
+		v.report(bounds.Pos, ErrRedundantRepeat, "repeat "+bounds.Value+" is redundant")
+	}
+}
+
+func (v *validator) checkBackreferences(re *Regexp) {
+	for _, ref := range re.Backreferences() {
+		switch {
+		case ref.Group == nil:
+			v.report(ref.Pos, ErrUndefinedBackreference, "backreference "+v.text(ref.Pos)+" refers to a non-existent group")
+		case !v.warnings:
+			// The checks below are warnings.
+		case ref.Pos.Begin < ref.Group.Pos.Begin:
+			v.report(ref.Pos, ErrForwardBackreference, "backreference "+v.text(ref.Pos)+" refers to a group that is defined later")
+		case ref.Pos.End <= ref.Group.Pos.End:
+			v.report(ref.Pos, ErrForwardBackreference, "backreference "+v.text(ref.Pos)+" is inside the group it refers to")
+		}
+	}
+}
+
+func (v *validator) report(pos Position, code ErrorCode, message string) {
+	v.errors = append(v.errors, ParseError{
+		Pos:     pos,
+		Code:    code,
+		Text:    v.text(pos),
+		Message: message,
+	})
+}
+
+func (v *validator) text(pos Position) string {
+	return patternText(v.pattern, pos)
+}
+
+// isClassShorthand reports whether e is a class that can't be a range bound.
+func isClassShorthand(e *Expr) bool {
+	switch e.Op {
+	case OpEscapeUni, OpEscapeClass, OpPosixClass:
+		return true
+	default:
+		return false
+	}
+}
+
+// isUnicodeClassName reports whether name is a known Unicode class.
+// If strict is true, only the category short names (like `Lu`)
+// and script names (like `Greek`) are permitted.
+// Otherwise, the long category names and `Key=Value` forms are also accepted.
+func isUnicodeClassName(name string, strict bool) bool {
+	if name == "Any" || unicode.Categories[name] != nil || unicode.Scripts[name] != nil {
+		return true
+	}
+	if strict {
+		return false
+	}
+	if eq := strings.IndexByte(name, '='); eq != -1 {
+		key, value := name[:eq], name[eq+1:]
+		switch key {
+		case "Script", "sc", "Script_Extensions", "scx":
+			return unicode.Scripts[value] != nil
+		case "General_Category", "gc":
+			return unicode.Categories[value] != nil || unicodeCategoryNames[value] != ""
+		default:
+			return false
+		}
+	}
+	return name == "L&" || unicodeCategoryNames[name] != ""
+}
+
+// suggestUnicodeClass returns a known Unicode class name that is the
+// closest to the misspelled name: `greek` => `Greek`, `Cyrilic` => `Cyrillic`.
+// If there is no such name, an empty string is returned.
+func suggestUnicodeClass(name string, strict bool) string {
+	m := newNameMatcher(name)
+	for candidate := range unicode.Categories {
+		m.add(candidate, candidate)
+	}
+	for candidate := range unicode.Scripts {
+		m.add(candidate, candidate)
+	}
+	for long, short := range unicodeCategoryNames {
+		switch {
+		case !strict:
+			m.add(long, long)
+		case unicode.Categories[short] != nil:
+			m.add(long, short)
+		}
+	}
+	return m.best
+}
+
+// nameMatcher finds a known name that is the closest to the misspelled one.
+//
+// Names are compared using their loose forms (see looseUnicodeName)
+// by the edit distance. A candidate is only suggested if the distance
+// doesn't exceed the third of the name length, so the short names
+// are only matched loosely. The ties are resolved alphabetically,
+// so the result doesn't depend on the candidates order.
+type nameMatcher struct {
+	key      string
+	best     string
+	bestDist int
+}
+
+func newNameMatcher(name string) nameMatcher {
+	key := looseUnicodeName(name)
+	return nameMatcher{key: key, bestDist: len(key)/3 + 1}
+}
+
+// add checks the candidate name; suggestion is reported if it's the best match.
+func (m *nameMatcher) add(candidate, suggestion string) {
+	dist := editDistance(m.key, looseUnicodeName(candidate))
+	if dist < m.bestDist || (dist == m.bestDist && m.best != "" && suggestion < m.best) {
+		m.best = suggestion
+		m.bestDist = dist
+	}
+}
+
+// looseUnicodeName normalizes the Unicode class name according to the
+// UAX44-LM3 loose matching rules: case, spaces, '-' and '_' are ignored.
+func looseUnicodeName(name string) string {
+	name = strings.ToLower(name)
+	return strings.NewReplacer(" ", "", "-", "", "_", "").Replace(name)
+}
@@ -0,0 +1,114 @@
+package syntax
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		dialect Dialect
+		pattern string
+		want    []string
+	}{
+		{DialectDefault, `([a-z\d])\y\x2\1`, nil},
+		{DialectRE2, `[a-z\d-]\x20\x{2}\0\A\z`, nil},
+		{DialectRE2, `[\d-a]`, nil},
+		{DialectPCRE, `[\d-a]`, nil},
+		{DialectPCRE, `(?<=a)(?<!b)`, nil},
+
+		{DialectDefault, `[z-a]`, []string{`InvalidRange 1-4 z-a: invalid char range z-a: bounds are reversed`}},
+		{DialectDefault, `[\x{10}-\x01]`, []string{`InvalidRange 1-12 \x{10}-\x01: invalid char range \x{10}-\x01: bounds are reversed`}},
+		{DialectRE2, `[a-\d]`, []string{`InvalidRange 1-5 a-\d: invalid char range a-\d: \d is not a char`}},
+		{DialectDefault, `[\d-a]`, []string{`InvalidRange 1-5 \d-a: invalid char range \d-a: \d is not a char`}},
+		{DialectJava, `x[[:alpha:]-z]`, []string{`InvalidRange 2-13 [:alpha:]-z: invalid char range [:alpha:]-z: [:alpha:] is not a char`}},
+
+		{DialectRE2, `\y`, []string{`InvalidEscape 0-2 \y: invalid escape \y in RE2`}},
+		{DialectRE2, `a\x2`, []string{`InvalidEscape 1-4 \x2: invalid escape \x2: expected 2 hex digits`}},
+		{DialectRE2, `(a)\1`, []string{`InvalidEscape 3-5 \1: backreferences are not supported in RE2`}},
+		{DialectPython, `\d\Z\y`, []string{`InvalidEscape 4-6 \y: invalid escape \y in Python`}},
+
+		{DialectPCRE, `a(?<=)`, []string{`EmptyLookbehind 1-6 (?<=): empty lookbehind assertion`}},
+		{DialectPCRE, `(?<!)`, []string{`EmptyLookbehind 0-5 (?<!): empty lookbehind assertion`}},
+
+		{DialectRE2, `\pL\PN\p{Greek}\p{^Lu}\p{Any}`, nil},
+		{DialectPCRE, `\p{Letter}\p{sc=Greek}\p{General_Category=Lu}\p{L&}`, nil},
+		{DialectRE2, `\p{Foo}`, []string{`UnknownUnicodeClass 0-7 \p{Foo}: unknown Unicode class Foo`}},
+		{DialectRE2, `[\p{greek}]`, []string{`UnknownUnicodeClass 1-10 \p{greek}: unknown Unicode class greek, did you mean Greek?`}},
+		{DialectRE2, `\P{^Letter}`, []string{`UnknownUnicodeClass 0-11 \P{^Letter}: unknown Unicode class Letter, did you mean L?`}},
+		{DialectRE2, `\pX`, []string{`UnknownUnicodeClass 0-3 \pX: unknown Unicode class X`}},
+		{DialectPCRE, `\p{upper-case letter}`, []string{`UnknownUnicodeClass 0-21 \p{upper-case letter}: unknown Unicode class upper-case letter, did you mean Uppercase_Letter?`}},
+		{DialectPCRE, `\p{sc=Foo}`, []string{`UnknownUnicodeClass 0-10 \p{sc=Foo}: unknown Unicode class sc=Foo`}},
+		{DialectRE2, `\p{Cyrilic}`, []string{`UnknownUnicodeClass 0-11 \p{Cyrilic}: unknown Unicode class Cyrilic, did you mean Cyrillic?`}},
+		{DialectPCRE, `\P{greak}`, []string{`UnknownUnicodeClass 0-9 \P{greak}: unknown Unicode class greak, did you mean Greek?`}},
+		{DialectPCRE, `\p{Uppercase_Leter}`, []string{`UnknownUnicodeClass 0-19 \p{Uppercase_Leter}: unknown Unicode class Uppercase_Leter, did you mean Uppercase_Letter?`}},
+
+		{DialectRE2, `[[:alpha:][:^word:]]`, nil},
+		{DialectRE2, `[[:alnum3:]]`, []string{`UnknownPosixClass 1-11 [:alnum3:]: unknown POSIX class alnum3, did you mean alnum?`}},
+		{DialectPCRE, `x[[:^Digit:]]`, []string{`UnknownPosixClass 2-12 [:^Digit:]: unknown POSIX class Digit, did you mean digit?`}},
+		{DialectPCRE, `[[:foo:]]`, []string{`UnknownPosixClass 1-8 [:foo:]: unknown POSIX class foo`}},
+
+		{DialectRE2, `a{2,5}b{1000}c{3,}`, nil},
+		{DialectPCRE, `a{1001}`, nil},
+		{DialectDefault, `a{0}b{1}c{1,1}`, nil},
+		{DialectDefault, `a{5,2}`, []string{`InvalidRepeat 1-6 {5,2}: invalid repeat {5,2}: min is greater than max`}},
+		{DialectRE2, `x(?:ab){2,1001}`, []string{`RepeatTooLarge 7-15 {2,1001}: repeat {2,1001} exceeds the RE2 limit of 1000`}},
+		{DialectPCRE, `a{70000,}`, []string{`RepeatTooLarge 1-9 {70000,}: repeat {70000,} exceeds the PCRE limit of 65535`}},
+
+		{DialectPCRE, `(a)(?:b)\1[\2]\12`, nil},
+		{DialectPCRE, `(a)\2`, []string{`UndefinedBackreference 3-5 \2: backreference \2 refers to a non-existent group`}},
+		{DialectPCRE, `\1(a)`, nil},
+
+		{DialectRE2, `[z-a]\y`, []string{
+			`InvalidRange 1-4 z-a: invalid char range z-a: bounds are reversed`,
+			`InvalidEscape 5-7 \y: invalid escape \y in RE2`,
+		}},
+	}
+
+	for _, test := range tests {
+		runValidateTest(t, test.dialect, test.pattern, test.want, false)
+	}
+}
+
+func TestValidateWarnings(t *testing.T) {
+	tests := []struct {
+		pattern string
+		want    []string
+	}{
+		{`a{0,1}b{2}`, nil},
+		{`a{0}`, []string{`RedundantRepeat 1-4 {0}: a{0} always matches an empty string`}},
+		{`(?:ab){0,0}?`, []string{`RedundantRepeat 6-11 {0,0}: (?:ab){0,0} always matches an empty string`}},
+		{`x[a-z]{1}`, []string{`RedundantRepeat 6-9 {1}: repeat {1} is redundant`}},
+		{`(a)\1`, nil},
+		{`\1(a)`, []string{`ForwardBackreference 0-2 \1: backreference \1 refers to a group that is defined later`}},
+		{`(a\1)`, []string{`ForwardBackreference 2-4 \1: backreference \1 is inside the group it refers to`}},
+		{`a{1,1}{5,2}`, []string{
+			`InvalidRepeat 6-11 {5,2}: invalid repeat {5,2}: min is greater than max`,
+			`RedundantRepeat 1-6 {1,1}: repeat {1,1} is redundant`,
+		}},
+	}
+
+	for _, test := range tests {
+		runValidateTest(t, DialectDefault, test.pattern, test.want, true)
+	}
+}
+
+func runValidateTest(t *testing.T, dialect Dialect, pattern string, want []string, warnings bool) {
+	t.Helper()
+	p := NewParser(&ParserOptions{Dialect: dialect})
+	re, err := p.Parse(pattern)
+	if err != nil {
+		t.Fatalf("parse(%q): %v", pattern, err)
+	}
+	var have []string
+	err = Validate(re, &ValidateOptions{Dialect: dialect, Warnings: warnings})
+	if err != nil {
+		for _, e := range err.(ErrorList) {
+			have = append(have, fmt.Sprintf("%s %d-%d %s: %s", e.Code, e.Pos.Begin, e.Pos.End, e.Text, e.Message))
+		}
+	}
+	if !reflect.DeepEqual(have, want) {
+		t.Errorf("validate(%s, %q):\nhave: %q\nwant: %q", dialect, pattern, have, want)
+	}
+}
@@ -0,0 +1,266 @@
+package syntax
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// vimMagicLevel describes which chars are special in the Vim regexp syntax.
+// See `:help /magic` for more info.
+type vimMagicLevel byte
+
+const (
+	vimVeryMagic   vimMagicLevel = iota // \v
+	vimMagic                            // \m
+	vimNomagic                          // \M
+	vimVeryNomagic                      // \V
+)
+
+// scanVim is a Vim dialect version of scan.
+//
+// Vim patterns are mapped to the same tokens (and AST ops) where possible.
+// `\(re\)` becomes OpCapture, `\%(re\)` becomes OpGroup, `\{n,m}` becomes OpRepeat
+// and so on. Vim-specific escapes like `\zs` and `\<` are parsed as OpEscapeChar.
+// `re\@=` and similar postfix forms are parsed as lookarounds and atomic groups.
+func (l *lexer) scanVim() {
+	for l.pos < len(l.input) {
+		ch := l.input[l.pos]
+		if ch >= utf8.RuneSelf {
+			_, size := utf8.DecodeRuneInString(l.input[l.pos:])
+			l.pushTok(tokChar, size)
+			l.maybeInsertConcat()
+			continue
+		}
+
+		escaped := ch == '\\'
+		width := 1
+		if escaped {
+			if l.pos+1 >= len(l.input) {
+				throw(newPos(l.pos, l.pos+1), ErrTrailingBackslash, `unexpected end of pattern: trailing '\'`)
+			}
+			ch = l.input[l.pos+1]
+			width = 2
+		}
+
+		if !l.isVimSpecial(ch, escaped) {
+			if escaped {
+				l.scanVimEscape()
+			} else {
+				l.pushTok(tokChar, 1)
+			}
+			l.maybeInsertConcat()
+			continue
+		}
+
+		switch ch {
+		case '(':
+			l.pushTok(tokLparen, width)
+		case ')':
+			l.pushTok(tokRparen, width)
+		case '|':
+			l.pushTok(tokPipe, width)
+		case '+':
+			l.pushTok(tokPlus, width)
+		case '=', '?':
+			l.pushTok(tokQuestion, width)
+		case '*':
+			if l.vimHasOperand() {
+				l.pushTok(tokStar, width)
+			} else {
+				l.pushTok(tokChar, width)
+			}
+		case '.':
+			l.pushTok(tokDot, width)
+		case '~':
+			l.pushTok(tokChar, width)
+		case '[':
+			l.scanVimCharClass(width)
+		case '{':
+			j := strings.IndexByte(l.input[l.pos+width:], '}')
+			if j < 0 {
+				throw(newPos(l.pos, l.pos+width), ErrUnterminatedRepeat, "can't find closing '}'")
+			}
+			l.pushTok(tokRepeat, width+j+len("}"))
+		case '@':
+			l.scanVimLookaround(width)
+		case '%':
+			if l.byteAt(l.pos+width) == '(' {
+				l.pushTok(tokLparenGroup, width+len("("))
+			} else {
+				l.pushTok(tokEscapeChar, width+1)
+			}
+		case '<', '>':
+			l.pushTok(tokEscapeChar, width)
+		case '^':
+			if l.vimIsLineStart() {
+				l.pushTok(tokCaret, width)
+			} else {
+				l.pushTok(tokChar, width)
+			}
+		case '$':
+			if l.vimIsLineEnd(l.pos + width) {
+				l.pushTok(tokDollar, width)
+			} else {
+				l.pushTok(tokChar, width)
+			}
+		}
+		l.maybeInsertConcat()
+	}
+}
+
+// isVimSpecial reports whether ch has a special meaning
+// with the current magic level.
+func (l *lexer) isVimSpecial(ch byte, escaped bool) bool {
+	switch ch {
+	case '^', '$':
+		return !escaped
+	case '.', '*', '[', '~':
+		// Special without backslash unless 'nomagic'.
+		return escaped == (l.vimMagic >= vimNomagic)
+	case '(', ')', '|', '+', '=', '?', '{', '@', '%', '<', '>':
+		// Special with backslash unless 'very magic'.
+		return escaped == (l.vimMagic != vimVeryMagic)
+	default:
+		return false
+	}
+}
+
+func (l *lexer) scanVimEscape() {
+	ch := l.input[l.pos+1]
+	if ch >= utf8.RuneSelf {
+		_, size := utf8.DecodeRuneInString(l.input[l.pos+1:])
+		l.pushTok(tokEscapeChar, len(`\`)+size)
+		return
+	}
+	switch ch {
+	case 'v':
+		l.vimMagic = vimVeryMagic
+	case 'm':
+		l.vimMagic = vimMagic
+	case 'M':
+		l.vimMagic = vimNomagic
+	case 'V':
+		l.vimMagic = vimVeryNomagic
+	case 'z', '_':
+		// Two-letter escapes like `\zs` and `\_s`.
+		if ch == '_' && l.byteAt(l.pos+2) == '[' {
+			// `\_[]` is a char class that also matches a newline.
+			l.scanVimCharClass(len(`\_[`))
+			return
+		}
+		if l.pos+2 >= len(l.input) {
+			throw(newPos(l.pos, l.pos+2), ErrIncompleteEscape, "unexpected end of pattern: incomplete '\\"+string(ch)+"' escape")
+		}
+		l.pushTok(tokEscapeChar, len(`\zs`))
+		return
+	}
+	kind := tokEscapeChar
+	if ch == '\\' || ch == '/' || l.isVimSpecial(ch, false) || l.isVimSpecial(ch, true) {
+		kind = tokEscapeMeta
+	}
+	l.pushTok(kind, 2)
+}
+
+func (l *lexer) scanVimCharClass(width int) {
+	if l.byteAt(l.pos+width) == '^' {
+		l.pushTok(tokLbracketCaret, width+1)
+	} else {
+		l.pushTok(tokLbracket, width)
+	}
+	l.scanCharClass()
+}
+
+func (l *lexer) scanVimLookaround(width int) {
+	j := l.pos + width
+	for isDigit(l.byteAt(j)) {
+		j++ // Lookbehind byte limit, like in `\@123<=`
+	}
+	kind := tokNone
+	switch l.byteAt(j) {
+	case '=':
+		kind = tokPositiveLookaheadPostfix
+	case '!':
+		kind = tokNegativeLookaheadPostfix
+	case '>':
+		kind = tokAtomicPostfix
+	case '<':
+		j++
+		switch l.byteAt(j) {
+		case '=':
+			kind = tokPositiveLookbehindPostfix
+		case '!':
+			kind = tokNegativeLookbehindPostfix
+		}
+	}
+	if kind == tokNone {
+		throw(newPos(l.pos, j), ErrInvalidLookaround, "expected '=', '!', '>', '<=' or '<!' after '@'")
+	}
+	l.pushTok(kind, j+1-l.pos)
+}
+
+// vimHasOperand reports whether there is an element that can be quantified.
+// A star at the beginning of a pattern or a group matches a literal '*' char.
+func (l *lexer) vimHasOperand() bool {
+	if len(l.tokens) == 0 {
+		return false
+	}
+	last := l.tokens[len(l.tokens)-1]
+	if last.kind == tokCaret {
+		// `^*` matches a '*' at the line start.
+		return false
+	}
+	return concatTable[last.kind]&concatX == 0 && !l.isVimMagicSwitch(last)
+}
+
+// vimIsLineStart reports whether '^' at the current position is an anchor.
+func (l *lexer) vimIsLineStart() bool {
+	for i := len(l.tokens) - 1; i >= 0; i-- {
+		tok := l.tokens[i]
+		switch tok.kind {
+		case tokConcat:
+			continue
+		case tokEscapeChar:
+			if !l.isVimMagicSwitch(tok) {
+				return false
+			}
+		case tokLparen, tokLparenGroup, tokPipe:
+			return true
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// vimIsLineEnd reports whether '$' that ends before pos is an anchor.
+func (l *lexer) vimIsLineEnd(pos int) bool {
+	if pos >= len(l.input) {
+		return true
+	}
+	if l.vimMagic == vimVeryMagic {
+		return l.input[pos] == '|' || l.input[pos] == ')'
+	}
+	if l.input[pos] != '\\' {
+		return false
+	}
+	switch l.byteAt(pos + 1) {
+	case '|', ')', '&', 'n':
+		return true
+	default:
+		return false
+	}
+}
+
+// isVimMagicSwitch reports whether tok is one of the `\v`, `\m`, `\M` or `\V` escapes.
+// Other escapes, like `%V` in the very magic mode, are not matched.
+func (l *lexer) isVimMagicSwitch(tok token) bool {
+	if tok.kind != tokEscapeChar {
+		return false
+	}
+	switch l.input[tok.pos.Begin:tok.pos.End] {
+	case `\v`, `\m`, `\M`, `\V`:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,51 @@
+package syntax
+
+// Walk traverses re AST in depth-first pre-order.
+// If fn returns false, the visited expression args are not traversed.
+//
+// Note that OpString args are visited as well.
+func Walk(re *Regexp, fn func(*Expr) bool) {
+	WalkExpr(&re.Expr, fn)
+}
+
+// WalkPost traverses re AST in depth-first post-order:
+// expression args are visited before the expression itself.
+func WalkPost(re *Regexp, fn func(*Expr)) {
+	WalkExprPost(&re.Expr, fn)
+}
+
+// WalkExpr is like Walk, but it traverses the e subtree.
+func WalkExpr(e *Expr, fn func(*Expr) bool) {
+	if !fn(e) {
+		return
+	}
+	for i := range e.Args {
+		WalkExpr(&e.Args[i], fn)
+	}
+}
+
+// WalkExprPost is like WalkPost, but it traverses the e subtree.
+func WalkExprPost(e *Expr, fn func(*Expr)) {
+	for i := range e.Args {
+		WalkExprPost(&e.Args[i], fn)
+	}
+	fn(e)
+}
+
+// WalkWithPath is like Walk, but fn also receives the visited expression ancestors.
+// path[0] is the root expression and path[len(path)-1] is the parent of e.
+//
+// path slice is reused during the traversal, copy it to retain it after fn returns.
+func WalkWithPath(re *Regexp, fn func(e *Expr, path []*Expr) bool) {
+	walkWithPath(&re.Expr, make([]*Expr, 0, 8), fn)
+}
+
+func walkWithPath(e *Expr, path []*Expr, fn func(*Expr, []*Expr) bool) {
+	if !fn(e, path) {
+		return
+	}
+	path = append(path, e)
+	for i := range e.Args {
+		walkWithPath(&e.Args[i], path, fn)
+	}
+}
@@ -0,0 +1,110 @@
+package syntax
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestWalk(t *testing.T) {
+	tests := []struct {
+		pattern string
+		pre     string
+		post    string
+	}{
+		{`a`, `Char`, `Char`},
+		{`ab`, `Literal Char Char`, `Char Char Literal`},
+		{`a|b*`, `Alt Char Star Char`, `Char Char Star Alt`},
+		{`(?:x)+`, `Plus Group Char`, `Char Group Plus`},
+		{`[a-z]`, `CharClass CharRange Char Char`, `Char Char CharRange CharClass`},
+		{`x(?P<n>y)`, `Concat Char NamedCapture Char String`, `Char Char String NamedCapture Concat`},
+	}
+
+	p := NewParser(nil)
+	for _, test := range tests {
+		re, err := p.Parse(test.pattern)
+		if err != nil {
+			t.Fatalf("parse(%q): %v", test.pattern, err)
+		}
+
+		var ops []string
+		Walk(re, func(e *Expr) bool {
+			ops = append(ops, e.Op.String())
+			return true
+		})
+		if have := strings.Join(ops, " "); have != test.pre {
+			t.Errorf("walk(%q):\nhave: %s\nwant: %s", test.pattern, have, test.pre)
+		}
+
+		ops = ops[:0]
+		WalkPost(re, func(e *Expr) {
+			ops = append(ops, e.Op.String())
+		})
+		if have := strings.Join(ops, " "); have != test.post {
+			t.Errorf("walk post(%q):\nhave: %s\nwant: %s", test.pattern, have, test.post)
+		}
+	}
+}
+
+func TestWalkSkip(t *testing.T) {
+	re, err := NewParser(nil).Parse(`a(b|c)[d]`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ops []string
+	Walk(re, func(e *Expr) bool {
+		ops = append(ops, e.Op.String())
+		return e.Op != OpCapture && e.Op != OpCharClass
+	})
+	have := strings.Join(ops, " ")
+	want := `Concat Char Capture CharClass`
+	if have != want {
+		t.Errorf("walk with skip:\nhave: %s\nwant: %s", have, want)
+	}
+}
+
+func TestWalkWithPath(t *testing.T) {
+	re, err := NewParser(nil).Parse(`[a](?<=x[b]|y)`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Find all char classes that are inside a lookbehind.
+	var found []string
+	WalkWithPath(re, func(e *Expr, path []*Expr) bool {
+		if e.Op != OpCharClass {
+			return true
+		}
+		for _, parent := range path {
+			if parent.Op == OpPositiveLookbehind {
+				found = append(found, e.Value)
+			}
+		}
+		return true
+	})
+	if len(found) != 1 || found[0] != "[b]" {
+		t.Errorf("unexpected result: %q", found)
+	}
+
+	var paths []string
+	WalkWithPath(re, func(e *Expr, path []*Expr) bool {
+		if e.Op != OpChar {
+			return true
+		}
+		ops := make([]string, len(path))
+		for i, parent := range path {
+			ops[i] = parent.Op.String()
+		}
+		paths = append(paths, e.Value+": "+strings.Join(ops, " "))
+		return true
+	})
+	have := strings.Join(paths, "\n")
+	want := strings.Join([]string{
+		"a: Concat CharClass",
+		"x: Concat PositiveLookbehind Alt Concat",
+		"b: Concat PositiveLookbehind Alt Concat CharClass",
+		"y: Concat PositiveLookbehind Alt",
+	}, "\n")
+	if have != want {
+		t.Errorf("paths mismatch:\nhave:\n%s\nwant:\n%s", have, want)
+	}
+}